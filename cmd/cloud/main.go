@@ -0,0 +1,134 @@
+/*
+Copyright 2022 jevv k.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"os"
+	"time"
+
+	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
+	// to ensure that exec-entrypoint and run can make use of them.
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	edgev1alpha1 "edge.jevv.dev/pkg/apis/edge/v1alpha1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+
+	//+kubebuilder:scaffold:imports
+
+	"edge.jevv.dev/pkg/heartbeat"
+	"edge.jevv.dev/pkg/webhooks"
+)
+
+var (
+	scheme   = runtime.NewScheme()
+	setupLog = ctrl.Log.WithName("setup")
+)
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+
+	utilruntime.Must(servingv1.AddToScheme(scheme))
+	utilruntime.Must(edgev1alpha1.AddToScheme(scheme))
+	//+kubebuilder:scaffold:scheme
+}
+
+func main() {
+	var metricsAddr string
+	var probeAddr string
+
+	var unreachableAfter time.Duration
+	var enableWebhooks bool
+
+	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
+	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", true, "Serve the admission webhooks, which need a certificate in the cert dir of the webhook server.")
+	flag.DurationVar(&unreachableAfter, "unreachable-after", heartbeat.UnreachableAfter, "How long an EdgeCluster can go without reporting before it's marked as unreachable.")
+
+	opts := zap.Options{
+		Development: true,
+	}
+	opts.BindFlags(flag.CommandLine)
+	flag.Parse()
+
+	ctx := ctrl.SetupSignalHandler()
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+
+	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+		Scheme:                        scheme,
+		MetricsBindAddress:            metricsAddr,
+		Port:                          9443,
+		HealthProbeBindAddress:        probeAddr,
+		LeaderElection:                true,
+		LeaderElectionID:              "c7a1f0e2.cloud.edge.jevv.dev",
+		LeaderElectionReleaseOnCancel: true,
+	})
+
+	if err != nil {
+		setupLog.Error(err, "unable to start manager")
+		os.Exit(1)
+	}
+
+	if err := mgr.Add(&heartbeat.EdgeClusterMonitor{
+		Client:           mgr.GetClient(),
+		Log:              mgr.GetLogger().WithName("edgecluster-monitor"),
+		UnreachableAfter: unreachableAfter,
+	}); err != nil {
+		setupLog.Error(err, "Unable to set up EdgeCluster monitor.")
+		os.Exit(1)
+	}
+
+	if enableWebhooks {
+		if err := (&webhooks.EdgeClusterValidator{}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "Unable to create webhook.", "webhook", "EdgeCluster")
+			os.Exit(1)
+		}
+
+		if err := (&webhooks.KServiceValidator{
+			Reader: mgr.GetClient(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "Unable to create webhook.", "webhook", "Service.serving.knative.dev")
+			os.Exit(1)
+		}
+	}
+	//+kubebuilder:scaffold:builder
+
+	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
+		setupLog.Error(err, "Unable to set up health check.")
+		os.Exit(1)
+	}
+	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+		setupLog.Error(err, "Unable to set up ready check.")
+		os.Exit(1)
+	}
+
+	setupLog.Info("starting manager")
+	if err := mgr.Start(ctx); err != nil {
+		setupLog.Error(err, "Encountered fatal error running manager.")
+		os.Exit(1)
+	}
+}
@@ -18,6 +18,7 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
 	"strings"
 
@@ -27,11 +28,13 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
 
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/cluster"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -41,14 +44,23 @@ import (
 
 	//+kubebuilder:scaffold:imports
 
+	"edge.jevv.dev/pkg/controllers"
 	"edge.jevv.dev/pkg/controllers/edge"
+	"edge.jevv.dev/pkg/controllers/utils"
+	"edge.jevv.dev/pkg/heartbeat"
 	"edge.jevv.dev/pkg/workoffload"
+	"edge.jevv.dev/pkg/workoffload/health"
+	"edge.jevv.dev/pkg/workoffload/history"
+	"edge.jevv.dev/pkg/workoffload/prometheus/usage"
 	"edge.jevv.dev/pkg/workoffload/store"
 )
 
 var (
 	scheme   = runtime.NewScheme()
 	setupLog = ctrl.Log.WithName("setup")
+
+	// overridden at build time (-ldflags "-X main.version=...")
+	version = "development"
 )
 
 func init() {
@@ -66,27 +78,68 @@ func main() {
 
 	var proxyImage string
 	var environments string
+	var clusterName string
 
 	var remoteUrl string
 	var prometheusUrl string
+	var upstreamsList string
 
 	var httpProxy string
 	var httpsProxy string
 	var noProxy string
 
+	var otlpEndpoint string
+
+	var proxyCredentials string
+	var proxyTokenHeader string
+
+	var driftPolicy string
+	var driftPolicies string
+
+	var snapshotName string
+	var snapshotNamespace string
+
+	var peerUrl string
+	var peerOffload bool
+	var peerMaxUtilization int64
+
+	var trafficStoreBackend string
+	var trafficStoreName string
+	var trafficStoreNamespace string
+
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 
 	flag.StringVar(&proxyImage, "proxy-image", "", "The image of the proxy component.")
 	flag.StringVar(&environments, "envs", "", "A list of comma separated list of environments. The edge cluster will only listen and propagate to these environments.")
+	flag.StringVar(&clusterName, "cluster-name", "", "The name of the EdgeCluster in the remote cluster. The edge cluster will report its status to it.")
 
 	flag.StringVar(&remoteUrl, "remote-url", "", "The url of the remote cluster.")
 	flag.StringVar(&prometheusUrl, "prometheus-url", "", "The url of the Prometheus instance.")
+	flag.StringVar(&upstreamsList, "upstreams", "", "Comma separated name=priority=url remote clusters mirrored from besides the primary one, which has priority 0. The priority can be negative and the url is optional. Their kubeconfigs are read from "+edge.UpstreamsConfigPath+".")
 
 	flag.StringVar(&httpProxy, "http-proxy", "", "Address of http proxy")
 	flag.StringVar(&httpsProxy, "https-proxy", "", "Address of https proxy")
 	flag.StringVar(&noProxy, "no-proxy", "", "Skip proxy for domains or ips")
 
+	flag.StringVar(&otlpEndpoint, "otlp-endpoint", "", "The OTLP/HTTP endpoint the edge proxies export their traces to.")
+	flag.StringVar(&proxyCredentials, "proxy-credentials-secret", "", "The secret in the system namespace with the credentials the edge proxies use for the remote cluster.")
+	flag.StringVar(&proxyTokenHeader, "proxy-token-header", "", "The header the edge proxies send their bearer token in. Defaults to X-Edge-Remote-Token.")
+
+	flag.StringVar(&driftPolicy, "drift-policy", string(edge.DriftPolicyRevert), "What happens to local changes of mirrored resources (revert, keep-local or alert-only).")
+	flag.StringVar(&driftPolicies, "drift-policies", "", "Comma separated drift policies by kind, e.g. ConfigMap=keep-local,Service.serving.knative.dev=alert-only.")
+
+	flag.StringVar(&snapshotName, "snapshot-name", "knative-edge-snapshot", "The prefix of the secrets keeping a snapshot of the remote objects, used while the remote cluster is unreachable. Empty disables the snapshot.")
+	flag.StringVar(&snapshotNamespace, "snapshot-namespace", controllers.SystemNamespace, "The namespace of the secrets keeping a snapshot of the remote objects.")
+
+	flag.StringVar(&peerUrl, "peer-url", "", "The url the other edge clusters of the region offload to, e.g. the ingress of this edge cluster. They don't offload to it when empty.")
+	flag.BoolVar(&peerOffload, "peer-offload", false, "Offload to the other edge clusters of the region with spare capacity before the remote cluster.")
+	flag.Int64Var(&peerMaxUtilization, "peer-max-utilization", edge.DefaultPeerMaxUtilization, "Edge clusters using more of their cpu or memory, in percent, aren't offloaded to.")
+
+	flag.StringVar(&trafficStoreBackend, "traffic-store", "memory", "Where to keep the traffic split of each service (memory or configmap). The configmap store keeps it across restarts and needs access to its config map.")
+	flag.StringVar(&trafficStoreName, "traffic-store-name", "knative-edge-traffic", "The name of the config map backing the traffic split store.")
+	flag.StringVar(&trafficStoreNamespace, "traffic-store-namespace", controllers.SystemNamespace, "The namespace of the config map backing the traffic split store.")
+
 	opts := zap.Options{
 		Development: true,
 	}
@@ -115,27 +168,141 @@ func main() {
 
 	envs := strings.Split(environments, ",")
 
-	cluster := edge.NewRemoteClusterOrDie(func(opts *cluster.Options) {
+	drift := &edge.DriftTracker{}
+
+	if drift.DefaultPolicy, err = edge.ParseDriftPolicy(driftPolicy); err != nil {
+		setupLog.Error(err, "Invalid drift policy.")
+		os.Exit(1)
+	}
+
+	if drift.Policies, err = edge.ParseDriftPolicies(driftPolicies); err != nil {
+		setupLog.Error(err, "Invalid drift policies.")
+		os.Exit(1)
+	}
+
+	upstreams, err := edge.ParseUpstreams(upstreamsList)
+
+	if err != nil {
+		setupLog.Error(err, "Invalid upstreams.")
+		os.Exit(1)
+	}
+
+	remoteClusterOpts := func(opts *cluster.Options) {
 		opts.NewCache = edge.EnvScopedCache(envs)
 		opts.Scheme = scheme
-	})
+	}
+
+	proxy := utils.ProxyFunc(httpProxy, httpsProxy, noProxy)
 
-	if err = mgr.Add(cluster); err != nil {
+	if proxy != nil {
+		setupLog.Info("Connecting to the remote cluster through a proxy.", "httpProxy", httpProxy, "httpsProxy", httpsProxy, "noProxy", noProxy)
+	}
+
+	// adds the remote cluster to the manager, behind a snapshot of its objects if enabled
+	addRemoteCluster := func(remoteCluster cluster.Cluster, snapshotPrefix string) (cluster.Cluster, error) {
+		if snapshotPrefix == "" {
+			return remoteCluster, mgr.Add(remoteCluster)
+		}
+
+		snapshot := &edge.RemoteSnapshot{
+			Log:           mgr.GetLogger().WithName("remote-snapshot"),
+			Client:        mgr.GetClient(),
+			Reader:        mgr.GetAPIReader(),
+			Scheme:        scheme,
+			RemoteCluster: remoteCluster,
+			Name:          types.NamespacedName{Name: snapshotPrefix, Namespace: snapshotNamespace},
+			Kinds: []client.ObjectList{
+				&servingv1.ServiceList{},
+				&corev1.ConfigMapList{},
+				&corev1.SecretList{},
+				&corev1.NamespaceList{},
+				&edgev1alpha1.EdgeProxyPolicyList{},
+				&edgev1alpha1.MirrorPolicyList{},
+				&edgev1alpha1.MirrorTransformationList{},
+			},
+			Elected: mgr.Elected(),
+		}
+
+		if err := mgr.Add(snapshot); err != nil {
+			return nil, err
+		}
+
+		offlineCluster := edge.NewOfflineCluster(remoteCluster, snapshot)
+
+		return offlineCluster, mgr.Add(offlineCluster.Runnable())
+	}
+
+	cluster := edge.NewRemoteClusterWithProxyOrDie(proxy, remoteClusterOpts)
+
+	if cluster, err = addRemoteCluster(cluster, snapshotName); err != nil {
 		setupLog.Error(err, "Unable to setup remote cluster.")
 		os.Exit(1)
 	}
 
-	trafficStore := store.Store{
-		Log: mgr.GetLogger().WithName("edge-traffic-store"),
+	if len(upstreams) > 0 {
+		for i := range upstreams {
+			upstream := &upstreams[i]
+			upstreamSnapshotName := ""
+
+			if snapshotName != "" {
+				upstreamSnapshotName = fmt.Sprintf("%s-%s", snapshotName, upstream.Name)
+			}
+
+			upstream.Cluster, err = addRemoteCluster(edge.NewUpstreamClusterOrDie(upstream.Name, proxy, remoteClusterOpts), upstreamSnapshotName)
+
+			if err != nil {
+				setupLog.Error(err, "Unable to setup upstream cluster.", "upstream", upstream.Name)
+				os.Exit(1)
+			}
+		}
+
+		setupLog.Info("Mirroring from several upstream clusters.", "upstreams", upstreamsList)
+
+		cluster = edge.NewMultiCluster(edge.Upstream{Cluster: cluster}, upstreams...)
 	}
 
-	if err = mgr.Add(&trafficStore); err != nil {
+	if err = mgr.Add(&edge.RemoteClusterProbe{
+		Log:           mgr.GetLogger().WithName("remote-cluster-probe"),
+		RemoteCluster: cluster,
+	}); err != nil {
+		setupLog.Error(err, "Unable to setup remote cluster probe.")
+		os.Exit(1)
+	}
+
+	var trafficStore store.TrafficStore
+
+	switch trafficStoreBackend {
+	case "memory":
+		trafficStore = &store.Store{
+			Log: mgr.GetLogger().WithName("edge-traffic-store"),
+		}
+	case "configmap":
+		trafficStore = &store.ConfigMapStore{
+			Store: store.Store{
+				Log: mgr.GetLogger().WithName("edge-traffic-store"),
+			},
+			Client: mgr.GetClient(),
+			Reader: mgr.GetAPIReader(),
+			Name:   types.NamespacedName{Name: trafficStoreName, Namespace: trafficStoreNamespace},
+		}
+	default:
+		setupLog.Error(fmt.Errorf("unknown traffic split store: %s", trafficStoreBackend), "Unable to setup traffic split store.")
+		os.Exit(1)
+	}
+
+	if err = mgr.Add(trafficStore); err != nil {
 		setupLog.Error(err, "Unable to setup traffic split store.")
 		os.Exit(1)
 	}
 
 	hasEdgeLabelPredicate := edge.HasEdgeSyncLabelPredicate(envs)
 
+	transformer := &edge.Transformer{
+		Scheme:        scheme,
+		RemoteCluster: cluster,
+		ClusterName:   clusterName,
+	}
+
 	if err = (&edge.NamespaceReconciler{
 		Client:        mgr.GetClient(),
 		Scheme:        mgr.GetScheme(),
@@ -143,6 +310,8 @@ func main() {
 		Recorder:      mgr.GetEventRecorderFor("namespace-controller"),
 		RemoteCluster: cluster,
 		Envs:          envs,
+		Transformer:   transformer,
+		Drift:         drift,
 	}).SetupWithManager(mgr, hasEdgeLabelPredicate); err != nil {
 		setupLog.Error(err, "Unable to create controller.", "controller", "namespace")
 		os.Exit(1)
@@ -155,6 +324,8 @@ func main() {
 		Recorder:      mgr.GetEventRecorderFor("secret-controller"),
 		RemoteCluster: cluster,
 		Envs:          envs,
+		Transformer:   transformer,
+		Drift:         drift,
 	}).SetupWithManager(mgr, hasEdgeLabelPredicate); err != nil {
 		setupLog.Error(err, "Unable to create controller.", "controller", "secret")
 		os.Exit(1)
@@ -167,11 +338,27 @@ func main() {
 		Recorder:      mgr.GetEventRecorderFor("configmap-controller"),
 		RemoteCluster: cluster,
 		Envs:          envs,
+		Transformer:   transformer,
+		Drift:         drift,
 	}).SetupWithManager(mgr, hasEdgeLabelPredicate); err != nil {
 		setupLog.Error(err, "Unable to create controller.", "controller", "configmap")
 		os.Exit(1)
 	}
 
+	if err = (&edge.MirrorPolicyReconciler{
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		Log:           mgr.GetLogger().WithName("mirrorpolicy-controller"),
+		Recorder:      mgr.GetEventRecorderFor("mirrorpolicy-controller"),
+		RemoteCluster: cluster,
+		Envs:          envs,
+		Transformer:   transformer,
+		Drift:         drift,
+	}).SetupWithManager(mgr, hasEdgeLabelPredicate); err != nil {
+		setupLog.Error(err, "Unable to create controller.", "controller", "mirrorpolicy")
+		os.Exit(1)
+	}
+
 	if err = (&edge.KServiceReconciler{
 		Client:        mgr.GetClient(),
 		Scheme:        mgr.GetScheme(),
@@ -181,14 +368,41 @@ func main() {
 		RemoteUrl:     remoteUrl,
 		ProxyImage:    proxyImage,
 		Envs:          envs,
-		Store:         &trafficStore,
+		Transformer:   transformer,
+		Drift:         drift,
+		Store:         trafficStore,
 		HttpProxy:     httpProxy,
 		HttpsProxy:    httpsProxy,
 		NoProxy:       noProxy,
+		OtlpEndpoint:  otlpEndpoint,
+
+		ProxyCredentials: proxyCredentials,
+		ProxyTokenHeader: proxyTokenHeader,
+		Reader:           mgr.GetAPIReader(),
+
+		ClusterName:        clusterName,
+		PeerOffload:        peerOffload,
+		PeerMaxUtilization: peerMaxUtilization,
 	}).SetupWithManager(mgr, hasEdgeLabelPredicate); err != nil {
 		setupLog.Error(err, "Unable to create controller.", "controller", "kservice")
 		os.Exit(1)
 	}
+
+	if clusterName != "" {
+		if err = (&edge.KServiceStatusReconciler{
+			Client:        mgr.GetClient(),
+			Scheme:        mgr.GetScheme(),
+			Log:           mgr.GetLogger().WithName("kservice-status-controller"),
+			Recorder:      mgr.GetEventRecorderFor("kservice-status-controller"),
+			RemoteCluster: cluster,
+			ClusterName:   clusterName,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "Unable to create controller.", "controller", "kservice-status")
+			os.Exit(1)
+		}
+	} else {
+		setupLog.Info("No cluster name provided, Knative Service status won't be reported to the remote cluster.")
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -208,18 +422,50 @@ func main() {
 		os.Exit(1)
 	}
 
+	decisionHistory := &history.DecisionHistory{}
+
+	// served next to the metrics
+	for _, path := range []string{history.HandlerPath, history.HandlerPath + "/"} {
+		if err := mgr.AddMetricsExtraHandler(path, decisionHistory.Handler()); err != nil {
+			setupLog.Error(err, "Unable to set up work offload decision history endpoint.")
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.Add(&workoffload.EdgeWorkOffload{
 		Client:        mgr.GetClient(),
 		MetricsClient: metricsClient,
 		Envs:          envs,
 		Log:           mgr.GetLogger().WithName("edge-traffic"),
-		Store:         &trafficStore,
+		Store:         trafficStore,
 		PrometheusUrl: prometheusUrl,
+		Recorder:      mgr.GetEventRecorderFor("edge-traffic"),
+		History:       decisionHistory,
+		ProxyHealth:   health.NewProxyHealthChecker(mgr.GetLogger().WithName("proxy-health"), mgr.GetClient()),
 	}); err != nil {
 		setupLog.Error(err, "Unable to set up edge traffic splitter.")
 		os.Exit(1)
 	}
 
+	if err := mgr.Add(&heartbeat.EdgeHeartbeat{
+		Client:        mgr.GetClient(),
+		Log:           mgr.GetLogger().WithName("edge-heartbeat"),
+		RemoteCluster: cluster,
+		Store:         trafficStore,
+		Drift:         drift,
+		ClusterName:   clusterName,
+		Version:       version,
+		PeerUrl:       peerUrl,
+		Usage: &usage.Collector{
+			Client:        mgr.GetClient(),
+			Log:           mgr.GetLogger().WithName("edge-heartbeat"),
+			MetricsClient: metricsClient,
+		},
+	}); err != nil {
+		setupLog.Error(err, "Unable to set up edge heartbeat.")
+		os.Exit(1)
+	}
+
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "Encountered fatal error running manager.")
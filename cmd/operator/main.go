@@ -44,6 +44,7 @@ import (
 	edgev1alpha1 "edge.jevv.dev/pkg/apis/edge/v1alpha1"
 	operatorv1alpha1 "edge.jevv.dev/pkg/apis/operator/v1alpha1"
 	operatorcontrollers "edge.jevv.dev/pkg/controllers/operator"
+	"edge.jevv.dev/pkg/webhooks"
 	appsv1 "k8s.io/api/apps/v1"
 	//+kubebuilder:scaffold:imports
 )
@@ -91,6 +92,8 @@ func main() {
 	var proxyImage string
 	var controllerImage string
 
+	var enableWebhooks bool
+
 	defaultSyncPeriod := 1 * time.Minute
 	remoteSyncPeriod := 5 * time.Minute
 
@@ -105,6 +108,8 @@ func main() {
 	flag.StringVar(&proxyImage, "proxy-image", "", "The image of the proxy component.")
 	flag.StringVar(&controllerImage, "controller-image", "", "The image of the controller component.")
 
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", true, "Serve the admission webhooks, which need a certificate in the cert dir of the webhook server.")
+
 	opts := zap.Options{
 		Development: true,
 	}
@@ -175,6 +180,15 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "Edge")
 		os.Exit(1)
 	}
+
+	if enableWebhooks {
+		if err = (&webhooks.KnativeEdgeWebhook{
+			Reader: mgr.GetAPIReader(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "KnativeEdge")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
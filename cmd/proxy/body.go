@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// countingReader counts the bytes of a request body as they are streamed to the remote cluster.
+// The transport can keep streaming the body after the response came back, so the count is only
+// added to the counter once the body is read to the end or closed.
+type countingReader struct {
+	io.ReadCloser
+	counter prometheus.Counter
+	count   int64
+	once    sync.Once
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	atomic.AddInt64(&c.count, int64(n))
+
+	if err == io.EOF {
+		c.report()
+	}
+
+	return n, err
+}
+
+func (c *countingReader) Close() error {
+	err := c.ReadCloser.Close()
+	c.report()
+
+	return err
+}
+
+func (c *countingReader) report() {
+	c.once.Do(func() {
+		if c.counter != nil {
+			c.counter.Add(float64(atomic.LoadInt64(&c.count)))
+		}
+	})
+}
+
+func isBodyTooLarge(err error) bool {
+	var maxBytesError *http.MaxBytesError
+	return errors.As(err, &maxBytesError)
+}
+
+// shouldFlushImmediately is true for responses which are consumed while they're streamed, like
+// server-sent events, or whose length isn't known in advance.
+func shouldFlushImmediately(res *http.Response) bool {
+	if res.ContentLength == -1 {
+		return true
+	}
+
+	contentType := res.Header.Get("Content-Type")
+
+	return strings.HasPrefix(strings.ToLower(contentType), "text/event-stream")
+}
+
+// copyResponse streams the response body to the client, flushing every chunk if needed.
+func copyResponse(w http.ResponseWriter, body io.Reader, flush bool) (int64, error) {
+	flusher, canFlush := w.(http.Flusher)
+
+	if !flush || !canFlush {
+		return io.Copy(w, body)
+	}
+
+	var written int64
+	buf := make([]byte, 32*1024)
+
+	for {
+		n, readErr := body.Read(buf)
+
+		if n > 0 {
+			m, writeErr := w.Write(buf[:n])
+			written += int64(m)
+
+			if writeErr != nil {
+				return written, writeErr
+			}
+
+			flusher.Flush()
+		}
+
+		if readErr == io.EOF {
+			return written, nil
+		}
+
+		if readErr != nil {
+			return written, readErr
+		}
+	}
+}
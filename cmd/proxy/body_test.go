@@ -0,0 +1,69 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var _ = Describe("counting reader", func() {
+	var counter prometheus.Counter
+
+	counted := func() float64 {
+		return counterValue(counter)
+	}
+
+	BeforeEach(func() {
+		counter = prometheus.NewCounter(prometheus.CounterOpts{Name: "test_request_bytes_total"})
+	})
+
+	It("reports the bytes once the body is read to the end", func() {
+		reader := &countingReader{ReadCloser: io.NopCloser(strings.NewReader("hello world")), counter: counter}
+
+		buf := make([]byte, 5)
+		_, err := reader.Read(buf)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(counted()).To(BeZero())
+
+		_, err = io.ReadAll(reader)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(counted()).To(BeEquivalentTo(11))
+
+		Expect(reader.Close()).To(Succeed())
+		Expect(counted()).To(BeEquivalentTo(11))
+	})
+
+	It("reports the bytes read so far when the body is closed early", func() {
+		reader := &countingReader{ReadCloser: io.NopCloser(strings.NewReader("hello world")), counter: counter}
+
+		buf := make([]byte, 5)
+		_, err := reader.Read(buf)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(reader.Close()).To(Succeed())
+		Expect(counted()).To(BeEquivalentTo(5))
+	})
+
+	It("counts the bytes streamed by the transport", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.Copy(io.Discard, r.Body)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		body := strings.Repeat("a", 64*1024)
+		req, err := http.NewRequest(http.MethodPost, server.URL, &countingReader{ReadCloser: io.NopCloser(strings.NewReader(body)), counter: counter})
+		Expect(err).NotTo(HaveOccurred())
+
+		res, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		res.Body.Close()
+
+		Eventually(counted).Should(BeEquivalentTo(len(body)))
+	})
+})
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	credentialsReloadPeriod = 30 * time.Second
+	defaultTokenHeader      = "X-Edge-Remote-Token"
+)
+
+// credentials of the proxy for the remote cluster, mounted from a secret. They're reloaded
+// periodically, since the secret is updated in place when the credentials are rotated.
+type credentials struct {
+	path        string
+	tokenHeader string
+
+	lock        sync.RWMutex
+	certificate *tls.Certificate
+	rootCAs     *x509.CertPool
+	token       string
+}
+
+func newCredentialsFromEnv(getenv func(string) string) *credentials {
+	path := getenv("REMOTE_CREDENTIALS_PATH")
+
+	if path == "" {
+		return nil
+	}
+
+	tokenHeader := getenv("REMOTE_TOKEN_HEADER")
+
+	if tokenHeader == "" {
+		tokenHeader = defaultTokenHeader
+	}
+
+	c := &credentials{path: path, tokenHeader: tokenHeader}
+	c.reload()
+
+	return c
+}
+
+func (c *credentials) readFile(name string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(c.path, name))
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	return data, err
+}
+
+// reload replaces each credential with what was read, clearing the ones whose files were removed,
+// so revoked credentials aren't used anymore. A credential whose files can't be read or parsed
+// keeps its previous value instead, in case they're caught in the middle of an update.
+func (c *credentials) reload() {
+	certificate, certificateErr := c.loadCertificate()
+	rootCAs, rootCAsErr := c.loadRootCAs()
+	token, tokenErr := c.loadToken()
+
+	for _, err := range []error{certificateErr, rootCAsErr, tokenErr} {
+		if err != nil {
+			log.Printf("Error: cannot load remote credentials: %s\n", err)
+		}
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if certificateErr == nil {
+		c.certificate = certificate
+	}
+
+	if rootCAsErr == nil {
+		c.rootCAs = rootCAs
+	}
+
+	if tokenErr == nil {
+		c.token = token
+	}
+}
+
+// loadCertificate returns nil without a client certificate.
+func (c *credentials) loadCertificate() (*tls.Certificate, error) {
+	cert, err := c.readFile("tls.crt")
+
+	if err != nil {
+		return nil, fmt.Errorf("client certificate: %w", err)
+	}
+
+	key, err := c.readFile("tls.key")
+
+	if err != nil {
+		return nil, fmt.Errorf("client certificate: %w", err)
+	}
+
+	if cert == nil && key == nil {
+		return nil, nil
+	}
+
+	certificate, err := tls.X509KeyPair(cert, key)
+
+	if err != nil {
+		return nil, fmt.Errorf("client certificate: %w", err)
+	}
+
+	return &certificate, nil
+}
+
+// loadRootCAs returns nil without a pinned CA.
+func (c *credentials) loadRootCAs() (*x509.CertPool, error) {
+	ca, err := c.readFile("ca.crt")
+
+	if err != nil {
+		return nil, fmt.Errorf("ca: %w", err)
+	}
+
+	if ca == nil {
+		return nil, nil
+	}
+
+	rootCAs := x509.NewCertPool()
+
+	if !rootCAs.AppendCertsFromPEM(ca) {
+		return nil, errors.New("ca: no valid certificate")
+	}
+
+	return rootCAs, nil
+}
+
+func (c *credentials) loadToken() (string, error) {
+	token, err := c.readFile("token")
+
+	if err != nil {
+		return "", fmt.Errorf("token: %w", err)
+	}
+
+	return string(bytes.TrimSpace(token)), nil
+}
+
+func (c *credentials) watch() {
+	for {
+		time.Sleep(credentialsReloadPeriod)
+		c.reload()
+	}
+}
+
+func (c *credentials) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.certificate == nil {
+		// no certificate is sent, the remote cluster decides if that's fine
+		return &tls.Certificate{}, nil
+	}
+
+	return c.certificate, nil
+}
+
+// verifyConnection checks the remote certificate against the pinned CA if there is one, and
+// against the system roots otherwise. This is done by hand, since the pinned CA can change.
+func (c *credentials) verifyConnection(state tls.ConnectionState) error {
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("remote cluster didn't present a certificate")
+	}
+
+	c.lock.RLock()
+	rootCAs := c.rootCAs
+	c.lock.RUnlock()
+
+	intermediates := x509.NewCertPool()
+
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+		DNSName:       state.ServerName,
+		Roots:         rootCAs,
+		Intermediates: intermediates,
+	})
+
+	return err
+}
+
+// tlsConfig uses the host of the service as server name, so the remote ingress presents the
+// certificate of the service even when it's reached by ip.
+func (c *credentials) tlsConfig(serverName string) *tls.Config {
+	if host, _, err := net.SplitHostPort(serverName); err == nil {
+		serverName = host
+	}
+
+	return &tls.Config{
+		ServerName:           serverName,
+		GetClientCertificate: c.getClientCertificate,
+		// verified in verifyConnection instead
+		InsecureSkipVerify: true,
+		VerifyConnection:   c.verifyConnection,
+	}
+}
+
+func (c *credentials) getToken() string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.token
+}
+
+// setToken authenticates the request to the remote cluster. The token goes in its own header by
+// default, and never replaces a header sent by the client, which may be meant for the service.
+func (c *credentials) setToken(headers http.Header) {
+	token := c.getToken()
+
+	if token == "" || headers.Get(c.tokenHeader) != "" {
+		return
+	}
+
+	if strings.EqualFold(c.tokenHeader, "Authorization") {
+		headers.Set(c.tokenHeader, "Bearer "+token)
+	} else {
+		headers.Set(c.tokenHeader, token)
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// testCertificate returns a self-signed certificate and its key, in PEM.
+func testCertificate() (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "remote.example.com"},
+		DNSNames:              []string{"remote.example.com"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+
+	keyData, err := x509.MarshalECPrivateKey(key)
+	Expect(err).NotTo(HaveOccurred())
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyData}))
+}
+
+func decodePEM(data string) []byte {
+	block, _ := pem.Decode([]byte(data))
+	Expect(block).NotTo(BeNil())
+
+	return block.Bytes
+}
+
+var _ = Describe("remote credentials", func() {
+	newCredentials := func(files map[string]string, tokenHeader string) *credentials {
+		path := GinkgoT().TempDir()
+
+		for name, content := range files {
+			Expect(os.WriteFile(filepath.Join(path, name), []byte(content), 0600)).To(Succeed())
+		}
+
+		return newCredentialsFromEnv(func(key string) string {
+			switch key {
+			case "REMOTE_CREDENTIALS_PATH":
+				return path
+			case "REMOTE_TOKEN_HEADER":
+				return tokenHeader
+			}
+
+			return ""
+		})
+	}
+
+	It("isn't loaded without a path", func() {
+		Expect(newCredentialsFromEnv(func(string) string { return "" })).To(BeNil())
+	})
+
+	DescribeTable("setting the token",
+		func(token string, tokenHeader string, clientHeaders map[string]string, expectedHeaders map[string]string) {
+			files := map[string]string{}
+
+			if token != "" {
+				files["token"] = token
+			}
+
+			c := newCredentials(files, tokenHeader)
+			headers := http.Header{}
+
+			for key, value := range clientHeaders {
+				headers.Set(key, value)
+			}
+
+			c.setToken(headers)
+
+			Expect(headers).To(HaveLen(len(expectedHeaders)))
+
+			for key, value := range expectedHeaders {
+				Expect(headers.Get(key)).To(Equal(value))
+			}
+		},
+		Entry("sends nothing without a token", "", "", nil, map[string]string{}),
+		Entry("sends the token in its own header by default", "secret\n", "", nil,
+			map[string]string{defaultTokenHeader: "secret"}),
+		Entry("keeps the authorization of the client", "secret", "",
+			map[string]string{"Authorization": "Bearer client"},
+			map[string]string{"Authorization": "Bearer client", defaultTokenHeader: "secret"}),
+		Entry("sends a bearer token in the authorization header", "secret", "Authorization", nil,
+			map[string]string{"Authorization": "Bearer secret"}),
+		Entry("doesn't overwrite a header sent by the client", "secret", "Authorization",
+			map[string]string{"Authorization": "Bearer client"},
+			map[string]string{"Authorization": "Bearer client"}),
+		Entry("sends the token in a custom header", "secret", "X-Token", nil,
+			map[string]string{"X-Token": "secret"}),
+	)
+
+	Describe("reloading", func() {
+		certificate, key := testCertificate()
+
+		files := map[string]string{
+			"tls.crt": certificate,
+			"tls.key": key,
+			"ca.crt":  certificate,
+			"token":   "secret",
+		}
+
+		It("loads every credential", func() {
+			c := newCredentials(files, "")
+
+			Expect(c.certificate).NotTo(BeNil())
+			Expect(c.rootCAs).NotTo(BeNil())
+			Expect(c.getToken()).To(Equal("secret"))
+		})
+
+		It("clears the credentials whose files were removed", func() {
+			c := newCredentials(files, "")
+
+			for name := range files {
+				Expect(os.Remove(filepath.Join(c.path, name))).To(Succeed())
+			}
+
+			c.reload()
+
+			Expect(c.certificate).To(BeNil())
+			Expect(c.rootCAs).To(BeNil())
+			Expect(c.getToken()).To(BeEmpty())
+		})
+
+		It("clears an emptied token", func() {
+			c := newCredentials(files, "")
+
+			Expect(os.WriteFile(filepath.Join(c.path, "token"), []byte("\n"), 0600)).To(Succeed())
+			c.reload()
+
+			Expect(c.getToken()).To(BeEmpty())
+			Expect(c.certificate).NotTo(BeNil())
+		})
+
+		It("replaces rotated credentials", func() {
+			c := newCredentials(files, "")
+			previous := c.certificate
+
+			rotatedCertificate, rotatedKey := testCertificate()
+			Expect(os.WriteFile(filepath.Join(c.path, "tls.crt"), []byte(rotatedCertificate), 0600)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(c.path, "tls.key"), []byte(rotatedKey), 0600)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(c.path, "token"), []byte("rotated"), 0600)).To(Succeed())
+			c.reload()
+
+			Expect(c.certificate).NotTo(BeIdenticalTo(previous))
+			Expect(c.certificate.Certificate).To(Equal([][]byte{decodePEM(rotatedCertificate)}))
+			Expect(c.getToken()).To(Equal("rotated"))
+		})
+
+		DescribeTable("keeping the credentials which can't be loaded",
+			func(name string, content string) {
+				c := newCredentials(files, "")
+				certificate, rootCAs := c.certificate, c.rootCAs
+
+				Expect(os.WriteFile(filepath.Join(c.path, name), []byte(content), 0600)).To(Succeed())
+				c.reload()
+
+				// the other credentials are loaded again
+				if name == "ca.crt" {
+					Expect(c.rootCAs).To(BeIdenticalTo(rootCAs))
+					Expect(c.certificate).NotTo(BeIdenticalTo(certificate))
+				} else {
+					Expect(c.certificate).To(BeIdenticalTo(certificate))
+					Expect(c.rootCAs).NotTo(BeIdenticalTo(rootCAs))
+				}
+
+				Expect(c.certificate).NotTo(BeNil())
+				Expect(c.rootCAs).NotTo(BeNil())
+				Expect(c.getToken()).To(Equal("secret"))
+			},
+			Entry("certificate being written", "tls.crt", certificate[:len(certificate)/2]),
+			Entry("key of another certificate", "tls.key", func() string { _, key := testCertificate(); return key }()),
+			Entry("invalid ca", "ca.crt", "not a certificate"),
+		)
+
+		It("keeps the certificate while only its key is written", func() {
+			c := newCredentials(files, "")
+			previous := c.certificate
+
+			Expect(os.Remove(filepath.Join(c.path, "tls.crt"))).To(Succeed())
+			c.reload()
+
+			Expect(c.certificate).To(BeIdenticalTo(previous))
+		})
+	})
+
+	It("rejects an invalid ca", func() {
+		c := newCredentials(map[string]string{"ca.crt": "not a certificate"}, "")
+
+		Expect(c.rootCAs).To(BeNil())
+	})
+})
@@ -0,0 +1,413 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	selectionLatency  = "latency"
+	selectionWeighted = "weighted"
+
+	defaultEndpointProbePeriod = 10 * time.Second
+	peersReloadPeriod          = 15 * time.Second
+
+	// weight of the latest probe in the moving average of the round trip time
+	rttSmoothing = 0.3
+
+	// set on the requests offloaded to a peer, whose proxy then only offloads them to the remote
+	// clusters, so they don't bounce between edge clusters
+	peerHeader = "x-knative-edge-peer"
+)
+
+// endpoint is one of the remote clusters requests can be offloaded to, passed by the controller
+// as JSON in the REMOTE_ENDPOINTS env.
+type endpoint struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Host   string `json:"host"`
+	Weight int64  `json:"weight,omitempty"`
+	Peer   bool   `json:"peer,omitempty"`
+
+	url     *url.URL
+	client  *http.Client
+	breaker *circuitBreaker
+
+	// closed when the peer is removed
+	stop chan struct{}
+
+	lock    sync.RWMutex
+	healthy bool
+	rtt     time.Duration
+}
+
+func (e *endpoint) state() (bool, time.Duration) {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	return e.healthy, e.rtt
+}
+
+func (e *endpoint) setHealthy(healthy bool) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.healthy != healthy {
+		if healthy {
+			log.Printf("Endpoint %s is reachable again.\n", e.Name)
+		} else {
+			log.Printf("Endpoint %s is unreachable.\n", e.Name)
+		}
+	}
+
+	e.healthy = healthy
+
+	if healthy {
+		endpointUp.WithLabelValues(e.Name).Set(1)
+	} else {
+		endpointUp.WithLabelValues(e.Name).Set(0)
+	}
+}
+
+func (e *endpoint) observeRTT(rtt time.Duration) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.rtt == 0 {
+		e.rtt = rtt
+	} else {
+		e.rtt = time.Duration(rttSmoothing*float64(rtt) + (1-rttSmoothing)*float64(e.rtt))
+	}
+
+	endpointRTT.WithLabelValues(e.Name).Set(e.rtt.Seconds())
+}
+
+// endpointSelector picks the endpoint of each request among the healthy ones whose circuit
+// breaker isn't open, either the one with the lowest round trip time or randomly by weight.
+// Healthy peers are picked before the remote clusters.
+//
+// The peers which can take traffic change with their utilization, so they're read from a file
+// mounted from a ConfigMap the edge controller keeps up to date, instead of the env.
+type endpointSelector struct {
+	selection string
+
+	lock      sync.RWMutex
+	endpoints []*endpoint
+
+	// prepares the client and the circuit breaker of the endpoints, including the peers added later
+	setup func(*endpoint)
+}
+
+// list returns the endpoints, the slice is replaced rather than changed when the peers change.
+func (s *endpointSelector) list() []*endpoint {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.endpoints
+}
+
+func parseEndpoints(value, selection string) (*endpointSelector, error) {
+	var endpoints []*endpoint
+
+	if err := json.Unmarshal([]byte(value), &endpoints); err != nil {
+		return nil, fmt.Errorf("couldn't parse remote endpoints: %w", err)
+	}
+
+	if len(endpoints) == 0 {
+		return nil, errors.New("no remote endpoints")
+	}
+
+	switch selection {
+	case "":
+		selection = selectionLatency
+	case selectionLatency, selectionWeighted:
+	default:
+		return nil, fmt.Errorf("unknown endpoint selection %q", selection)
+	}
+
+	if err := initEndpoints(endpoints); err != nil {
+		return nil, err
+	}
+
+	return &endpointSelector{selection: selection, endpoints: endpoints}, nil
+}
+
+func initEndpoints(endpoints []*endpoint) error {
+	for _, e := range endpoints {
+		u, err := url.Parse(e.URL)
+
+		if err != nil {
+			return fmt.Errorf("couldn't parse url of endpoint %s: %w", e.Name, err)
+		}
+
+		e.url = u
+		e.healthy = true
+	}
+
+	return nil
+}
+
+// readPeers returns no peers while the file doesn't exist, the ConfigMap is optional.
+func readPeers(path string) ([]*endpoint, error) {
+	data, err := os.ReadFile(path)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var peers []*endpoint
+
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &peers); err != nil {
+			return nil, fmt.Errorf("couldn't parse peer endpoints: %w", err)
+		}
+	}
+
+	for _, e := range peers {
+		e.Peer = true
+	}
+
+	return peers, initEndpoints(peers)
+}
+
+// setPeers replaces the peers, keeping the state of the ones which didn't change.
+func (s *endpointSelector) setPeers(peers []*endpoint) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	current := make(map[string]*endpoint)
+	var endpoints []*endpoint
+
+	for _, e := range s.endpoints {
+		if e.Peer {
+			current[e.Name] = e
+		} else {
+			endpoints = append(endpoints, e)
+		}
+	}
+
+	for _, peer := range peers {
+		if e, exists := current[peer.Name]; exists && e.URL == peer.URL && e.Host == peer.Host {
+			endpoints = append(endpoints, e)
+			delete(current, peer.Name)
+
+			continue
+		}
+
+		log.Printf("Offloading to peer %s.\n", peer.Name)
+
+		if s.setup != nil {
+			s.setup(peer)
+		}
+
+		endpoints = append(endpoints, peer)
+	}
+
+	for _, e := range current {
+		log.Printf("Not offloading to peer %s anymore.\n", e.Name)
+
+		if e.stop != nil {
+			close(e.stop)
+		}
+	}
+
+	s.endpoints = endpoints
+}
+
+// watchPeers reloads the peers periodically, the kubelet updates the file in place.
+func (s *endpointSelector) watchPeers(path string) {
+	for {
+		if peers, err := readPeers(path); err != nil {
+			log.Printf("Error: cannot load peer endpoints: %s\n", err)
+		} else {
+			s.setPeers(peers)
+		}
+
+		time.Sleep(peersReloadPeriod)
+	}
+}
+
+// pick returns the endpoint for a request, skipping the peers unless allowed, and the endpoints
+// which already failed for it. When none is healthy, the first remote cluster is used anyway.
+func (s *endpointSelector) pick(peers bool, failed []*endpoint) *endpoint {
+	var healthy []*endpoint
+	var healthyPeers []*endpoint
+
+	for _, e := range s.list() {
+		if ok, _ := e.state(); !ok || e.breaker.isOpen() || containsEndpoint(failed, e) {
+			continue
+		}
+
+		if !e.Peer {
+			healthy = append(healthy, e)
+		} else if peers {
+			healthyPeers = append(healthyPeers, e)
+		}
+	}
+
+	if len(healthyPeers) > 0 {
+		healthy = healthyPeers
+	}
+
+	if len(healthy) == 0 {
+		return s.fallback()
+	}
+
+	if s.selection == selectionWeighted {
+		if e := pickWeighted(healthy); e != nil {
+			return e
+		}
+	}
+
+	return pickLowestRTT(healthy)
+}
+
+// unreachable is true when the circuit breakers of all remote clusters are open, peers aside.
+func (s *endpointSelector) unreachable() bool {
+	for _, e := range s.list() {
+		if !e.Peer && !e.breaker.isOpen() {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsEndpoint(endpoints []*endpoint, e *endpoint) bool {
+	for _, other := range endpoints {
+		if other == e {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *endpointSelector) fallback() *endpoint {
+	endpoints := s.list()
+
+	for _, e := range endpoints {
+		if !e.Peer {
+			return e
+		}
+	}
+
+	return endpoints[0]
+}
+
+// pickWeighted returns nil if none of the endpoints has a weight.
+func pickWeighted(endpoints []*endpoint) *endpoint {
+	var total int64
+
+	for _, e := range endpoints {
+		if e.Weight > 0 {
+			total += e.Weight
+		}
+	}
+
+	if total == 0 {
+		return nil
+	}
+
+	n := rand.Int63n(total)
+
+	for _, e := range endpoints {
+		if e.Weight <= 0 {
+			continue
+		}
+
+		if n < e.Weight {
+			return e
+		}
+
+		n -= e.Weight
+	}
+
+	return nil
+}
+
+// pickLowestRTT picks the probed endpoint with the lowest round trip time. Endpoints which weren't
+// probed yet, like the peers added since the last probe, are only picked while none was probed, the
+// first one then. The next probe measures them, so they're picked by their round trip time after.
+func pickLowestRTT(endpoints []*endpoint) *endpoint {
+	var best *endpoint
+	var bestRTT time.Duration
+
+	for _, e := range endpoints {
+		_, rtt := e.state()
+
+		if best == nil || (rtt > 0 && (bestRTT == 0 || rtt < bestRTT)) {
+			best, bestRTT = e, rtt
+		}
+	}
+
+	return best
+}
+
+// probe measures the round trip time of every endpoint and checks whether it's reachable.
+func (s *endpointSelector) probe(period time.Duration) {
+	for {
+		for _, e := range s.list() {
+			start := time.Now()
+
+			if err := probeEndpoint(e); err != nil {
+				e.setHealthy(false)
+			} else {
+				e.observeRTT(time.Since(start))
+				e.setHealthy(true)
+			}
+		}
+
+		time.Sleep(period)
+	}
+}
+
+// probeEndpoint only cares that the endpoint answers, whatever the status.
+func probeEndpoint(e *endpoint) error {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteProbeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, e.url.String(), nil)
+
+	if err != nil {
+		return err
+	}
+
+	req.Host = e.Host
+
+	if e.Peer {
+		req.Header.Set(peerHeader, e.Name)
+	}
+
+	c := e.client
+
+	if c == nil {
+		c = client
+	}
+
+	res, err := c.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	res.Body.Close()
+
+	if isRemoteFailure(res, nil) {
+		return errors.New(res.Status)
+	}
+
+	return nil
+}
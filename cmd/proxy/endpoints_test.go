@@ -0,0 +1,215 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("endpoint selection", func() {
+	type testEndpoint struct {
+		name      string
+		weight    int64
+		peer      bool
+		unhealthy bool
+		open      bool
+		rtt       time.Duration
+	}
+
+	newSelector := func(selection string, testEndpoints []testEndpoint) *endpointSelector {
+		s := &endpointSelector{selection: selection}
+
+		for _, te := range testEndpoints {
+			e := &endpoint{Name: te.name, Weight: te.weight, Peer: te.peer, healthy: !te.unhealthy, rtt: te.rtt}
+			e.breaker = &circuitBreaker{name: te.name, threshold: 1, timeout: time.Minute}
+
+			if te.open {
+				e.breaker.failure()
+			}
+
+			s.endpoints = append(s.endpoints, e)
+		}
+
+		return s
+	}
+
+	DescribeTable("picking an endpoint",
+		func(selection string, testEndpoints []testEndpoint, peers bool, failed []string, expected string) {
+			s := newSelector(selection, testEndpoints)
+
+			var failedEndpoints []*endpoint
+
+			for _, e := range s.endpoints {
+				for _, name := range failed {
+					if e.Name == name {
+						failedEndpoints = append(failedEndpoints, e)
+					}
+				}
+			}
+
+			Expect(s.pick(peers, failedEndpoints).Name).To(Equal(expected))
+		},
+		Entry("picks the lowest round trip time", selectionLatency,
+			[]testEndpoint{{name: "a", rtt: 30 * time.Millisecond}, {name: "b", rtt: 10 * time.Millisecond}, {name: "c", rtt: 20 * time.Millisecond}},
+			true, nil, "b"),
+		Entry("keeps the order of endpoints not probed yet", selectionLatency,
+			[]testEndpoint{{name: "a"}, {name: "b"}},
+			true, nil, "a"),
+		Entry("prefers probed endpoints", selectionLatency,
+			[]testEndpoint{{name: "a"}, {name: "b", rtt: 10 * time.Millisecond}},
+			true, nil, "b"),
+		Entry("skips unhealthy endpoints", selectionLatency,
+			[]testEndpoint{{name: "a", rtt: 10 * time.Millisecond, unhealthy: true}, {name: "b", rtt: 20 * time.Millisecond}},
+			true, nil, "b"),
+		Entry("skips endpoints with an open circuit breaker", selectionLatency,
+			[]testEndpoint{{name: "a", rtt: 10 * time.Millisecond, open: true}, {name: "b", rtt: 20 * time.Millisecond}},
+			true, nil, "b"),
+		Entry("skips endpoints which failed for the request", selectionLatency,
+			[]testEndpoint{{name: "a", rtt: 10 * time.Millisecond}, {name: "b", rtt: 20 * time.Millisecond}},
+			true, []string{"a"}, "b"),
+		Entry("falls back to the first remote cluster", selectionLatency,
+			[]testEndpoint{{name: "peer", peer: true, unhealthy: true}, {name: "a", unhealthy: true}, {name: "b", unhealthy: true}},
+			true, nil, "a"),
+		Entry("prefers healthy peers", selectionLatency,
+			[]testEndpoint{{name: "a", rtt: 10 * time.Millisecond}, {name: "peer", peer: true, rtt: 20 * time.Millisecond}},
+			true, nil, "peer"),
+		Entry("skips peers for requests from a peer", selectionLatency,
+			[]testEndpoint{{name: "a", rtt: 10 * time.Millisecond}, {name: "peer", peer: true, rtt: 20 * time.Millisecond}},
+			false, nil, "a"),
+		Entry("picks by weight", selectionWeighted,
+			[]testEndpoint{{name: "a", weight: 0, rtt: 10 * time.Millisecond}, {name: "b", weight: 1, rtt: 20 * time.Millisecond}},
+			true, nil, "b"),
+		Entry("picks by latency without weights", selectionWeighted,
+			[]testEndpoint{{name: "a", rtt: 20 * time.Millisecond}, {name: "b", rtt: 10 * time.Millisecond}},
+			true, nil, "b"),
+	)
+
+	It("spreads the requests by weight", func() {
+		s := newSelector(selectionWeighted, []testEndpoint{{name: "a", weight: 3}, {name: "b", weight: 1}})
+		counts := map[string]int{}
+
+		for i := 0; i < 4000; i++ {
+			counts[s.pick(true, nil).Name]++
+		}
+
+		Expect(counts["a"]).To(BeNumerically("~", 3000, 200))
+		Expect(counts["b"]).To(BeNumerically("~", 1000, 200))
+	})
+
+	It("picks endpoints added later once they're probed", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		DeferCleanup(server.Close)
+
+		s := newSelector(selectionLatency, []testEndpoint{{name: "slow", rtt: time.Hour}, {name: "new"}})
+
+		for _, e := range s.endpoints {
+			e.url, _ = url.Parse(server.URL)
+			e.client = server.Client()
+		}
+
+		Expect(s.pick(true, nil).Name).To(Equal("slow"))
+
+		go s.probe(time.Hour)
+
+		Eventually(func() string { return s.pick(true, nil).Name }).Should(Equal("new"))
+	})
+
+	It("smooths the round trip time", func() {
+		e := &endpoint{Name: "a"}
+
+		e.observeRTT(100 * time.Millisecond)
+		e.observeRTT(200 * time.Millisecond)
+
+		_, rtt := e.state()
+		Expect(rtt).To(Equal(130 * time.Millisecond))
+	})
+
+	DescribeTable("parsing the endpoints",
+		func(value string, selection string, expectedErr bool) {
+			s, err := parseEndpoints(value, selection)
+
+			if expectedErr {
+				Expect(err).To(HaveOccurred())
+				return
+			}
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.endpoints[0].healthy).To(BeTrue())
+			Expect(s.selection).NotTo(BeEmpty())
+		},
+		Entry("defaults to latency", `[{"name":"a","url":"http://a","host":"a"}]`, "", false),
+		Entry("accepts weights", `[{"name":"a","url":"http://a","host":"a","weight":2}]`, selectionWeighted, false),
+		Entry("rejects no endpoints", `[]`, "", true),
+		Entry("rejects invalid JSON", `{`, "", true),
+		Entry("rejects unknown selections", `[{"name":"a","url":"http://a","host":"a"}]`, "random", true),
+	)
+
+	Describe("peers", func() {
+		writePeers := func(path, content string) {
+			Expect(os.WriteFile(path, []byte(content), 0600)).To(Succeed())
+		}
+
+		It("reads no peers without the file", func() {
+			peers, err := readPeers(filepath.Join(GinkgoT().TempDir(), "peers.json"))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(peers).To(BeEmpty())
+		})
+
+		It("follows the changes of the peers", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "peers.json")
+			s := newSelector(selectionLatency, []testEndpoint{{name: "remote"}})
+
+			var added []string
+			s.setup = func(e *endpoint) {
+				added = append(added, e.Name)
+				e.stop = make(chan struct{})
+			}
+
+			writePeers(path, `[{"name":"a","url":"http://a","host":"svc.a"},{"name":"b","url":"http://b","host":"svc.b"}]`)
+			peers, err := readPeers(path)
+			Expect(err).NotTo(HaveOccurred())
+			s.setPeers(peers)
+
+			Expect(added).To(Equal([]string{"a", "b"}))
+			Expect(s.list()).To(HaveLen(3))
+			Expect(s.pick(true, nil).Peer).To(BeTrue())
+			Expect(s.pick(false, nil).Name).To(Equal("remote"))
+
+			a, b := s.list()[1], s.list()[2]
+			a.observeRTT(10 * time.Millisecond)
+
+			writePeers(path, `[{"name":"a","url":"http://a","host":"svc.a"}]`)
+			peers, err = readPeers(path)
+			Expect(err).NotTo(HaveOccurred())
+			s.setPeers(peers)
+
+			Expect(added).To(Equal([]string{"a", "b"}))
+			Expect(s.list()).To(HaveLen(2))
+			Expect(s.list()[1]).To(BeIdenticalTo(a))
+			Expect(b.stop).To(BeClosed())
+
+			writePeers(path, `[]`)
+			peers, err = readPeers(path)
+			Expect(err).NotTo(HaveOccurred())
+			s.setPeers(peers)
+
+			Expect(s.list()).To(HaveLen(1))
+			Expect(s.pick(true, nil).Name).To(Equal("remote"))
+		})
+
+		It("rejects invalid peers", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "peers.json")
+			writePeers(path, `{`)
+
+			_, err := readPeers(path)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
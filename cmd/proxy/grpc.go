@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+func isGrpcRequest(r *http.Request) bool {
+	return strings.HasPrefix(strings.ToLower(r.Header.Get("Content-Type")), "application/grpc")
+}
+
+// dialRemote opens a plain connection to the remote cluster, tunneling through the http proxy
+// if the remote cluster isn't excluded from it.
+func dialRemote(ctx context.Context, dialer *net.Dialer, network, addr string) (net.Conn, error) {
+	proxyURL, err := proxyFor(&url.URL{Scheme: "http", Host: addr})
+
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read proxy url: %w", err)
+	}
+
+	if proxyURL == nil {
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	conn, err := dialer.DialContext(ctx, network, proxyURL.Host)
+
+	if err != nil {
+		return nil, fmt.Errorf("couldn't connect to remote proxy: %w", err)
+	}
+
+	connectReq := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	if err := connectReq.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("couldn't connect to remote proxy: %w", err)
+	}
+
+	res, err := http.ReadResponse(bufio.NewReader(conn), connectReq)
+
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("couldn't connect to remote proxy: %w", err)
+	}
+
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("remote proxy refused tunnel: %s", res.Status)
+	}
+
+	return conn, nil
+}
+
+// newH2CClient creates a client which speaks HTTP/2 without TLS, which is what gRPC services
+// expect when the remote cluster is reached over plain http.
+func newH2CClient(dialer *net.Dialer) *http.Client {
+	return &http.Client{
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+				return dialRemote(ctx, dialer, network, addr)
+			},
+			ReadIdleTimeout: 30 * time.Second,
+		},
+	}
+}
+
+// clientFor picks the client to forward a request to the endpoint with, nil for REMOTE_URL.
+// HTTP/2 requests are kept HTTP/2 end to end, so that streaming and trailers keep working.
+func clientFor(r *http.Request, target *url.URL, e *endpoint) *http.Client {
+	if r.ProtoMajor == 2 && target.Scheme == "http" {
+		return h2cClient
+	}
+
+	if e != nil && e.client != nil {
+		return e.client
+	}
+
+	return client
+}
+
+// copyTrailers sends the trailers received from the remote cluster, like grpc-status, after
+// the response body.
+func copyTrailers(w http.ResponseWriter, res *http.Response) {
+	headers := w.Header()
+
+	for key, values := range res.Trailer {
+		for _, value := range values {
+			headers.Add(http.TrailerPrefix+key, value)
+		}
+	}
+}
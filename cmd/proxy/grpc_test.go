@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+var _ = Describe("grpc", func() {
+	newH2CServer := func(h http.HandlerFunc) *httptest.Server {
+		server := httptest.NewServer(h2c.NewHandler(h, &http2.Server{}))
+		DeferCleanup(server.Close)
+
+		return server
+	}
+
+	h2cTransport := &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}
+
+	DescribeTable("picking the client",
+		func(proto int, target string, withEndpointClient bool, expected func() *http.Client) {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			r.ProtoMajor = proto
+
+			u, err := url.Parse(target)
+			Expect(err).NotTo(HaveOccurred())
+
+			var e *endpoint
+
+			if withEndpointClient {
+				e = &endpoint{client: &http.Client{}}
+			}
+
+			c := clientFor(r, u, e)
+
+			if withEndpointClient && expected == nil {
+				Expect(c).To(BeIdenticalTo(e.client))
+				return
+			}
+
+			Expect(c).To(BeIdenticalTo(expected()))
+		},
+		Entry("h2c for HTTP/2 over http", 2, "http://remote", true, func() *http.Client { return h2cClient }),
+		Entry("the client of the endpoint over https", 2, "https://remote", true, nil),
+		Entry("the client of the endpoint for HTTP/1", 1, "http://remote", true, nil),
+		Entry("the default client without endpoint", 1, "http://remote", false, func() *http.Client { return client }),
+	)
+
+	It("forwards the trailers of the remote over h2c", func() {
+		remote := newH2CServer(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+
+			Expect(r.ProtoMajor).To(Equal(2))
+			Expect(isGrpcRequest(r)).To(BeTrue())
+
+			body, err := io.ReadAll(r.Body)
+			Expect(err).NotTo(HaveOccurred())
+
+			w.Header().Set("Content-Type", "application/grpc")
+			w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+			w.WriteHeader(http.StatusOK)
+			w.Write(body)
+
+			w.Header().Set("Grpc-Status", "0")
+			w.Header().Set("Grpc-Message", "done")
+		})
+
+		useRemote(remote.URL)
+
+		proxy := newH2CServer(handler)
+
+		req, err := http.NewRequest(http.MethodPost, proxy.URL+"/app.Echo/Say", strings.NewReader("hello"))
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Content-Type", "application/grpc+proto")
+
+		res, err := h2cTransport.RoundTrip(req)
+		Expect(err).NotTo(HaveOccurred())
+		defer res.Body.Close()
+
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+
+		body, err := io.ReadAll(res.Body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(body)).To(Equal("hello"))
+
+		// the trailers are only known once the body was read
+		Expect(res.Trailer.Get("Grpc-Status")).To(Equal("0"))
+		Expect(res.Trailer.Get("Grpc-Message")).To(Equal("done"))
+	})
+})
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// parseExtraHeaders reads headers formatted as Name=value,Name=value, which are sent with every
+// request to the remote service.
+func parseExtraHeaders(value string) http.Header {
+	headers := make(http.Header)
+
+	for _, header := range strings.Split(value, ",") {
+		name, headerValue, found := strings.Cut(header, "=")
+		name = strings.TrimSpace(name)
+
+		if !found || name == "" {
+			continue
+		}
+
+		headers.Add(name, strings.TrimSpace(headerValue))
+	}
+
+	return headers
+}
@@ -1,8 +1,7 @@
 package main
 
 import (
-	"bytes"
-	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
@@ -11,15 +10,29 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 var (
-	remoteURL      *url.URL
-	remoteProxyURL *url.URL
-	remoteHost     string
-	timeout        time.Duration = time.Second * 30
-	client         *http.Client
+	remoteURL          *url.URL
+	proxyFunc          func(*url.URL) (*url.URL, error)
+	remoteHost         string
+	timeout            time.Duration = time.Second * 30
+	maxBodySize        int64         = 0
+	upgradeIdleTimeout time.Duration = time.Minute * 5
+	client             *http.Client
+	h2cClient          *http.Client
+	exporter           *otlpExporter
+	retries            retryPolicy
+	breaker            *circuitBreaker
+	remoteCredentials  *credentials
+	extraHeaders       http.Header
+	policy             *proxyPolicy
+	endpoints          *endpointSelector
 )
 
 const (
@@ -33,69 +46,245 @@ var dropHeaders = []string{
 
 func handler(w http.ResponseWriter, r *http.Request) {
 	headers := w.Header()
+	start := time.Now()
+
+	span := startSpan(r, exporter != nil)
+
+	// every exit path finishes the span with its status, so the rejected requests are recorded too
+	status, upstreamServiceTime := 0, ""
+
+	finish := func(code int, err error) {
+		status = code
+		finishSpan(span, code, err)
+	}
+
+	defer func() {
+		observeRequest(r.Method, status, time.Since(start), upstreamServiceTime)
+	}()
+
+	// REMOTE_URL is only used when there aren't several endpoints to pick from
+	target, targetHost := remoteURL, remoteHost
+	var targetEndpoint *endpoint
+
+	// requests offloaded by a peer aren't offloaded to another one
+	allowPeers := r.Header.Get(peerHeader) == ""
+
+	if endpoints != nil {
+		targetEndpoint = endpoints.pick(allowPeers, nil)
+		target, targetHost = targetEndpoint.url, targetEndpoint.Host
 
-	headers.Set("x-knative-edge-proxy-host", remoteHost)
+		headers.Set("x-knative-edge-proxy-endpoint", targetEndpoint.Name)
+	}
+
+	if span != nil {
+		span.attributes["http.method"] = r.Method
+		span.attributes["http.target"] = r.URL.Path
+		span.attributes["knative_edge.remote_host"] = targetHost
+
+		defer func() {
+			if exporter != nil {
+				exporter.export(span)
+			}
+		}()
+	}
+
+	headers.Set("x-knative-edge-proxy-host", targetHost)
 	headers.Set("x-knative-edge-proxy", "true")
 	headers.Set("x-knative-edge-proxy-url", "")
 
-	if remoteURL == nil {
+	if target == nil {
+		observeError(errorClassConfig)
+		finish(http.StatusBadGateway, errors.New("no remote url set"))
+
 		w.Header().Add("Content-Type", "text/plain")
 		http.Error(w, "bad gateway: no remote url set", http.StatusBadGateway)
 
 		return
 	}
 
-	headers.Set("x-knative-edge-proxy-url", remoteURL.String())
+	headers.Set("x-knative-edge-proxy-url", target.String())
+
+	if targetHost == "" {
+		observeError(errorClassConfig)
+		finish(http.StatusBadGateway, errors.New("no remote host set"))
 
-	if remoteHost == "" {
 		w.Header().Add("Content-Type", "text/plain")
 		http.Error(w, "bad gateway: no remote host set", http.StatusBadGateway)
 
 		return
 	}
 
-	url := *remoteURL
-	url.Path = r.URL.Path
-	url.RawQuery = r.URL.RawQuery
-	url.Fragment = r.URL.Fragment
+	if !policy.allowsMethod(r.Method) {
+		observeError(errorClassPolicy)
+		finish(http.StatusMethodNotAllowed, errors.New("method not allowed by policy"))
 
-	body, err := io.ReadAll(r.Body)
+		w.Header().Set("Allow", policy.allowHeader())
+		w.Header().Add("Content-Type", "text/plain")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	if !policy.allowsPath(r.URL.Path) {
+		observeError(errorClassPolicy)
+		finish(http.StatusForbidden, errors.New("path not allowed by policy"))
+
+		w.Header().Add("Content-Type", "text/plain")
+		http.Error(w, "forbidden", http.StatusForbidden)
+
+		return
+	}
+
+	if !policy.allowsRequest() {
+		observeError(errorClassRateLimit)
+		finish(http.StatusTooManyRequests, errors.New("rate limited by policy"))
 
-	if err != nil {
 		w.Header().Add("Content-Type", "text/plain")
-		http.Error(w, fmt.Sprintf("failed to read body: %s", err), http.StatusBadRequest)
+		http.Error(w, "too many requests", http.StatusTooManyRequests)
 
 		return
 	}
 
-	remoteReq := &http.Request{
-		Method:        r.Method,
-		URL:           &url,
-		Header:        r.Header,
-		Body:          io.NopCloser(bytes.NewBuffer(body)),
-		ContentLength: r.ContentLength,
-		Host:          remoteHost,
+	if maxBodySize > 0 && r.ContentLength > maxBodySize {
+		observeError(errorClassClient)
+		finish(http.StatusRequestEntityTooLarge, errors.New("request body too large"))
+
+		w.Header().Add("Content-Type", "text/plain")
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+
+		return
 	}
 
-	remoteReq.Header.Set("Host", remoteHost)
-	remoteReq.Header.Add("X-Forwarded-For", r.RemoteAddr)
-	remoteReq.Header.Add("X-Forwarded-Host", r.Host)
-	remoteReq.Header.Add("X-Forwarded-Proto", r.Proto)
+	// stream the body instead of buffering it, chunked if the length is unknown
+	var body io.ReadCloser = http.NoBody
 
-	remoteReq.Host = remoteHost
-	remoteReq = remoteReq.WithContext(r.Context())
+	if r.ContentLength != 0 {
+		body = r.Body
+
+		if maxBodySize > 0 {
+			body = http.MaxBytesReader(w, body, maxBodySize)
+		}
+	}
+
+	var remoteBody io.ReadCloser = http.NoBody
+
+	// otherwise the transport can't tell the body is empty, and sends it chunked
+	if body != http.NoBody {
+		remoteBody = &countingReader{ReadCloser: body, counter: requestBytes}
+	}
+
+	remoteHeader := r.Header
+
+	remoteHeader.Add("X-Forwarded-For", r.RemoteAddr)
+	remoteHeader.Add("X-Forwarded-Host", r.Host)
+	remoteHeader.Add("X-Forwarded-Proto", r.Proto)
+
+	if span != nil {
+		span.inject(remoteHeader)
+	}
+
+	for header, values := range extraHeaders {
+		remoteHeader[header] = values
+	}
+
+	policy.rewriteRequest(remoteHeader)
+
+	// each attempt might go to another endpoint, with its own host and credentials
+	newTarget := func(e *endpoint) *remoteTarget {
+		targetURL, host, targetBreaker := remoteURL, remoteHost, breaker
+
+		if e != nil {
+			targetURL, host, targetBreaker = e.url, e.Host, e.breaker
+			endpointRequestsTotal.WithLabelValues(e.Name).Inc()
+		}
+
+		url := *targetURL
+		url.Path = r.URL.Path
+		url.RawQuery = r.URL.RawQuery
+		url.Fragment = r.URL.Fragment
+
+		header := remoteHeader.Clone()
+		header.Set("Host", host)
+
+		// peers aren't authenticated to, the credentials are only for the remote clusters
+		if e != nil && e.Peer {
+			header.Set(peerHeader, e.Name)
+		} else if remoteCredentials != nil {
+			remoteCredentials.setToken(header)
+		}
+
+		remoteReq := &http.Request{
+			Method:        r.Method,
+			URL:           &url,
+			Header:        header,
+			Body:          remoteBody,
+			ContentLength: r.ContentLength,
+			Trailer:       r.Trailer,
+			Host:          host,
+		}
+
+		return &remoteTarget{
+			client:   clientFor(r, targetURL, e),
+			req:      remoteReq.WithContext(r.Context()),
+			breaker:  targetBreaker,
+			endpoint: e,
+		}
+	}
+
+	remoteStart := time.Now()
+
+	// this doesn't do absolute url for proxies ([GET /] vs [GET http://foo.bar/])
+	res, sent, err := retries.do(r, func(failed []*endpoint) *remoteTarget {
+		if len(failed) > 0 {
+			targetEndpoint = endpoints.pick(allowPeers, failed)
+		}
+
+		return newTarget(targetEndpoint)
+	})
 
-	start := time.Now()
-	res, err := client.Do(remoteReq) // this doesn't do absolute url for proxies ([GET /] vs [GET http://foo.bar/])
 	end := time.Now()
-	duration := end.Sub(start)
+	duration := end.Sub(remoteStart)
+
+	if sent.endpoint != nil {
+		headers.Set("x-knative-edge-proxy-endpoint", sent.endpoint.Name)
+		headers.Set("x-knative-edge-proxy-host", sent.endpoint.Host)
+		headers.Set("x-knative-edge-proxy-url", sent.endpoint.url.String())
+	}
 
 	headers.Set("x-knative-edge-proxy-duration", duration.String())
 
 	if err != nil {
+		if isBodyTooLarge(err) {
+			observeError(errorClassClient)
+			finish(http.StatusRequestEntityTooLarge, err)
+
+			w.Header().Add("Content-Type", "text/plain")
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+
+			return
+		}
+
+		if errors.Is(err, errCircuitOpen) {
+			observeError(errorClassCircuit)
+			finish(http.StatusServiceUnavailable, err)
+
+			w.Header().Add("Content-Type", "text/plain")
+			http.Error(w, "service unavailable: remote cluster is unreachable", http.StatusServiceUnavailable)
+
+			return
+		}
+
+		if isTimeout(err) {
+			observeError(errorClassTimeout)
+		} else {
+			observeError(errorClassConnection)
+		}
+
+		finish(http.StatusBadGateway, err)
+
 		w.Header().Add("Content-Type", "text/plain")
 
-		if errors.Is(err, context.DeadlineExceeded) {
+		if isTimeout(err) {
 			http.Error(w, "bad gateway: couldn't proxy to remote", http.StatusBadGateway)
 		} else {
 			http.Error(w, fmt.Sprintf("gateway error: %s", err), http.StatusBadGateway)
@@ -104,7 +293,9 @@ func handler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	headers.Set("x-knative-edge-proxy-upstream", res.Header.Get("x-envoy-upstream-service-time"))
+	upstreamServiceTime = res.Header.Get("x-envoy-upstream-service-time")
+	observeUpstreamStatus(res.StatusCode)
+	headers.Set("x-knative-edge-proxy-upstream", upstreamServiceTime)
 
 	for _, header := range dropHeaders {
 		res.Header.Del(header)
@@ -116,16 +307,32 @@ func handler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	policy.rewriteResponse(headers)
+
+	if res.StatusCode == http.StatusSwitchingProtocols && isUpgradeRequest(r) {
+		sent, received, err := handleUpgradeResponse(w, res)
+
+		requestBytes.Add(float64(sent))
+		responseBytes.Add(float64(received))
+		finish(res.StatusCode, err)
+
+		if err != nil {
+			log.Printf("[%s %s] upgrade to %s failed: %s\n", r.Method, r.URL.Path, res.Header.Get("Upgrade"), err)
+		}
+
+		return
+	}
+
 	w.WriteHeader(res.StatusCode)
 	defer res.Body.Close()
 
-	if res.ContentLength > 0 {
-		io.CopyN(w, res.Body, res.ContentLength)
-	} else {
-		io.Copy(w, res.Body)
-	}
+	written, err := copyResponse(w, res.Body, shouldFlushImmediately(res) || isGrpcRequest(r))
+	copyTrailers(w, res)
 
-	log.Printf("[%s %s] status: %d, content-length: %d, duration: %s, duration2: %s\n", r.Method, r.URL.Path, res.StatusCode, res.ContentLength, duration.String(), res.Header.Get("x-envoy-upstream-service-time"))
+	responseBytes.Add(float64(written))
+	finish(res.StatusCode, err)
+
+	log.Printf("[%s %s] status: %d, content-length: %d, duration: %s, duration2: %s\n", r.Method, r.URL.Path, res.StatusCode, res.ContentLength, duration.String(), upstreamServiceTime)
 }
 
 func main() {
@@ -141,17 +348,24 @@ func main() {
 		}
 	}
 
-	remoteProxyUrl := os.Getenv("REMOTE_PROXY")
-
-	if remoteProxyUrl != "" {
-		var err error
-		remoteProxyURL, err = url.Parse(remoteProxyUrl)
+	if endpointsStr := os.Getenv("REMOTE_ENDPOINTS"); endpointsStr != "" {
+		newEndpoints, err := parseEndpoints(endpointsStr, os.Getenv("ENDPOINT_SELECTION"))
 
 		if err != nil {
-			log.Printf("Error: cannot read remote proxy url: %s", err)
+			log.Printf("Error: %s. Using the remote url only.\n", err)
+		} else {
+			endpoints = newEndpoints
+			log.Printf("Picking among %d remote endpoints by %s.\n", len(endpoints.endpoints), endpoints.selection)
 		}
 	}
 
+	proxyConfig := newProxyConfigFromEnv(os.Getenv)
+	proxyFunc = proxyConfig.ProxyFunc()
+
+	if proxyConfig.HTTPProxy != "" || proxyConfig.HTTPSProxy != "" {
+		log.Printf("Using proxy %s for http and %s for https, except for %s.\n", proxyConfig.HTTPProxy, proxyConfig.HTTPSProxy, proxyConfig.NoProxy)
+	}
+
 	timeoutStr := os.Getenv("REMOTE_TIMEOUT")
 
 	if timeoutStr == "" {
@@ -167,32 +381,152 @@ func main() {
 		}
 	}
 
+	if idleTimeoutStr := os.Getenv("UPGRADE_IDLE_TIMEOUT"); idleTimeoutStr != "" {
+		newIdleTimeout, err := time.ParseDuration(idleTimeoutStr)
+
+		if err != nil || newIdleTimeout <= 0 {
+			log.Printf("Couldn't parse upgrade idle timeout env. Using default of %s.\n", upgradeIdleTimeout.String())
+		} else {
+			upgradeIdleTimeout = newIdleTimeout
+			log.Printf("Set upgrade idle timeout to %s.\n", idleTimeoutStr)
+		}
+	}
+
+	if extraHeadersStr := os.Getenv("EXTRA_HEADERS"); extraHeadersStr != "" {
+		extraHeaders = parseExtraHeaders(extraHeadersStr)
+		log.Printf("Sending %d extra headers to the remote service.\n", len(extraHeaders))
+	}
+
+	if policyStr := os.Getenv("PROXY_POLICY"); policyStr != "" {
+		newPolicy, err := parseProxyPolicy(policyStr)
+
+		if err != nil {
+			log.Printf("Error: %s\n", err)
+		} else {
+			policy = newPolicy
+			log.Printf("Using proxy policy %s.\n", os.Getenv("PROXY_POLICY_NAME"))
+		}
+	}
+
+	if maxBodySizeStr := os.Getenv("MAX_BODY_SIZE"); maxBodySizeStr != "" {
+		newMaxBodySize, err := strconv.ParseInt(maxBodySizeStr, 10, 64)
+
+		if err != nil || newMaxBodySize < 0 {
+			log.Printf("Couldn't parse max body size env. Request bodies won't be limited.\n")
+		} else {
+			maxBodySize = newMaxBodySize
+			log.Printf("Set max body size to %d bytes.\n", maxBodySize)
+		}
+	}
+
 	addr := os.Getenv("BIND_ADDRESS")
 
 	if addr == "" {
 		addr = defaultAddr
 	}
 
+	var tlsConfig *tls.Config
+
+	if remoteCredentials = newCredentialsFromEnv(os.Getenv); remoteCredentials != nil {
+		log.Printf("Using remote credentials from %s.\n", remoteCredentials.path)
+
+		tlsConfig = remoteCredentials.tlsConfig(remoteHost)
+		go remoteCredentials.watch()
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
 	// same as http.DefaultClient, but using custom proxy
 	client = &http.Client{
 		Transport: &http.Transport{
-			Proxy: func(r *http.Request) (*url.URL, error) {
-				return remoteProxyURL, nil
-			},
-			DialContext: (&net.Dialer{
-				Timeout:   30 * time.Second,
-				KeepAlive: 30 * time.Second,
-			}).DialContext,
+			Proxy:                 remoteProxy,
+			DialContext:           dialer.DialContext,
+			TLSClientConfig:       tlsConfig,
 			ForceAttemptHTTP2:     true,
 			MaxIdleConns:          100,
 			IdleConnTimeout:       90 * time.Second,
 			TLSHandshakeTimeout:   10 * time.Second,
 			ExpectContinueTimeout: 1 * time.Second,
+			ResponseHeaderTimeout: timeout,
 		},
 	}
 
-	log.Printf("Listening to x %s.\n", addr)
+	h2cClient = newH2CClient(dialer)
+
+	retries = newRetryPolicyFromEnv(os.Getenv)
+	breaker = newCircuitBreakerFromEnv(os.Getenv, "remote")
+
+	log.Printf("Retrying idempotent requests up to %d times, circuit breaker opens after %d failures.\n", retries.attempts, breaker.threshold)
+
+	if endpoints != nil {
+		endpoints.setup = func(e *endpoint) {
+			// the server name of the remote certificate is different for each endpoint, and peers
+			// don't get the client certificate of the remote clusters
+			if tlsConfig != nil {
+				transport := client.Transport.(*http.Transport).Clone()
+				transport.TLSClientConfig = nil
+
+				if !e.Peer {
+					transport.TLSClientConfig = remoteCredentials.tlsConfig(e.Host)
+				}
+
+				e.client = &http.Client{Transport: transport}
+			}
+
+			// each endpoint has its own circuit breaker, so a failing one doesn't stop the others
+			e.breaker = &circuitBreaker{name: e.Name, threshold: breaker.threshold, timeout: breaker.timeout}
+			e.stop = make(chan struct{})
+
+			go e.breaker.probe(func() error { return probeEndpoint(e) }, e.stop)
+		}
+
+		for _, e := range endpoints.list() {
+			endpoints.setup(e)
+		}
+
+		if peersFile := os.Getenv("PEER_ENDPOINTS_FILE"); peersFile != "" {
+			log.Printf("Reading peer endpoints from %s.\n", peersFile)
+			go endpoints.watchPeers(peersFile)
+		}
+
+		probePeriod := defaultEndpointProbePeriod
+		parseDurationEnv(os.Getenv, "ENDPOINT_PROBE_PERIOD", &probePeriod)
+
+		go endpoints.probe(probePeriod)
+	} else if remoteURL != nil {
+		go breaker.probe(probeRemote, nil)
+	}
+
+	metricsAddr := os.Getenv("METRICS_BIND_ADDRESS")
+
+	if metricsAddr == "" {
+		metricsAddr = defaultMetricsAddr
+	}
+
+	// the edge controller relies on the remote health endpoint, so don't run without it
+	metricsListener, err := net.Listen("tcp", metricsAddr)
+
+	if err != nil {
+		log.Fatalf("Error: cannot serve metrics on %s: %s\n", metricsAddr, err)
+	}
+
+	go func() {
+		log.Printf("Serving metrics on %s.\n", metricsAddr)
+		log.Fatalf("Error: cannot serve metrics: %s\n", serveMetrics(metricsListener))
+	}()
+
+	if exporter = newOtlpExporterFromEnv(os.Getenv); exporter != nil {
+		log.Printf("Exporting traces to %s.\n", exporter.endpoint)
+		go exporter.run()
+	}
+
+	log.Printf("Listening to %s.\n", addr)
 
 	http.HandleFunc("/", handler)
-	log.Fatal(http.ListenAndServe("0.0.0.0:8080", nil))
+
+	// accept HTTP/2 without TLS as well, which is used by knative for h2c ports
+	log.Fatal(http.ListenAndServe(addr, h2c.NewHandler(http.DefaultServeMux, &http2.Server{})))
 }
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// useRemote points the handler at the remote url for the current spec, with the defaults of main
+// and neither endpoints, policy nor exporter.
+func useRemote(remote string) {
+	u, err := url.Parse(remote)
+	Expect(err).NotTo(HaveOccurred())
+
+	savedURL, savedHost := remoteURL, remoteHost
+	savedClient, savedH2CClient := client, h2cClient
+	savedRetries, savedBreaker := retries, breaker
+	savedPolicy, savedEndpoints, savedExporter := policy, endpoints, exporter
+
+	DeferCleanup(func() {
+		remoteURL, remoteHost = savedURL, savedHost
+		client, h2cClient = savedClient, savedH2CClient
+		retries, breaker = savedRetries, savedBreaker
+		policy, endpoints, exporter = savedPolicy, savedEndpoints, savedExporter
+	})
+
+	remoteURL, remoteHost = u, u.Host
+	client = &http.Client{}
+	h2cClient = newH2CClient(&net.Dialer{Timeout: time.Second})
+	retries = retryPolicy{}
+	breaker = &circuitBreaker{name: "remote", threshold: defaultCircuitBreakerThreshold, timeout: time.Minute}
+	policy, endpoints, exporter = nil, nil, nil
+}
+
+func counterValue(counter prometheus.Counter) float64 {
+	metric := &dto.Metric{}
+	Expect(counter.Write(metric)).To(Succeed())
+
+	return metric.GetCounter().GetValue()
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	defaultMetricsAddr = ":9095"
+	remoteHealthPath   = "/healthz/remote"
+	metricsNamespace   = "knative_edge_proxy"
+)
+
+const (
+	errorClassConfig     = "config"
+	errorClassClient     = "client"
+	errorClassTimeout    = "timeout"
+	errorClassConnection = "connection"
+	errorClassUpstream   = "upstream"
+	errorClassCircuit    = "circuit_open"
+	errorClassPolicy     = "policy"
+	errorClassRateLimit  = "rate_limit"
+)
+
+var (
+	requestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "requests_total",
+			Help:      "Number of requests proxied to the remote cluster.",
+		},
+		[]string{"method", "code"},
+	)
+
+	requestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "request_duration_seconds",
+			Help:      "Time taken to proxy a request to the remote cluster, including the response body.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"method", "code"},
+	)
+
+	upstreamDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "upstream_duration_seconds",
+			Help:      "Time taken by the remote service, as reported by x-envoy-upstream-service-time.",
+			Buckets:   prometheus.DefBuckets,
+		},
+	)
+
+	requestBytes = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "request_bytes_total",
+			Help:      "Number of request body bytes sent to the remote cluster.",
+		},
+	)
+
+	responseBytes = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "response_bytes_total",
+			Help:      "Number of response body bytes received from the remote cluster.",
+		},
+	)
+
+	errorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "errors_total",
+			Help:      "Number of failed requests, by class of error.",
+		},
+		[]string{"class"},
+	)
+
+	retriesTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "retries_total",
+			Help:      "Number of requests sent again to the remote cluster after a failure.",
+		},
+	)
+
+	circuitBreakerOpen = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "circuit_breaker_open",
+			Help:      "Whether requests to the remote endpoint are currently being rejected (1) or not (0).",
+		},
+		[]string{"endpoint"},
+	)
+
+	endpointUp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "endpoint_up",
+			Help:      "Whether the remote endpoint answered the last probe (1) or not (0).",
+		},
+		[]string{"endpoint"},
+	)
+
+	endpointRTT = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "endpoint_rtt_seconds",
+			Help:      "Moving average of the round trip time of the probes of the remote endpoint.",
+		},
+		[]string{"endpoint"},
+	)
+
+	endpointRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "endpoint_requests_total",
+			Help:      "Number of requests proxied to each remote endpoint.",
+		},
+		[]string{"endpoint"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(
+		requestsTotal,
+		requestDuration,
+		upstreamDuration,
+		requestBytes,
+		responseBytes,
+		errorsTotal,
+		retriesTotal,
+		circuitBreakerOpen,
+		endpointUp,
+		endpointRTT,
+		endpointRequestsTotal,
+	)
+}
+
+func observeRequest(method string, code int, duration time.Duration, upstreamServiceTime string) {
+	codeStr := strconv.Itoa(code)
+
+	requestsTotal.WithLabelValues(method, codeStr).Inc()
+	requestDuration.WithLabelValues(method, codeStr).Observe(duration.Seconds())
+
+	// envoy reports it in milliseconds
+	if upstreamMs, err := strconv.ParseFloat(upstreamServiceTime, 64); err == nil {
+		upstreamDuration.Observe(upstreamMs / 1000)
+	}
+}
+
+// observeUpstreamStatus counts the server errors of the responses received from the remote
+// cluster, the failures to get one are counted by their own class.
+func observeUpstreamStatus(code int) {
+	if code >= 500 {
+		errorsTotal.WithLabelValues(errorClassUpstream).Inc()
+	}
+}
+
+func observeError(class string) {
+	errorsTotal.WithLabelValues(class).Inc()
+}
+
+// remoteHealthHandler tells the edge controller whether the remote cluster is reachable, so it
+// can stop offloading traffic while it isn't.
+func remoteHealthHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+
+	if isRemoteUnreachable() {
+		http.Error(w, "remote cluster is unreachable", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Write([]byte("ok"))
+}
+
+// serveMetrics serves the metrics on their own listener, so they don't shadow a path of the
+// proxied service.
+func serveMetrics(listener net.Listener) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc(remoteHealthPath, remoteHealthHandler)
+
+	return http.Serve(listener, mux)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("request metrics", func() {
+	// counts returns the requests with the status, and the errors of each class
+	counts := func(method string, status string) map[string]float64 {
+		ret := map[string]float64{"requests": counterValue(requestsTotal.WithLabelValues(method, status))}
+
+		for _, class := range []string{errorClassUpstream, errorClassConnection, errorClassConfig, errorClassPolicy} {
+			ret[class] = counterValue(errorsTotal.WithLabelValues(class))
+		}
+
+		return ret
+	}
+
+	// delta returns how much each count increased while the request was handled
+	delta := func(method, status string, r *http.Request) map[string]float64 {
+		before := counts(method, status)
+		handler(httptest.NewRecorder(), r)
+		after := counts(method, status)
+
+		for key := range after {
+			after[key] -= before[key]
+		}
+
+		return after
+	}
+
+	newRemote := func(status int) string {
+		remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+		DeferCleanup(remote.Close)
+
+		return remote.URL
+	}
+
+	It("counts the server errors of the remote as upstream errors", func() {
+		useRemote(newRemote(http.StatusServiceUnavailable))
+
+		Expect(delta(http.MethodGet, "503", httptest.NewRequest(http.MethodGet, "/", nil))).To(Equal(map[string]float64{
+			"requests": 1, errorClassUpstream: 1, errorClassConnection: 0, errorClassConfig: 0, errorClassPolicy: 0,
+		}))
+	})
+
+	It("doesn't count the responses of the remote which succeeded as errors", func() {
+		useRemote(newRemote(http.StatusOK))
+
+		Expect(delta(http.MethodGet, "200", httptest.NewRequest(http.MethodGet, "/", nil))).To(Equal(map[string]float64{
+			"requests": 1, errorClassUpstream: 0, errorClassConnection: 0, errorClassConfig: 0, errorClassPolicy: 0,
+		}))
+	})
+
+	It("counts the remote which can't be reached as a connection error only", func() {
+		remote := httptest.NewServer(http.NotFoundHandler())
+		remote.Close()
+
+		useRemote(remote.URL)
+
+		Expect(delta(http.MethodGet, "502", httptest.NewRequest(http.MethodGet, "/", nil))).To(Equal(map[string]float64{
+			"requests": 1, errorClassUpstream: 0, errorClassConnection: 1, errorClassConfig: 0, errorClassPolicy: 0,
+		}))
+	})
+
+	It("records the requests the proxy rejects", func() {
+		useRemote(newRemote(http.StatusOK))
+		policy = &proxyPolicy{AllowedMethods: []string{http.MethodGet}}
+
+		Expect(delta(http.MethodPost, "405", httptest.NewRequest(http.MethodPost, "/", nil))).To(Equal(map[string]float64{
+			"requests": 1, errorClassUpstream: 0, errorClassConnection: 0, errorClassConfig: 0, errorClassPolicy: 1,
+		}))
+	})
+
+	It("records the requests without a remote", func() {
+		useRemote("")
+		remoteURL = nil
+
+		Expect(delta(http.MethodGet, "502", httptest.NewRequest(http.MethodGet, "/", nil))).To(Equal(map[string]float64{
+			"requests": 1, errorClassUpstream: 0, errorClassConnection: 0, errorClassConfig: 1, errorClassPolicy: 0,
+		}))
+	})
+})
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	otlpBatchSize     = 512
+	otlpQueueSize     = 4096
+	otlpFlushPeriod   = time.Second * 5
+	otlpExportTimeout = time.Second * 10
+
+	// https://opentelemetry.io/docs/specs/otlp/
+	otlpSpanKindServer  = 2
+	otlpStatusCodeError = 2
+)
+
+// otlpExporter sends the spans of the proxy to an OpenTelemetry collector, in batches, using
+// OTLP over HTTP with the json encoding.
+type otlpExporter struct {
+	endpoint    string
+	serviceName string
+	client      *http.Client
+	spans       chan *span
+}
+
+type otlpAttribute struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+type otlpSpan struct {
+	TraceId           string          `json:"traceId"`
+	SpanId            string          `json:"spanId"`
+	ParentSpanId      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            struct {
+		Code int `json:"code,omitempty"`
+	} `json:"status"`
+}
+
+// newOtlpExporterFromEnv follows the environment variables of the OpenTelemetry SDKs. It returns
+// nil when no endpoint is configured.
+func newOtlpExporterFromEnv(getenv func(string) string) *otlpExporter {
+	endpoint := getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+
+	if endpoint == "" {
+		if base := getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); base != "" {
+			endpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+		}
+	}
+
+	if endpoint == "" {
+		return nil
+	}
+
+	serviceName := getenv("OTEL_SERVICE_NAME")
+
+	if serviceName == "" {
+		serviceName = "knative-edge-proxy"
+	}
+
+	return &otlpExporter{
+		endpoint:    endpoint,
+		serviceName: serviceName,
+		client:      &http.Client{Timeout: otlpExportTimeout},
+		spans:       make(chan *span, otlpQueueSize),
+	}
+}
+
+// export queues a span, or drops it if the collector can't keep up.
+func (e *otlpExporter) export(s *span) {
+	if !s.sampled {
+		return
+	}
+
+	select {
+	case e.spans <- s:
+	default:
+		observeError("trace_dropped")
+	}
+}
+
+func (e *otlpExporter) run() {
+	batch := make([]*span, 0, otlpBatchSize)
+	ticker := time.NewTicker(otlpFlushPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case s := <-e.spans:
+			batch = append(batch, s)
+
+			if len(batch) < otlpBatchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+
+		if err := e.send(batch); err != nil {
+			log.Printf("Error: couldn't export %d spans: %s\n", len(batch), err)
+		}
+
+		batch = make([]*span, 0, otlpBatchSize)
+	}
+}
+
+func (e *otlpExporter) send(batch []*span) error {
+	spans := make([]otlpSpan, 0, len(batch))
+
+	for _, s := range batch {
+		spans = append(spans, toOtlpSpan(s))
+	}
+
+	payload := map[string]interface{}{
+		"resourceSpans": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{
+					"attributes": []otlpAttribute{toOtlpAttribute("service.name", e.serviceName)},
+				},
+				"scopeSpans": []interface{}{
+					map[string]interface{}{
+						"scope": map[string]interface{}{"name": "edge.jevv.dev/proxy"},
+						"spans": spans,
+					},
+				},
+			},
+		},
+	}
+
+	body, err := json.Marshal(payload)
+
+	if err != nil {
+		return err
+	}
+
+	res, err := e.client.Post(e.endpoint, "application/json", bytes.NewReader(body))
+
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("collector responded with status %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+func toOtlpSpan(s *span) otlpSpan {
+	ret := otlpSpan{
+		TraceId:           s.traceId,
+		SpanId:            s.spanId,
+		ParentSpanId:      s.parentSpanId,
+		Name:              s.name,
+		Kind:              otlpSpanKindServer,
+		StartTimeUnixNano: fmt.Sprint(s.start.UnixNano()),
+		EndTimeUnixNano:   fmt.Sprint(s.end.UnixNano()),
+	}
+
+	keys := make([]string, 0, len(s.attributes))
+
+	for key := range s.attributes {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		ret.Attributes = append(ret.Attributes, toOtlpAttribute(key, s.attributes[key]))
+	}
+
+	if s.failed {
+		ret.Status.Code = otlpStatusCodeError
+	}
+
+	return ret
+}
+
+func toOtlpAttribute(key string, value interface{}) otlpAttribute {
+	switch v := value.(type) {
+	case int:
+		// int64 values are strings in the json encoding
+		return otlpAttribute{Key: key, Value: map[string]interface{}{"intValue": fmt.Sprint(v)}}
+	case int64:
+		return otlpAttribute{Key: key, Value: map[string]interface{}{"intValue": fmt.Sprint(v)}}
+	default:
+		return otlpAttribute{Key: key, Value: map[string]interface{}{"stringValue": fmt.Sprint(v)}}
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/time/rate"
+)
+
+// headerRewrite of the requests or responses, as rendered from the EdgeProxyPolicy.
+type headerRewrite struct {
+	Set    map[string]string `json:"set,omitempty"`
+	Remove []string          `json:"remove,omitempty"`
+}
+
+type rateLimit struct {
+	RequestsPerSecond int32 `json:"requestsPerSecond"`
+	Burst             int32 `json:"burst,omitempty"`
+}
+
+// proxyPolicy is the EdgeProxyPolicy of the service, passed by the controller as JSON in the
+// PROXY_POLICY env. Timeouts are passed as their own env.
+type proxyPolicy struct {
+	RequestHeaders  *headerRewrite `json:"requestHeaders,omitempty"`
+	ResponseHeaders *headerRewrite `json:"responseHeaders,omitempty"`
+	AllowedMethods  []string       `json:"allowedMethods,omitempty"`
+	AllowedPaths    []string       `json:"allowedPaths,omitempty"`
+	RateLimit       *rateLimit     `json:"rateLimit,omitempty"`
+
+	limiter *rate.Limiter
+}
+
+func parseProxyPolicy(value string) (*proxyPolicy, error) {
+	policy := &proxyPolicy{}
+
+	if err := json.Unmarshal([]byte(value), policy); err != nil {
+		return nil, fmt.Errorf("couldn't parse proxy policy: %w", err)
+	}
+
+	if policy.RateLimit != nil && policy.RateLimit.RequestsPerSecond > 0 {
+		burst := int(policy.RateLimit.Burst)
+
+		if burst <= 0 {
+			burst = int(policy.RateLimit.RequestsPerSecond)
+		}
+
+		policy.limiter = rate.NewLimiter(rate.Limit(policy.RateLimit.RequestsPerSecond), burst)
+	}
+
+	return policy, nil
+}
+
+func (p *proxyPolicy) allowsMethod(method string) bool {
+	if p == nil || len(p.AllowedMethods) == 0 {
+		return true
+	}
+
+	for _, allowed := range p.AllowedMethods {
+		if strings.EqualFold(allowed, method) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *proxyPolicy) allowsPath(path string) bool {
+	if p == nil || len(p.AllowedPaths) == 0 {
+		return true
+	}
+
+	for _, prefix := range p.AllowedPaths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *proxyPolicy) allowsRequest() bool {
+	if p == nil || p.limiter == nil {
+		return true
+	}
+
+	return p.limiter.Allow()
+}
+
+func (p *proxyPolicy) allowHeader() string {
+	methods := make([]string, 0, len(p.AllowedMethods))
+
+	for _, method := range p.AllowedMethods {
+		methods = append(methods, strings.ToUpper(method))
+	}
+
+	return strings.Join(methods, ", ")
+}
+
+func (p *proxyPolicy) rewriteRequest(headers http.Header) {
+	if p != nil {
+		p.RequestHeaders.apply(headers)
+	}
+}
+
+func (p *proxyPolicy) rewriteResponse(headers http.Header) {
+	if p != nil {
+		p.ResponseHeaders.apply(headers)
+	}
+}
+
+func (h *headerRewrite) apply(headers http.Header) {
+	if h == nil {
+		return
+	}
+
+	for _, name := range h.Remove {
+		headers.Del(name)
+	}
+
+	for name, value := range h.Set {
+		headers.Set(name, value)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// newProxyConfigFromEnv reads the HTTP_PROXY, HTTPS_PROXY and NO_PROXY env. REMOTE_PROXY is
+// still supported, and used for both http and https.
+func newProxyConfigFromEnv(getenv func(string) string) *httpproxy.Config {
+	config := &httpproxy.Config{
+		HTTPProxy:  getenv("HTTP_PROXY"),
+		HTTPSProxy: getenv("HTTPS_PROXY"),
+		NoProxy:    getenv("NO_PROXY"),
+	}
+
+	if remoteProxy := getenv("REMOTE_PROXY"); remoteProxy != "" {
+		config.HTTPProxy = remoteProxy
+		config.HTTPSProxy = remoteProxy
+	}
+
+	return config
+}
+
+// proxyFor returns the proxy for the url, or nil if it should be reached directly.
+func proxyFor(u *url.URL) (*url.URL, error) {
+	if proxyFunc == nil {
+		return nil, nil
+	}
+
+	return proxyFunc(u)
+}
+
+func remoteProxy(r *http.Request) (*url.URL, error) {
+	return proxyFor(r.URL)
+}
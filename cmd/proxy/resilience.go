@@ -0,0 +1,396 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRetryAttempts           = 2
+	defaultRetryBackoff            = 100 * time.Millisecond
+	maxRetryBackoff                = 2 * time.Second
+	defaultCircuitBreakerThreshold = 5
+	defaultCircuitBreakerTimeout   = 30 * time.Second
+	remoteProbeTimeout             = 5 * time.Second
+)
+
+var errCircuitOpen = errors.New("circuit breaker is open")
+
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+}
+
+type retryPolicy struct {
+	attempts int
+	backoff  time.Duration
+}
+
+// canRetry is true for requests which can be sent again safely. Bodies are streamed, so only
+// requests without one can be replayed.
+func canRetry(r *http.Request) bool {
+	return idempotentMethods[r.Method] && r.ContentLength == 0 && !isUpgradeRequest(r)
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// isRemoteFailure is true when the remote cluster couldn't be reached, as opposed to errors
+// caused by the client or by the remote service itself. Gateway errors only count when they
+// didn't go through to Knative, e.g. the 503 of an overloaded activator doesn't.
+func isRemoteFailure(res *http.Response, err error) bool {
+	if err != nil {
+		return !isBodyTooLarge(err) && !errors.Is(err, context.Canceled)
+	}
+
+	switch res.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return !isFromService(res)
+	}
+
+	return false
+}
+
+// isFromService is true for responses which the remote ingress got from an upstream, or which
+// Knative answered.
+func isFromService(res *http.Response) bool {
+	if res.Header.Get("x-envoy-upstream-service-time") != "" {
+		return true
+	}
+
+	for name := range res.Header {
+		if strings.HasPrefix(http.CanonicalHeaderKey(name), "Knative-") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p retryPolicy) wait(ctx context.Context, attempt int) error {
+	backoff := p.backoff << attempt
+
+	if backoff > maxRetryBackoff || backoff <= 0 {
+		backoff = maxRetryBackoff
+	}
+
+	// add some jitter so proxies don't retry in lockstep
+	backoff = backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// remoteTarget is where an attempt of a request is sent, with the circuit breaker of the
+// endpoint, or the one of REMOTE_URL when the endpoint is nil.
+type remoteTarget struct {
+	client   *http.Client
+	req      *http.Request
+	breaker  *circuitBreaker
+	endpoint *endpoint
+}
+
+// do sends the request, retrying idempotent requests when the remote cluster fails, as long as
+// the circuit breaker of the target allows it. Each attempt is sent to the target returned by
+// next, which is told the endpoints which failed so far so it can pick another one.
+func (p retryPolicy) do(r *http.Request, next func(failed []*endpoint) *remoteTarget) (*http.Response, *remoteTarget, error) {
+	retryable := canRetry(r)
+	var failedEndpoints []*endpoint
+
+	for attempt := 0; ; attempt++ {
+		t := next(failedEndpoints)
+
+		if !t.breaker.allow() {
+			return nil, t, errCircuitOpen
+		}
+
+		if attempt > 0 {
+			retriesTotal.Inc()
+		}
+
+		res, err := t.client.Do(t.req)
+		failed := isRemoteFailure(res, err)
+
+		if failed {
+			t.breaker.failure()
+		} else if err == nil {
+			t.breaker.success()
+		} else {
+			t.breaker.release()
+		}
+
+		if failed && t.endpoint != nil {
+			failedEndpoints = append(failedEndpoints, t.endpoint)
+
+			// don't wait for the next probe to stop picking an endpoint which can't be reached
+			if err != nil {
+				t.endpoint.setHealthy(false)
+			}
+		}
+
+		if !failed || !retryable || attempt >= p.attempts {
+			return res, t, err
+		}
+
+		if res != nil {
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+
+		if err := p.wait(r.Context(), attempt); err != nil {
+			return nil, t, err
+		}
+	}
+}
+
+type circuitBreakerState int
+
+const (
+	circuitClosed circuitBreakerState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// circuitBreaker stops sending requests to an endpoint after too many consecutive failures.
+// After a while, a single request is let through to check if it's back.
+type circuitBreaker struct {
+	name      string
+	threshold int
+	timeout   time.Duration
+
+	lock     sync.Mutex
+	state    circuitBreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func (b *circuitBreaker) enabled() bool {
+	return b != nil && b.threshold > 0
+}
+
+func (b *circuitBreaker) setState(state circuitBreakerState) {
+	if b.state == state {
+		return
+	}
+
+	if state == circuitOpen {
+		log.Printf("Circuit breaker of %s opened after %d failures, it's unreachable.\n", b.name, b.failures)
+		circuitBreakerOpen.WithLabelValues(b.name).Set(1)
+	} else if state == circuitClosed {
+		log.Printf("Circuit breaker of %s closed, it's reachable again.\n", b.name)
+		circuitBreakerOpen.WithLabelValues(b.name).Set(0)
+	}
+
+	b.state = state
+}
+
+func (b *circuitBreaker) allow() bool {
+	if !b.enabled() {
+		return true
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if time.Since(b.openedAt) < b.timeout {
+			return false
+		}
+
+		b.setState(circuitHalfOpen)
+		b.probing = true
+
+		return true
+	case circuitHalfOpen:
+		if b.probing {
+			return false
+		}
+
+		b.probing = true
+
+		return true
+	}
+
+	return true
+}
+
+func (b *circuitBreaker) success() {
+	if !b.enabled() {
+		return
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.failures = 0
+	b.probing = false
+	b.setState(circuitClosed)
+}
+
+func (b *circuitBreaker) failure() {
+	if !b.enabled() {
+		return
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.failures++
+	b.probing = false
+
+	if b.state == circuitHalfOpen || b.failures >= b.threshold {
+		b.openedAt = time.Now()
+		b.setState(circuitOpen)
+	}
+}
+
+// release gives back the half open slot when a request failed for reasons unrelated to the
+// remote cluster.
+func (b *circuitBreaker) release() {
+	if !b.enabled() {
+		return
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.probing = false
+}
+
+func (b *circuitBreaker) isOpen() bool {
+	if !b.enabled() {
+		return false
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.state != circuitClosed
+}
+
+// probe checks the endpoint while the circuit is open. Once the controller sees the breakers open
+// it stops offloading, and the endpoint isn't picked anymore, so there might not be any request
+// to close it again. It stops when stop is closed, if ever.
+func (b *circuitBreaker) probe(check func() error, stop <-chan struct{}) {
+	if !b.enabled() {
+		return
+	}
+
+	for {
+		select {
+		case <-time.After(b.timeout):
+		case <-stop:
+			return
+		}
+
+		if !b.isOpen() || !b.allow() {
+			continue
+		}
+
+		if err := check(); err != nil {
+			b.failure()
+		} else {
+			b.success()
+		}
+	}
+}
+
+// isRemoteUnreachable tells the edge controller to stop offloading, when the circuit breaker of
+// REMOTE_URL, or the ones of all the remote endpoints, are open.
+func isRemoteUnreachable() bool {
+	if endpoints != nil {
+		return endpoints.unreachable()
+	}
+
+	return breaker.isOpen()
+}
+
+// probeRemote only cares that the remote cluster answers, whatever the status.
+func probeRemote() error {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteProbeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, remoteURL.String(), nil)
+
+	if err != nil {
+		return err
+	}
+
+	req.Host = remoteHost
+
+	res, err := client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	res.Body.Close()
+
+	if isRemoteFailure(res, nil) {
+		return errors.New(res.Status)
+	}
+
+	return nil
+}
+
+func parseIntEnv(getenv func(string) string, name string, value *int) {
+	if str := getenv(name); str != "" {
+		if parsed, err := strconv.Atoi(str); err != nil || parsed < 0 {
+			log.Printf("Couldn't parse %s env. Using default of %d.\n", name, *value)
+		} else {
+			*value = parsed
+		}
+	}
+}
+
+func parseDurationEnv(getenv func(string) string, name string, value *time.Duration) {
+	if str := getenv(name); str != "" {
+		if parsed, err := time.ParseDuration(str); err != nil || parsed <= 0 {
+			log.Printf("Couldn't parse %s env. Using default of %s.\n", name, value.String())
+		} else {
+			*value = parsed
+		}
+	}
+}
+
+func newRetryPolicyFromEnv(getenv func(string) string) retryPolicy {
+	policy := retryPolicy{attempts: defaultRetryAttempts, backoff: defaultRetryBackoff}
+
+	parseIntEnv(getenv, "RETRY_ATTEMPTS", &policy.attempts)
+	parseDurationEnv(getenv, "RETRY_BACKOFF", &policy.backoff)
+
+	return policy
+}
+
+func newCircuitBreakerFromEnv(getenv func(string) string, name string) *circuitBreaker {
+	breaker := &circuitBreaker{name: name, threshold: defaultCircuitBreakerThreshold, timeout: defaultCircuitBreakerTimeout}
+
+	parseIntEnv(getenv, "CIRCUIT_BREAKER_THRESHOLD", &breaker.threshold)
+	parseDurationEnv(getenv, "CIRCUIT_BREAKER_TIMEOUT", &breaker.timeout)
+
+	return breaker
+}
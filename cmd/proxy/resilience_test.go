@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("circuit breaker", func() {
+	const (
+		allow   = "allow"
+		success = "success"
+		failure = "failure"
+		release = "release"
+		wait    = "wait"
+	)
+
+	type step struct {
+		action        string
+		expectedAllow bool
+	}
+
+	DescribeTable("state machine",
+		func(steps []step, expectedState circuitBreakerState) {
+			b := &circuitBreaker{name: "test", threshold: 2, timeout: 10 * time.Millisecond}
+
+			for i, s := range steps {
+				switch s.action {
+				case allow:
+					Expect(b.allow()).To(Equal(s.expectedAllow), "step %d", i)
+				case success:
+					b.success()
+				case failure:
+					b.failure()
+				case release:
+					b.release()
+				case wait:
+					time.Sleep(2 * b.timeout)
+				}
+			}
+
+			Expect(b.state).To(Equal(expectedState))
+			Expect(b.isOpen()).To(Equal(expectedState != circuitClosed))
+		},
+		Entry("stays closed below the threshold",
+			[]step{{action: failure}, {action: allow, expectedAllow: true}},
+			circuitClosed),
+		Entry("resets the failures on success",
+			[]step{{action: failure}, {action: success}, {action: failure}, {action: allow, expectedAllow: true}},
+			circuitClosed),
+		Entry("opens at the threshold",
+			[]step{{action: failure}, {action: failure}, {action: allow, expectedAllow: false}},
+			circuitOpen),
+		Entry("lets a single request through once the timeout passed",
+			[]step{{action: failure}, {action: failure}, {action: wait}, {action: allow, expectedAllow: true}, {action: allow, expectedAllow: false}},
+			circuitHalfOpen),
+		Entry("closes when the half open request succeeds",
+			[]step{{action: failure}, {action: failure}, {action: wait}, {action: allow, expectedAllow: true}, {action: success}, {action: allow, expectedAllow: true}},
+			circuitClosed),
+		Entry("opens again when the half open request fails",
+			[]step{{action: failure}, {action: failure}, {action: wait}, {action: allow, expectedAllow: true}, {action: failure}, {action: allow, expectedAllow: false}},
+			circuitOpen),
+		Entry("gives back the half open slot on unrelated errors",
+			[]step{{action: failure}, {action: failure}, {action: wait}, {action: allow, expectedAllow: true}, {action: release}, {action: allow, expectedAllow: true}},
+			circuitHalfOpen),
+	)
+
+	It("is always closed when disabled", func() {
+		var nilBreaker *circuitBreaker
+		b := &circuitBreaker{name: "test", threshold: 0}
+
+		for _, breaker := range []*circuitBreaker{nilBreaker, b} {
+			breaker.failure()
+			breaker.failure()
+
+			Expect(breaker.allow()).To(BeTrue())
+			Expect(breaker.isOpen()).To(BeFalse())
+		}
+	})
+
+	It("closes after a successful probe", func() {
+		b := &circuitBreaker{name: "test", threshold: 1, timeout: 10 * time.Millisecond}
+		b.failure()
+
+		probed := make(chan struct{}, 1)
+
+		go b.probe(func() error {
+			select {
+			case probed <- struct{}{}:
+			default:
+			}
+
+			return nil
+		}, nil)
+
+		Eventually(probed).Should(Receive())
+		Eventually(b.isOpen).Should(BeFalse())
+	})
+})
+
+var _ = Describe("remote failures", func() {
+	response := func(status int, headers ...string) *http.Response {
+		res := &http.Response{StatusCode: status, Header: http.Header{}}
+
+		for i := 0; i+1 < len(headers); i += 2 {
+			res.Header.Set(headers[i], headers[i+1])
+		}
+
+		return res
+	}
+
+	DescribeTable("counting",
+		func(res *http.Response, err error, expected bool) {
+			Expect(isRemoteFailure(res, err)).To(Equal(expected))
+		},
+		Entry("transport errors", nil, errors.New("connection refused"), true),
+		Entry("timeouts", nil, context.DeadlineExceeded, true),
+		Entry("canceled requests", nil, context.Canceled, false),
+		Entry("bodies too large", nil, &http.MaxBytesError{Limit: 10}, false),
+		Entry("gateway errors of the remote ingress", response(http.StatusBadGateway), nil, true),
+		Entry("unavailable remote ingress", response(http.StatusServiceUnavailable), nil, true),
+		Entry("gateway timeouts of the remote ingress", response(http.StatusGatewayTimeout), nil, true),
+		Entry("unavailable activator", response(http.StatusServiceUnavailable, "x-envoy-upstream-service-time", "12"), nil, false),
+		Entry("gateway errors answered by Knative", response(http.StatusBadGateway, "Knative-Serving-Revision", "app-00001"), nil, false),
+		Entry("errors of the service", response(http.StatusInternalServerError), nil, false),
+		Entry("successful responses", response(http.StatusOK), nil, false),
+	)
+})
+
+var _ = Describe("retry policy", func() {
+	var servers []*httptest.Server
+
+	newEndpoint := func(name string, status int) *endpoint {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+		servers = append(servers, server)
+
+		selector, err := parseEndpoints(`[{"name":"`+name+`","url":"`+server.URL+`","host":"`+name+`"}]`, "")
+		Expect(err).NotTo(HaveOccurred())
+
+		e := selector.endpoints[0]
+		e.breaker = &circuitBreaker{name: name, threshold: 5, timeout: time.Minute}
+
+		return e
+	}
+
+	AfterEach(func() {
+		for _, server := range servers {
+			server.Close()
+		}
+
+		servers = nil
+	})
+
+	send := func(selector *endpointSelector, method string) (*http.Response, *remoteTarget, []string, error) {
+		var tried []string
+
+		p := retryPolicy{attempts: 2, backoff: time.Millisecond}
+		r := httptest.NewRequest(method, "/", nil)
+		current := selector.pick(true, nil)
+
+		res, sent, err := p.do(r, func(failed []*endpoint) *remoteTarget {
+			if len(failed) > 0 {
+				current = selector.pick(true, failed)
+			}
+
+			tried = append(tried, current.Name)
+			req, err := http.NewRequest(method, current.url.String(), nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			return &remoteTarget{client: http.DefaultClient, req: req, breaker: current.breaker, endpoint: current}
+		})
+
+		if res != nil {
+			res.Body.Close()
+		}
+
+		return res, sent, tried, err
+	}
+
+	It("retries idempotent requests on another endpoint", func() {
+		failing := newEndpoint("failing", http.StatusServiceUnavailable)
+		working := newEndpoint("working", http.StatusOK)
+		selector := &endpointSelector{selection: selectionLatency, endpoints: []*endpoint{failing, working}}
+
+		res, sent, tried, err := send(selector, http.MethodGet)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.StatusCode).To(Equal(http.StatusOK))
+		Expect(sent.endpoint).To(Equal(working))
+		Expect(tried).To(Equal([]string{"failing", "working"}))
+		Expect(failing.breaker.failures).To(Equal(1))
+	})
+
+	It("doesn't retry other requests", func() {
+		failing := newEndpoint("failing", http.StatusServiceUnavailable)
+		working := newEndpoint("working", http.StatusOK)
+		selector := &endpointSelector{selection: selectionLatency, endpoints: []*endpoint{failing, working}}
+
+		res, _, tried, err := send(selector, http.MethodPost)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.StatusCode).To(Equal(http.StatusServiceUnavailable))
+		Expect(tried).To(Equal([]string{"failing"}))
+	})
+
+	It("stops at the open circuit breaker of the endpoint", func() {
+		failing := newEndpoint("failing", http.StatusServiceUnavailable)
+		failing.breaker.threshold = 1
+		selector := &endpointSelector{selection: selectionLatency, endpoints: []*endpoint{failing}}
+
+		_, _, tried, err := send(selector, http.MethodGet)
+
+		Expect(errors.Is(err, errCircuitOpen)).To(BeTrue())
+		Expect(tried).To(Equal([]string{"failing", "failing"}))
+		Expect(selector.unreachable()).To(BeTrue())
+	})
+})
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	traceparentHeader = "traceparent"
+	b3Header          = "b3"
+	b3TraceIdHeader   = "X-B3-TraceId"
+	b3SpanIdHeader    = "X-B3-SpanId"
+	b3ParentIdHeader  = "X-B3-ParentSpanId"
+	b3SampledHeader   = "X-B3-Sampled"
+)
+
+const (
+	propagationW3C = iota
+	propagationB3Single
+	propagationB3Multi
+)
+
+// span is the part of the request handled by the proxy. Its context is propagated to the
+// remote cluster, so the remote spans are its children.
+type span struct {
+	traceId      string
+	spanId       string
+	parentSpanId string
+	sampled      bool
+
+	// trace ids in b3 can be 64 bits
+	b3TraceId   string
+	propagation int
+
+	name       string
+	start      time.Time
+	end        time.Time
+	attributes map[string]interface{}
+	failed     bool
+}
+
+func randomHex(size int) string {
+	b := make([]byte, size)
+
+	if _, err := rand.Read(b); err != nil {
+		// extremely unlikely, but ids must never be empty
+		return strings.Repeat("0", size*2-1) + "1"
+	}
+
+	return hex.EncodeToString(b)
+}
+
+func isValidHex(value string, size int) bool {
+	if len(value) != size {
+		return false
+	}
+
+	_, err := hex.DecodeString(value)
+
+	return err == nil && strings.Trim(value, "0") != ""
+}
+
+// startSpan continues the trace of the request, if any, or starts a new one. It returns nil when
+// spans aren't exported, the context of the request is then forwarded as is, since the remote
+// spans would otherwise point to a parent which doesn't exist.
+func startSpan(r *http.Request, exported bool) *span {
+	if !exported {
+		return nil
+	}
+
+	s := &span{
+		spanId:     randomHex(8),
+		start:      time.Now(),
+		name:       fmt.Sprintf("edge-proxy %s", r.Method),
+		attributes: make(map[string]interface{}),
+	}
+
+	if !s.continueW3C(r.Header) && !s.continueB3Single(r.Header) && !s.continueB3Multi(r.Header) {
+		s.traceId = randomHex(16)
+		s.sampled = true
+		s.propagation = propagationW3C
+	}
+
+	return s
+}
+
+func (s *span) continueW3C(headers http.Header) bool {
+	parts := strings.Split(headers.Get(traceparentHeader), "-")
+
+	if len(parts) != 4 || !isValidHex(parts[1], 32) || !isValidHex(parts[2], 16) || len(parts[3]) != 2 {
+		return false
+	}
+
+	flags, err := hex.DecodeString(parts[3])
+
+	if err != nil {
+		return false
+	}
+
+	s.traceId = parts[1]
+	s.parentSpanId = parts[2]
+	s.sampled = flags[0]&1 == 1
+	s.propagation = propagationW3C
+
+	return true
+}
+
+func (s *span) continueB3Single(headers http.Header) bool {
+	parts := strings.Split(headers.Get(b3Header), "-")
+
+	if len(parts) < 2 || !s.setB3TraceId(parts[0]) || !isValidHex(parts[1], 16) {
+		return false
+	}
+
+	s.parentSpanId = parts[1]
+	s.sampled = len(parts) < 3 || parts[2] == "1" || parts[2] == "d"
+	s.propagation = propagationB3Single
+
+	return true
+}
+
+func (s *span) continueB3Multi(headers http.Header) bool {
+	if !s.setB3TraceId(headers.Get(b3TraceIdHeader)) || !isValidHex(headers.Get(b3SpanIdHeader), 16) {
+		return false
+	}
+
+	sampled := headers.Get(b3SampledHeader)
+
+	s.parentSpanId = headers.Get(b3SpanIdHeader)
+	s.sampled = sampled == "" || sampled == "1" || sampled == "true"
+	s.propagation = propagationB3Multi
+
+	return true
+}
+
+func (s *span) setB3TraceId(traceId string) bool {
+	switch {
+	case isValidHex(traceId, 32):
+		s.traceId = traceId
+	case isValidHex(traceId, 16):
+		s.traceId = strings.Repeat("0", 16) + traceId
+	default:
+		return false
+	}
+
+	s.b3TraceId = traceId
+
+	return true
+}
+
+// inject sets the context of the span on the request to the remote cluster, in the format it
+// was received, and always as traceparent.
+func (s *span) inject(headers http.Header) {
+	flags := "00"
+
+	if s.sampled {
+		flags = "01"
+	}
+
+	headers.Set(traceparentHeader, fmt.Sprintf("00-%s-%s-%s", s.traceId, s.spanId, flags))
+
+	sampled := "0"
+
+	if s.sampled {
+		sampled = "1"
+	}
+
+	switch s.propagation {
+	case propagationB3Single:
+		headers.Set(b3Header, fmt.Sprintf("%s-%s-%s", s.b3TraceId, s.spanId, sampled))
+	case propagationB3Multi:
+		headers.Set(b3TraceIdHeader, s.b3TraceId)
+		headers.Set(b3SpanIdHeader, s.spanId)
+		headers.Set(b3ParentIdHeader, s.parentSpanId)
+		headers.Set(b3SampledHeader, sampled)
+	}
+}
+
+func finishSpan(s *span, statusCode int, err error) {
+	if s != nil {
+		s.finish(statusCode, err)
+	}
+}
+
+func (s *span) finish(statusCode int, err error) {
+	s.end = time.Now()
+
+	if statusCode > 0 {
+		s.attributes["http.status_code"] = statusCode
+	}
+
+	if err != nil {
+		s.attributes["error.message"] = err.Error()
+	}
+
+	s.failed = err != nil || statusCode >= 500
+}
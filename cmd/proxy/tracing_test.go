@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("trace propagation", func() {
+	const (
+		traceId  = "4bf92f3577b34da6a3ce929d0e0e4736"
+		traceId8 = "a3ce929d0e0e4736"
+		parentId = "00f067aa0ba902b7"
+	)
+
+	newRequest := func(headers map[string]string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+		for header, value := range headers {
+			r.Header.Set(header, value)
+		}
+
+		return r
+	}
+
+	DescribeTable("continuing the trace of the request",
+		func(headers map[string]string, expectedTraceId string, sampled bool, propagation int) {
+			s := startSpan(newRequest(headers), true)
+
+			Expect(s).NotTo(BeNil())
+			Expect(s.traceId).To(Equal(expectedTraceId))
+			Expect(s.parentSpanId).To(Equal(parentId))
+			Expect(s.spanId).To(HaveLen(16))
+			Expect(s.spanId).NotTo(Equal(parentId))
+			Expect(s.sampled).To(Equal(sampled))
+			Expect(s.propagation).To(Equal(propagation))
+		},
+		Entry("w3c sampled", map[string]string{traceparentHeader: "00-" + traceId + "-" + parentId + "-01"}, traceId, true, propagationW3C),
+		Entry("w3c not sampled", map[string]string{traceparentHeader: "00-" + traceId + "-" + parentId + "-00"}, traceId, false, propagationW3C),
+		Entry("b3 single", map[string]string{b3Header: traceId + "-" + parentId + "-1"}, traceId, true, propagationB3Single),
+		Entry("b3 single without sampling", map[string]string{b3Header: traceId + "-" + parentId}, traceId, true, propagationB3Single),
+		Entry("b3 single not sampled", map[string]string{b3Header: traceId + "-" + parentId + "-0"}, traceId, false, propagationB3Single),
+		Entry("b3 single debug", map[string]string{b3Header: traceId + "-" + parentId + "-d"}, traceId, true, propagationB3Single),
+		Entry("b3 single with a 64 bit trace id", map[string]string{b3Header: traceId8 + "-" + parentId + "-1"}, "0000000000000000"+traceId8, true, propagationB3Single),
+		Entry("b3 multi", map[string]string{b3TraceIdHeader: traceId, b3SpanIdHeader: parentId, b3SampledHeader: "1"}, traceId, true, propagationB3Multi),
+		Entry("b3 multi not sampled", map[string]string{b3TraceIdHeader: traceId, b3SpanIdHeader: parentId, b3SampledHeader: "0"}, traceId, false, propagationB3Multi),
+		Entry("w3c over b3", map[string]string{traceparentHeader: "00-" + traceId + "-" + parentId + "-01", b3Header: traceId8 + "-" + parentId}, traceId, true, propagationW3C),
+		Entry("b3 when traceparent is invalid", map[string]string{traceparentHeader: "00-" + traceId + "-00", b3Header: traceId + "-" + parentId}, traceId, true, propagationB3Single),
+	)
+
+	DescribeTable("starting a new trace for invalid contexts",
+		func(headers map[string]string) {
+			s := startSpan(newRequest(headers), true)
+
+			Expect(s).NotTo(BeNil())
+			Expect(isValidHex(s.traceId, 32)).To(BeTrue())
+			Expect(s.traceId).NotTo(Equal(traceId))
+			Expect(s.parentSpanId).To(BeEmpty())
+			Expect(s.propagation).To(Equal(propagationW3C))
+		},
+		Entry("traceparent with too few parts", map[string]string{traceparentHeader: "00-" + traceId + "-01"}),
+		Entry("traceparent with zero trace id", map[string]string{traceparentHeader: "00-00000000000000000000000000000000-" + parentId + "-01"}),
+		Entry("traceparent with invalid flags", map[string]string{traceparentHeader: "00-" + traceId + "-" + parentId + "-zz"}),
+		Entry("b3 single with invalid trace id", map[string]string{b3Header: "xyz-" + parentId}),
+		Entry("b3 multi without span id", map[string]string{b3TraceIdHeader: traceId}),
+	)
+
+	It("doesn't start spans which aren't exported", func() {
+		Expect(startSpan(newRequest(nil), false)).To(BeNil())
+		Expect(startSpan(newRequest(map[string]string{traceparentHeader: "00-" + traceId + "-" + parentId + "-01"}), false)).To(BeNil())
+	})
+
+	It("forwards the context of the request unchanged without an exporter", func() {
+		remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, header := range []string{traceparentHeader, b3Header} {
+				w.Header().Set("Received-"+header, r.Header.Get(header))
+			}
+		}))
+		defer remote.Close()
+
+		useRemote(remote.URL)
+
+		traceparent := "00-" + traceId + "-" + parentId + "-01"
+		b3 := traceId + "-" + parentId + "-1"
+
+		recorder := httptest.NewRecorder()
+		handler(recorder, newRequest(map[string]string{traceparentHeader: traceparent, b3Header: b3}))
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Header().Get("Received-" + traceparentHeader)).To(Equal(traceparent))
+		Expect(recorder.Header().Get("Received-" + b3Header)).To(Equal(b3))
+	})
+
+	It("starts a new trace when spans are exported", func() {
+		s := startSpan(newRequest(nil), true)
+
+		Expect(s).NotTo(BeNil())
+		Expect(isValidHex(s.traceId, 32)).To(BeTrue())
+		Expect(s.parentSpanId).To(BeEmpty())
+		Expect(s.sampled).To(BeTrue())
+		Expect(s.name).To(Equal("edge-proxy GET"))
+	})
+
+	DescribeTable("injecting the context in the format it was received",
+		func(headers map[string]string, expected map[string]string) {
+			s := startSpan(newRequest(headers), true)
+			s.spanId = "b7ad6b7169203331"
+
+			injected := http.Header{}
+			s.inject(injected)
+
+			Expect(injected).To(HaveLen(len(expected)))
+
+			for header, value := range expected {
+				Expect(injected.Get(header)).To(Equal(value), header)
+			}
+		},
+		Entry("w3c", map[string]string{traceparentHeader: "00-" + traceId + "-" + parentId + "-00"}, map[string]string{
+			traceparentHeader: "00-" + traceId + "-b7ad6b7169203331-00",
+		}),
+		Entry("b3 single", map[string]string{b3Header: traceId8 + "-" + parentId + "-1"}, map[string]string{
+			traceparentHeader: "00-0000000000000000" + traceId8 + "-b7ad6b7169203331-01",
+			b3Header:          traceId8 + "-b7ad6b7169203331-1",
+		}),
+		Entry("b3 multi", map[string]string{b3TraceIdHeader: traceId, b3SpanIdHeader: parentId, b3SampledHeader: "0"}, map[string]string{
+			traceparentHeader: "00-" + traceId + "-b7ad6b7169203331-00",
+			b3TraceIdHeader:   traceId,
+			b3SpanIdHeader:    "b7ad6b7169203331",
+			b3ParentIdHeader:  parentId,
+			b3SampledHeader:   "0",
+		}),
+	)
+
+	DescribeTable("finishing the span",
+		func(statusCode int, err error, failed bool) {
+			s := startSpan(newRequest(nil), true)
+			finishSpan(s, statusCode, err)
+
+			Expect(s.end).NotTo(BeZero())
+			Expect(s.failed).To(Equal(failed))
+
+			if statusCode > 0 {
+				Expect(s.attributes).To(HaveKeyWithValue("http.status_code", statusCode))
+			}
+
+			if err != nil {
+				Expect(s.attributes).To(HaveKeyWithValue("error.message", err.Error()))
+			}
+		},
+		Entry("ok", http.StatusOK, nil, false),
+		Entry("client error", http.StatusNotFound, nil, false),
+		Entry("server error", http.StatusBadGateway, nil, true),
+		Entry("transport error", 0, errors.New("connection refused"), true),
+	)
+
+	It("ignores spans which weren't started", func() {
+		Expect(func() { finishSpan(nil, http.StatusOK, nil) }).NotTo(Panic())
+	})
+})
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+func hasToken(header, token string) bool {
+	for _, value := range strings.Split(header, ",") {
+		if strings.EqualFold(strings.TrimSpace(value), token) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isUpgradeRequest is true for requests switching protocols, like websockets or h2c.
+func isUpgradeRequest(r *http.Request) bool {
+	return hasToken(r.Header.Get("Connection"), "upgrade") && r.Header.Get("Upgrade") != ""
+}
+
+// idleTimer closes the connections when no data went through them for a while.
+type idleTimer struct {
+	timeout time.Duration
+	timer   *time.Timer
+	expired atomic.Bool
+	lock    sync.Mutex
+}
+
+func newIdleTimer(timeout time.Duration, onIdle func()) *idleTimer {
+	t := &idleTimer{timeout: timeout}
+
+	t.timer = time.AfterFunc(timeout, func() {
+		t.expired.Store(true)
+		onIdle()
+	})
+
+	return t
+}
+
+func (t *idleTimer) reset() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.timer.Reset(t.timeout)
+}
+
+func (t *idleTimer) stop() {
+	t.timer.Stop()
+}
+
+func pipe(dst io.Writer, src io.Reader, timer *idleTimer) (int64, error) {
+	var written int64
+	buf := make([]byte, 32*1024)
+
+	for {
+		n, readErr := src.Read(buf)
+
+		if n > 0 {
+			timer.reset()
+
+			m, writeErr := dst.Write(buf[:n])
+			written += int64(m)
+
+			if writeErr != nil {
+				return written, writeErr
+			}
+		}
+
+		if readErr != nil {
+			if errors.Is(readErr, io.EOF) {
+				return written, nil
+			}
+
+			return written, readErr
+		}
+	}
+}
+
+// handleUpgradeResponse takes over the client connection after the remote service agreed to
+// switch protocols, and pipes data both ways until either side closes or goes idle.
+func handleUpgradeResponse(w http.ResponseWriter, res *http.Response) (int64, int64, error) {
+	backConn, ok := res.Body.(io.ReadWriteCloser)
+
+	if !ok {
+		return 0, 0, fmt.Errorf("remote connection can't be upgraded")
+	}
+
+	defer backConn.Close()
+
+	hijacker, ok := w.(http.Hijacker)
+
+	if !ok {
+		return 0, 0, fmt.Errorf("client connection can't be upgraded")
+	}
+
+	conn, brw, err := hijacker.Hijack()
+
+	if err != nil {
+		return 0, 0, fmt.Errorf("couldn't hijack client connection: %w", err)
+	}
+
+	defer conn.Close()
+
+	// the headers were already copied to the response writer
+	res.Header = w.Header()
+	res.Body = nil
+
+	if err := res.Write(brw); err != nil {
+		return 0, 0, fmt.Errorf("couldn't write upgrade response: %w", err)
+	}
+
+	if err := brw.Flush(); err != nil {
+		return 0, 0, fmt.Errorf("couldn't write upgrade response: %w", err)
+	}
+
+	timer := newIdleTimer(upgradeIdleTimeout, func() {
+		conn.Close()
+		backConn.Close()
+	})
+
+	defer timer.stop()
+
+	var sent, received int64
+	errChan := make(chan error, 2)
+
+	go func() {
+		// the client may have sent data along with the request, which is buffered
+		n, err := pipe(backConn, brw.Reader, timer)
+		sent = n
+		errChan <- err
+	}()
+
+	go func() {
+		n, err := pipe(conn, backConn, timer)
+		received = n
+		errChan <- err
+	}()
+
+	// when either side is done, close both
+	err = <-errChan
+	conn.Close()
+	backConn.Close()
+	<-errChan
+
+	if timer.expired.Load() {
+		log.Printf("Closed upgraded connection after being idle for %s.\n", upgradeIdleTimeout.String())
+		return sent, received, nil
+	}
+
+	return sent, received, err
+}
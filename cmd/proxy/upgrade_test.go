@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("upgrades", func() {
+	DescribeTable("detecting upgrade requests",
+		func(connection, upgrade string, expected bool) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Connection", connection)
+			r.Header.Set("Upgrade", upgrade)
+
+			Expect(isUpgradeRequest(r)).To(Equal(expected))
+		},
+		Entry("websocket", "Upgrade", "websocket", true),
+		Entry("among other tokens", "keep-alive, upgrade", "h2c", true),
+		Entry("without upgrade token", "keep-alive", "websocket", false),
+		Entry("without protocol", "Upgrade", "", false),
+	)
+
+	Describe("proxying upgraded connections", func() {
+		var proxy *httptest.Server
+
+		// the remote switches to echoing what it receives back
+		newRemote := func() string {
+			remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !isUpgradeRequest(r) {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+
+				conn, brw, err := w.(http.Hijacker).Hijack()
+				if err != nil {
+					return
+				}
+
+				defer conn.Close()
+
+				brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: " + r.Header.Get("Upgrade") + "\r\n\r\n")
+				brw.Flush()
+
+				io.Copy(conn, brw)
+			}))
+			DeferCleanup(remote.Close)
+
+			return remote.URL
+		}
+
+		dial := func() (net.Conn, *bufio.Reader) {
+			conn, err := net.Dial("tcp", proxy.Listener.Addr().String())
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(conn.Close)
+
+			_, err = conn.Write([]byte("GET /echo HTTP/1.1\r\nHost: app.example.com\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n"))
+			Expect(err).NotTo(HaveOccurred())
+
+			reader := bufio.NewReader(conn)
+			res, err := http.ReadResponse(reader, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.StatusCode).To(Equal(http.StatusSwitchingProtocols))
+			Expect(res.Header.Get("Upgrade")).To(Equal("websocket"))
+
+			return conn, reader
+		}
+
+		BeforeEach(func() {
+			useRemote(newRemote())
+
+			proxy = httptest.NewServer(http.HandlerFunc(handler))
+			DeferCleanup(proxy.Close)
+		})
+
+		It("pipes data both ways after switching protocols", func() {
+			conn, reader := dial()
+
+			for _, message := range []string{"hello\n", "world\n"} {
+				_, err := conn.Write([]byte(message))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(reader.ReadString('\n')).To(Equal(message))
+			}
+		})
+
+		It("closes idle connections", func() {
+			saved := upgradeIdleTimeout
+			upgradeIdleTimeout = 50 * time.Millisecond
+			DeferCleanup(func() { upgradeIdleTimeout = saved })
+
+			conn, reader := dial()
+			Expect(conn.SetReadDeadline(time.Now().Add(5 * time.Second))).To(Succeed())
+
+			_, err := reader.ReadByte()
+			Expect(err).To(MatchError(io.EOF))
+		})
+	})
+})
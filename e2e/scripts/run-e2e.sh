@@ -21,6 +21,15 @@ echo "Deploying metrics server..."
 $KUSTOMIZE build e2e/config/metrics | KUBECONFIG=$TMP/kubeconfig-cloud kubectl apply -f -
 $KUSTOMIZE build e2e/config/metrics | KUBECONFIG=$TMP/kubeconfig-edge kubectl apply -f -
 
+echo ""
+echo "Deploying cert-manager for the webhook certificates..."
+
+KUBECONFIG=$TMP/kubeconfig-cloud kubectl apply -f https://github.com/cert-manager/cert-manager/releases/download/v1.10.1/cert-manager.yaml
+KUBECONFIG=$TMP/kubeconfig-edge kubectl apply -f https://github.com/cert-manager/cert-manager/releases/download/v1.10.1/cert-manager.yaml
+
+KUBECONFIG=$TMP/kubeconfig-cloud kubectl wait -n cert-manager deployments/cert-manager-webhook --for condition=Available=True --timeout=5m
+KUBECONFIG=$TMP/kubeconfig-edge kubectl wait -n cert-manager deployments/cert-manager-webhook --for condition=Available=True --timeout=5m
+
 echo ""
 echo "Deploying Knative Operator..."
 
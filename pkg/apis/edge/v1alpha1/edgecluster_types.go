@@ -32,10 +32,62 @@ type EdgeClusterSpec struct {
 	Environments []string `json:"environments"`
 }
 
+type EdgeClusterPhase string
+
+const (
+	// The edge controller is reporting and everything is in sync.
+	EdgeClusterPhaseReady EdgeClusterPhase = "Ready"
+	// The edge controller is reporting, but some conditions are not met.
+	EdgeClusterPhaseDegraded EdgeClusterPhase = "Degraded"
+	// The edge controller hasn't reported in a while.
+	EdgeClusterPhaseUnreachable EdgeClusterPhase = "Unreachable"
+)
+
+const (
+	// The edge controller is able to reach the remote cluster.
+	EdgeClusterConditionConnected = "Connected"
+	// The mirrored resources on the edge match the resources in the remote cluster.
+	EdgeClusterConditionSynced = "Synced"
+	// The edge controller encountered errors while collecting its status.
+	EdgeClusterConditionDegraded = "Degraded"
+	// Some mirrored resources were changed on the edge and the changes were kept, or they're pinned.
+	EdgeClusterConditionDrifted = "Drifted"
+)
+
+// EdgeClusterUtilization is the percentage of the capacity of the nodes in use
+type EdgeClusterUtilization struct {
+	// The percentage of cpu in use.
+	Cpu int64 `json:"cpu"`
+	// The percentage of memory in use.
+	Memory int64 `json:"memory"`
+}
+
 // EdgeClusterStatus defines the observed state of EdgeCluster
 type EdgeClusterStatus struct {
 	// The time the EdgeCluster last reported.
 	LastReportedAt string `json:"lastReportedAt,omitempty"`
+	// The phase of the EdgeCluster, derived from the conditions and the last report.
+	// +optional
+	Phase EdgeClusterPhase `json:"phase,omitempty"`
+	// The version of the edge controller which last reported.
+	// +optional
+	ControllerVersion string `json:"controllerVersion,omitempty"`
+	// The number of resources mirrored to the EdgeCluster, by kind.
+	// +optional
+	SyncedObjects map[string]int64 `json:"syncedObjects,omitempty"`
+	// The percentage of traffic offloaded to the remote cluster, by service (namespace/name).
+	// +optional
+	OffloadTraffic map[string]int64 `json:"offloadTraffic,omitempty"`
+	// The url the other EdgeClusters of the region offload to, e.g. the ingress of the EdgeCluster.
+	// +optional
+	PeerURL string `json:"peerUrl,omitempty"`
+	// The cpu and memory used on the EdgeCluster, which tell whether it can take the traffic of its
+	// peers.
+	// +optional
+	Utilization *EdgeClusterUtilization `json:"utilization,omitempty"`
+	// The status conditions of EdgeCluster
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -43,8 +95,10 @@ type EdgeClusterStatus struct {
 // +kubebuilder:resource:scope=Cluster
 // +kubebuilder:printcolumn:name=Zone,JSONPath=".spec.zone",type=string,priority=0
 // +kubebuilder:printcolumn:name=Region,JSONPath=".spec.region",type=string,priority=0
+// +kubebuilder:printcolumn:name=Phase,JSONPath=".status.phase",type=string,priority=0
 // +kubebuilder:printcolumn:name=Environments,JSONPath=".spec.environments",type=string,priority=1
 // +kubebuilder:printcolumn:name="Last Reported",JSONPath=".status.lastReportedAt",type=string,priority=1
+// +kubebuilder:printcolumn:name=Version,JSONPath=".status.controllerVersion",type=string,priority=1
 
 // EdgeCluster is the Schema for the edgeclusters API
 type EdgeCluster struct {
@@ -0,0 +1,93 @@
+/*
+Copyright 2022 jevv k.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EdgeProxyPolicySpec defines how the edge proxies of Knative Services forward requests to the cloud
+type EdgeProxyPolicySpec struct {
+	// How long to wait for the cloud service to start responding, e.g. 30s.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// How long an upgraded connection, like a websocket, can stay idle before it's closed.
+	// +optional
+	IdleTimeout *metav1.Duration `json:"idleTimeout,omitempty"`
+	// Headers rewritten on the requests sent to the cloud.
+	// +optional
+	RequestHeaders *EdgeProxyHeaderRewrite `json:"requestHeaders,omitempty"`
+	// Headers rewritten on the responses sent back to the client.
+	// +optional
+	ResponseHeaders *EdgeProxyHeaderRewrite `json:"responseHeaders,omitempty"`
+	// The methods which can be offloaded. All methods are allowed when empty.
+	// +optional
+	AllowedMethods []string `json:"allowedMethods,omitempty"`
+	// The path prefixes which can be offloaded. All paths are allowed when empty.
+	// +optional
+	AllowedPaths []string `json:"allowedPaths,omitempty"`
+	// Limit of the requests offloaded by each edge proxy replica.
+	// +optional
+	RateLimit *EdgeProxyRateLimit `json:"rateLimit,omitempty"`
+}
+
+type EdgeProxyHeaderRewrite struct {
+	// Headers to set, replacing any existing value.
+	// +optional
+	Set map[string]string `json:"set,omitempty"`
+	// Headers to remove.
+	// +optional
+	Remove []string `json:"remove,omitempty"`
+}
+
+type EdgeProxyRateLimit struct {
+	// The sustained number of requests per second.
+	// +kubebuilder:validation:Minimum:=1
+	RequestsPerSecond int32 `json:"requestsPerSecond"`
+	// The number of requests which can exceed the rate at once. Defaults to requestsPerSecond.
+	// +optional
+	Burst int32 `json:"burst,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Namespaced
+// +kubebuilder:printcolumn:name=Timeout,JSONPath=".spec.timeout",type=string,priority=0
+// +kubebuilder:printcolumn:name="Rate Limit",JSONPath=".spec.rateLimit.requestsPerSecond",type=integer,priority=0
+
+// EdgeProxyPolicy is the policy of the edge proxies of the Knative Services in its namespace.
+// Services select it with the edge.jevv.dev/proxy-policy annotation, otherwise they use the
+// policy named default. Like the services, it needs the edge.jevv.dev/environment label of
+// the edge clusters.
+type EdgeProxyPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec EdgeProxyPolicySpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// EdgeProxyPolicyList contains a list of EdgeProxyPolicy
+type EdgeProxyPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EdgeProxyPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EdgeProxyPolicy{}, &EdgeProxyPolicyList{})
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2022 jevv k.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EdgeServiceStatusSpec defines which Knative Service and EdgeCluster the status belongs to
+type EdgeServiceStatusSpec struct {
+	// The name of the Knative Service in the same namespace.
+	ServiceName string `json:"serviceName"`
+	// The name of the EdgeCluster which reports the status.
+	ClusterName string `json:"clusterName"`
+}
+
+// EdgeServiceStatusStatus defines the observed state of the Knative Service on the edge
+type EdgeServiceStatusStatus struct {
+	// The time the EdgeCluster last reported.
+	LastReportedAt string `json:"lastReportedAt,omitempty"`
+	// Whether the Knative Service is ready on the edge (True, False, or Unknown).
+	// +optional
+	Ready metav1.ConditionStatus `json:"ready,omitempty"`
+	// The reason of the Ready condition on the edge.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// The message of the Ready condition on the edge.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// The URL of the Knative Service on the edge.
+	// +optional
+	URL string `json:"url,omitempty"`
+	// The latest revision created on the edge.
+	// +optional
+	LatestCreatedRevisionName string `json:"latestCreatedRevisionName,omitempty"`
+	// The latest ready revision on the edge.
+	// +optional
+	LatestReadyRevisionName string `json:"latestReadyRevisionName,omitempty"`
+	// The generation of the Knative Service on the edge observed by Knative.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// The resource version of the cloud Knative Service mirrored to the edge.
+	// +optional
+	RemoteResourceVersion string `json:"remoteResourceVersion,omitempty"`
+	// The percentage of traffic offloaded from the edge to the cloud.
+	// +optional
+	OffloadTraffic *int64 `json:"offloadTraffic,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced
+// +kubebuilder:printcolumn:name=Service,JSONPath=".spec.serviceName",type=string,priority=0
+// +kubebuilder:printcolumn:name=Cluster,JSONPath=".spec.clusterName",type=string,priority=0
+// +kubebuilder:printcolumn:name=Ready,JSONPath=".status.ready",type=string,priority=0
+// +kubebuilder:printcolumn:name=Revision,JSONPath=".status.latestReadyRevisionName",type=string,priority=0
+// +kubebuilder:printcolumn:name=URL,JSONPath=".status.url",type=string,priority=1
+// +kubebuilder:printcolumn:name="Last Reported",JSONPath=".status.lastReportedAt",type=string,priority=1
+
+// EdgeServiceStatus is the status of a Knative Service as reported by a single EdgeCluster
+type EdgeServiceStatus struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   EdgeServiceStatusSpec   `json:"spec,omitempty"`
+	Status EdgeServiceStatusStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// EdgeServiceStatusList contains a list of EdgeServiceStatus
+type EdgeServiceStatusList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EdgeServiceStatus `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EdgeServiceStatus{}, &EdgeServiceStatusList{})
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2022 jevv k.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// MirrorPolicySpec defines which other kinds are mirrored from the cloud to the edge clusters
+type MirrorPolicySpec struct {
+	// The kinds to mirror. Like the built-in kinds, only objects with the
+	// edge.jevv.dev/environment label of an edge cluster are mirrored to it.
+	// +kubebuilder:validation:MinItems:=1
+	Resources []MirrorPolicyResource `json:"resources"`
+}
+
+type MirrorPolicyResource struct {
+	// The API group of the kind, empty for the core group.
+	// +optional
+	Group string `json:"group,omitempty"`
+	// The API version of the kind.
+	Version string `json:"version"`
+	// The kind, e.g. DomainMapping.
+	Kind string `json:"kind"`
+	// Fields copied from the cloud objects, as dot separated paths like spec.template. Every
+	// field but the metadata and status is copied when empty. The name, namespace, labels and
+	// annotations are always copied.
+	// +optional
+	IncludeFields []string `json:"includeFields,omitempty"`
+	// Fields which aren't copied from the cloud objects, like spec.volumeName.
+	// +optional
+	ExcludeFields []string `json:"excludeFields,omitempty"`
+}
+
+// GroupVersionKind of the mirrored kind.
+func (r MirrorPolicyResource) GroupVersionKind() schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: r.Group, Version: r.Version, Kind: r.Kind}
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+
+// MirrorPolicy is a set of kinds the edge clusters mirror from the cloud, besides the Knative
+// Services, ConfigMaps, Secrets and Namespaces
+type MirrorPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec MirrorPolicySpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// MirrorPolicyList contains a list of MirrorPolicy
+type MirrorPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MirrorPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MirrorPolicy{}, &MirrorPolicyList{})
+}
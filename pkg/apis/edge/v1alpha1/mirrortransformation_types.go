@@ -0,0 +1,120 @@
+/*
+Copyright 2022 jevv k.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// MirrorTransformationSpec defines how the mirrored objects are changed before they're applied
+// on the edge clusters
+type MirrorTransformationSpec struct {
+	// The EdgeClusters the transformation applies to, all of them when empty.
+	// +optional
+	Clusters []string `json:"clusters,omitempty"`
+	// The environments the transformation applies to, matched against the
+	// edge.jevv.dev/environment label of the mirrored objects. All of them when empty.
+	// +optional
+	Environments []string `json:"environments,omitempty"`
+	// The kinds the transformation applies to, every mirrored kind when empty.
+	// +optional
+	Resources []MirrorTransformationResource `json:"resources,omitempty"`
+	// Rewrites the registry of the container images, in the order they're listed.
+	// +optional
+	ImageRegistries []ImageRegistryRewrite `json:"imageRegistries,omitempty"`
+	// Rewrites the labels of the mirrored objects.
+	// +optional
+	Labels *MetadataRewrite `json:"labels,omitempty"`
+	// Rewrites the annotations of the mirrored objects.
+	// +optional
+	Annotations *MetadataRewrite `json:"annotations,omitempty"`
+	// Patch merged into the mirrored objects, as a strategic merge patch for built-in kinds and
+	// Knative Services, or a JSON merge patch for the kinds mirrored through a MirrorPolicy.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +optional
+	MergePatch *runtime.RawExtension `json:"mergePatch,omitempty"`
+	// JSON patch (RFC 6902) operations, applied after the merge patch.
+	// +optional
+	JSONPatch []JSONPatchOperation `json:"jsonPatch,omitempty"`
+}
+
+type MirrorTransformationResource struct {
+	// The API group of the kind, empty for the core group.
+	// +optional
+	Group string `json:"group,omitempty"`
+	// The kind, e.g. Service.
+	Kind string `json:"kind"`
+}
+
+type ImageRegistryRewrite struct {
+	// The registry, and optionally the repository, the images start with, e.g. gcr.io/project.
+	From string `json:"from"`
+	// What replaces it, e.g. registry.edge.local/project.
+	To string `json:"to"`
+}
+
+type MetadataRewrite struct {
+	// Keys set to the given values, overriding the mirrored ones.
+	// +optional
+	Set map[string]string `json:"set,omitempty"`
+	// Keys removed from the mirrored objects.
+	// +optional
+	Remove []string `json:"remove,omitempty"`
+}
+
+type JSONPatchOperation struct {
+	// +kubebuilder:validation:Enum=add;remove;replace;move;copy;test
+	Op string `json:"op"`
+	// The JSON pointer to the field, e.g. /spec/template/spec/containers/0/resources.
+	Path string `json:"path"`
+	// The JSON pointer to the source field of move and copy operations.
+	// +optional
+	From string `json:"from,omitempty"`
+	// The value of add, replace and test operations.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	// +optional
+	Value *runtime.RawExtension `json:"value,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+
+// MirrorTransformation changes the objects mirrored to the edge clusters, e.g. to use an edge
+// registry or smaller resource requests. Transformations are applied in the order of their names.
+type MirrorTransformation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec MirrorTransformationSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// MirrorTransformationList contains a list of MirrorTransformation
+type MirrorTransformationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MirrorTransformation `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MirrorTransformation{}, &MirrorTransformationList{})
+}
@@ -36,12 +36,50 @@ type KnativeEdgeSpec struct {
 	// +optional
 	SecretRef *corev1.SecretReference `json:"secretRef,omitempty"`
 
+	// Other remote clusters the edge cluster mirrors from besides the one of SecretRef, e.g. a global
+	// cloud next to a regional hub. The EdgeCluster is only read from the one of SecretRef.
+	// +optional
+	Upstreams []KnativeEdgeUpstream `json:"upstreams,omitempty"`
+
 	// HTTP proxy definition
 	// +optional
 	Proxy KnativeEdgeProxy `json:"proxy,omitempty"`
 
 	// Details of the Prometheus instance
 	Prometheus *KnativeEdgePrometheus `json:"prometheus,omitempty"`
+
+	// Tracing of the requests offloaded by the edge proxy
+	// +optional
+	Tracing KnativeEdgeTracing `json:"tracing,omitempty"`
+
+	// Credentials the edge proxies use to authenticate to the remote cluster
+	// +optional
+	RemoteAuth KnativeEdgeRemoteAuth `json:"remoteAuth,omitempty"`
+
+	// What happens to local changes of the mirrored resources
+	// +optional
+	Drift KnativeEdgeDrift `json:"drift,omitempty"`
+
+	// Offloading to the other edge clusters of the same region
+	// +optional
+	PeerOffload KnativeEdgePeerOffload `json:"peerOffload,omitempty"`
+}
+
+type KnativeEdgeUpstream struct {
+	// The name of the upstream, primary is reserved for the remote cluster of SecretRef.
+	// +kubebuilder:validation:Pattern:=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength:=63
+	Name string `json:"name"`
+	// The secret containing the kubeconfig to the upstream cluster.
+	SecretRef corev1.SecretReference `json:"secretRef"`
+	// When an object exists in several remote clusters, the one of the remote cluster with the
+	// highest priority is mirrored. The remote cluster of SecretRef has priority 0.
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+	// The hostname or ip the services mirrored from this upstream are offloaded to. They're only
+	// offloaded to the upstream when it's set.
+	// +optional
+	ClusterHostnameOrIp string `json:"clusterHostnameOrIp,omitempty"`
 }
 
 type KnativeEdgeProxy struct {
@@ -67,6 +105,54 @@ type KnativeEdgePrometheus struct {
 	UserAndPasswordSecretRef *corev1.SecretReference `json:"userAndPasswordSecretRef,omitempty"`
 }
 
+type KnativeEdgeTracing struct {
+	// The OTLP/HTTP endpoint of an OpenTelemetry collector, e.g. http://otel-collector.observability:4318.
+	// Traces are propagated but not exported when empty.
+	// +optional
+	OtlpEndpoint string `json:"otlpEndpoint,omitempty"`
+}
+
+type KnativeEdgeRemoteAuth struct {
+	// Secret with the client certificate (tls.crt and tls.key) the edge proxies present to the remote
+	// cluster. If it also contains ca.crt, the remote certificate must be signed by that CA.
+	// +optional
+	TLSSecretRef *corev1.SecretReference `json:"tlsSecretRef,omitempty"`
+	// Secret with a bearer token (token) the edge proxies send with every request.
+	// +optional
+	TokenSecretRef *corev1.SecretReference `json:"tokenSecretRef,omitempty"`
+	// The header the token is sent in. Defaults to X-Edge-Remote-Token.
+	// +optional
+	TokenHeader string `json:"tokenHeader,omitempty"`
+}
+
+type KnativeEdgeDrift struct {
+	// The drift policy of the kinds which aren't listed: revert reverts local changes, keep-local
+	// keeps them until the remote resource changes, and alert-only also reports them as warnings.
+	// Defaults to revert. Resources annotated with edge.jevv.dev/pinned=true are never changed.
+	// +kubebuilder:validation:Enum=revert;keep-local;alert-only
+	// +optional
+	DefaultPolicy string `json:"defaultPolicy,omitempty"`
+	// The drift policy by kind, e.g. ConfigMap or Service.serving.knative.dev.
+	// +optional
+	Policies map[string]string `json:"policies,omitempty"`
+}
+
+type KnativeEdgePeerOffload struct {
+	// Offload to the other edge clusters of the region which have spare capacity before the remote
+	// cluster. The region is the one of the EdgeCluster.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+	// The url the other edge clusters of the region offload to, e.g. the ingress of this edge
+	// cluster. They don't offload to it when empty.
+	// +optional
+	URL string `json:"url,omitempty"`
+	// Edge clusters using more of their cpu or memory, in percent, aren't offloaded to. Defaults to 70.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	MaxUtilization int32 `json:"maxUtilization,omitempty"`
+}
+
 // KnativeEdgeStatus defines the observed state of KnativeEdge
 type KnativeEdgeStatus struct {
 	// The zone of the edge cluster.
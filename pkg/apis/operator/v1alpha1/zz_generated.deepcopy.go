@@ -54,6 +54,28 @@ func (in *KnativeEdge) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KnativeEdgeDrift) DeepCopyInto(out *KnativeEdgeDrift) {
+	*out = *in
+	if in.Policies != nil {
+		in, out := &in.Policies, &out.Policies
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KnativeEdgeDrift.
+func (in *KnativeEdgeDrift) DeepCopy() *KnativeEdgeDrift {
+	if in == nil {
+		return nil
+	}
+	out := new(KnativeEdgeDrift)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KnativeEdgeList) DeepCopyInto(out *KnativeEdgeList) {
 	*out = *in
@@ -86,6 +108,21 @@ func (in *KnativeEdgeList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KnativeEdgePeerOffload) DeepCopyInto(out *KnativeEdgePeerOffload) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KnativeEdgePeerOffload.
+func (in *KnativeEdgePeerOffload) DeepCopy() *KnativeEdgePeerOffload {
+	if in == nil {
+		return nil
+	}
+	out := new(KnativeEdgePeerOffload)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KnativeEdgePrometheus) DeepCopyInto(out *KnativeEdgePrometheus) {
 	*out = *in
@@ -131,6 +168,31 @@ func (in *KnativeEdgeProxy) DeepCopy() *KnativeEdgeProxy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KnativeEdgeRemoteAuth) DeepCopyInto(out *KnativeEdgeRemoteAuth) {
+	*out = *in
+	if in.TLSSecretRef != nil {
+		in, out := &in.TLSSecretRef, &out.TLSSecretRef
+		*out = new(v1.SecretReference)
+		**out = **in
+	}
+	if in.TokenSecretRef != nil {
+		in, out := &in.TokenSecretRef, &out.TokenSecretRef
+		*out = new(v1.SecretReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KnativeEdgeRemoteAuth.
+func (in *KnativeEdgeRemoteAuth) DeepCopy() *KnativeEdgeRemoteAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(KnativeEdgeRemoteAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KnativeEdgeSpec) DeepCopyInto(out *KnativeEdgeSpec) {
 	*out = *in
@@ -139,12 +201,21 @@ func (in *KnativeEdgeSpec) DeepCopyInto(out *KnativeEdgeSpec) {
 		*out = new(v1.SecretReference)
 		**out = **in
 	}
+	if in.Upstreams != nil {
+		in, out := &in.Upstreams, &out.Upstreams
+		*out = make([]KnativeEdgeUpstream, len(*in))
+		copy(*out, *in)
+	}
 	out.Proxy = in.Proxy
 	if in.Prometheus != nil {
 		in, out := &in.Prometheus, &out.Prometheus
 		*out = new(KnativeEdgePrometheus)
 		(*in).DeepCopyInto(*out)
 	}
+	out.Tracing = in.Tracing
+	in.RemoteAuth.DeepCopyInto(&out.RemoteAuth)
+	in.Drift.DeepCopyInto(&out.Drift)
+	out.PeerOffload = in.PeerOffload
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KnativeEdgeSpec.
@@ -189,6 +260,37 @@ func (in *KnativeEdgeStatus) DeepCopy() *KnativeEdgeStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KnativeEdgeTracing) DeepCopyInto(out *KnativeEdgeTracing) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KnativeEdgeTracing.
+func (in *KnativeEdgeTracing) DeepCopy() *KnativeEdgeTracing {
+	if in == nil {
+		return nil
+	}
+	out := new(KnativeEdgeTracing)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KnativeEdgeUpstream) DeepCopyInto(out *KnativeEdgeUpstream) {
+	*out = *in
+	out.SecretRef = in.SecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KnativeEdgeUpstream.
+func (in *KnativeEdgeUpstream) DeepCopy() *KnativeEdgeUpstream {
+	if in == nil {
+		return nil
+	}
+	out := new(KnativeEdgeUpstream)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OperatorConfig) DeepCopyInto(out *OperatorConfig) {
 	*out = *in
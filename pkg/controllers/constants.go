@@ -13,6 +13,7 @@ const (
 	ManagedByLabel   = "edge.jevv.dev/managed-by"
 	CreatedByLabel   = "edge.jevv.dev/created-by"
 	EdgeOffloadLabel = "edge.jevv.dev/edge-offload"
+	EdgeClusterLabel = "edge.jevv.dev/cluster"
 
 	KServiceLabel    = "serving.knative.dev/service"
 	KServiceUIDLabel = "serving.knative.dev/serviceUID"
@@ -22,6 +23,17 @@ const (
 	ControllerLabel = "controller"
 )
 
+const (
+	// ProxyCredentialsSecretName is the secret the edge proxies of a namespace mount their
+	// credentials to the remote cluster from.
+	ProxyCredentialsSecretName = "knative-edge-proxy-credentials"
+	ProxyCACertKey             = "ca.crt"
+	ProxyTokenKey              = "token"
+
+	// DefaultProxyPolicyName is the EdgeProxyPolicy of the services which don't select one
+	DefaultProxyPolicyName = "default"
+)
+
 const (
 	ManagedByLabelValue = "knative-edge"
 	CreatedByLabelValue = "controller-manager"
@@ -39,6 +51,37 @@ const (
 	LastRemoteGenerationAnnotation = "edge.jevv.dev/last-observed-remote-generation"
 	RemoteUrlAnnotation            = "edge.jevv.dev/remote-url"
 	RemoteHostAnnotation           = "edge.jevv.dev/remote-host"
+	MirroredHashAnnotation         = "edge.jevv.dev/mirrored-hash"
+
+	// PinnedAnnotation stops mirroring changes to a local object, to keep local changes in emergencies
+	PinnedAnnotation = "edge.jevv.dev/pinned"
+
+	// UpstreamAnnotation is the remote cluster a mirrored object comes from, when there are several
+	UpstreamAnnotation = "edge.jevv.dev/upstream"
 
 	KnativeNoGCAnnotation = "serving.knative.dev/no-gc"
 )
+
+// annotations of a service which tune its edge proxy
+const (
+	ProxyTimeoutAnnotation                 = "edge.jevv.dev/proxy-timeout"
+	ProxyConcurrencyAnnotation             = "edge.jevv.dev/proxy-concurrency"
+	ProxyMinScaleAnnotation                = "edge.jevv.dev/proxy-min-scale"
+	ProxyMaxScaleAnnotation                = "edge.jevv.dev/proxy-max-scale"
+	ProxyCpuRequestAnnotation              = "edge.jevv.dev/proxy-cpu-request"
+	ProxyCpuLimitAnnotation                = "edge.jevv.dev/proxy-cpu-limit"
+	ProxyMemoryRequestAnnotation           = "edge.jevv.dev/proxy-memory-request"
+	ProxyMemoryLimitAnnotation             = "edge.jevv.dev/proxy-memory-limit"
+	ProxyHeadersAnnotation                 = "edge.jevv.dev/proxy-headers"
+	ProxyRetryAttemptsAnnotation           = "edge.jevv.dev/proxy-retry-attempts"
+	ProxyRetryBackoffAnnotation            = "edge.jevv.dev/proxy-retry-backoff"
+	ProxyCircuitBreakerThresholdAnnotation = "edge.jevv.dev/proxy-circuit-breaker-threshold"
+	ProxyCircuitBreakerTimeoutAnnotation   = "edge.jevv.dev/proxy-circuit-breaker-timeout"
+	ProxyPolicyAnnotation                  = "edge.jevv.dev/proxy-policy"
+	ProxyPolicyGenerationAnnotation        = "edge.jevv.dev/proxy-policy-generation"
+
+	// how the proxy picks among several remote endpoints (latency or weighted), and the weights
+	// of the endpoints as name=weight,name=weight
+	ProxyEndpointSelectionAnnotation = "edge.jevv.dev/proxy-endpoint-selection"
+	ProxyEndpointWeightsAnnotation   = "edge.jevv.dev/proxy-endpoint-weights"
+)
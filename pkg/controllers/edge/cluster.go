@@ -1,15 +1,39 @@
 package edge
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
+	"time"
 
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/cluster"
+
+	"edge.jevv.dev/pkg/controllers"
+	"edge.jevv.dev/pkg/metrics"
 )
 
+// lazyDiscovery lets the remote cluster be created while it's unreachable, its kinds are only
+// discovered when first used.
+func lazyDiscovery(opts *cluster.Options) {
+	opts.MapperProvider = func(c *rest.Config) (meta.RESTMapper, error) {
+		return apiutil.NewDynamicRESTMapper(c, apiutil.WithLazyDiscovery)
+	}
+}
+
 func NewRemoteClusterOrDie(opts ...cluster.Option) cluster.Cluster {
+	return NewRemoteClusterWithProxyOrDie(nil, opts...)
+}
+
+// NewRemoteClusterWithProxyOrDie connects to the remote cluster through the proxy picked by the
+// given function, see utils.ProxyFunc. The proxy is optional.
+func NewRemoteClusterWithProxyOrDie(proxy func(*http.Request) (*url.URL, error), opts ...cluster.Option) cluster.Cluster {
 	kubeconfigPath := fmt.Sprintf("%s/%s", ConfigPath, KubeconfigFile)
 
 	loader := clientcmd.NewDefaultClientConfigLoadingRules()
@@ -22,37 +46,93 @@ func NewRemoteClusterOrDie(opts ...cluster.Option) cluster.Cluster {
 		panic(fmt.Errorf("couldn't retrieve kubeconfig: %w", err))
 	}
 
-	cluster, err := cluster.New(kubeconfig, opts...)
+	return newClusterOrDie("remote cluster", kubeconfig, proxy, opts...)
+}
+
+// NewUpstreamClusterOrDie connects to the upstream with the given name, with its kubeconfig in
+// UpstreamsConfigPath. The proxy is optional.
+func NewUpstreamClusterOrDie(name string, proxy func(*http.Request) (*url.URL, error), opts ...cluster.Option) cluster.Cluster {
+	kubeconfig, err := clientcmd.BuildConfigFromFlags("", fmt.Sprintf("%s/%s", UpstreamsConfigPath, name))
 
 	if err != nil {
-		panic(fmt.Errorf("couldn't create remote cluster: %w", err))
+		panic(fmt.Errorf("couldn't retrieve kubeconfig of upstream %s: %w", name, err))
 	}
 
-	return cluster
+	return newClusterOrDie(fmt.Sprintf("upstream cluster %s", name), kubeconfig, proxy, opts...)
 }
 
-func NewRemoteClusterWithProxyOrDie(proxy *url.URL, opts ...cluster.Option) cluster.Cluster {
-	kubeconfigPath := fmt.Sprintf("%s/%s", ConfigPath, KubeconfigFile)
-
-	loader := clientcmd.NewDefaultClientConfigLoadingRules()
-	loader.Precedence = append(loader.Precedence, kubeconfigPath)
+func newClusterOrDie(description string, kubeconfig *rest.Config, proxy func(*http.Request) (*url.URL, error), opts ...cluster.Option) cluster.Cluster {
+	if proxy != nil {
+		kubeconfig.Proxy = proxy
+	}
 
-	kubeconfig, err :=
-		clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loader, nil).ClientConfig()
+	cluster, err := cluster.New(kubeconfig, append([]cluster.Option{lazyDiscovery}, opts...)...)
 
 	if err != nil {
-		panic(fmt.Errorf("couldn't retrieve kubeconfig: %w", err))
+		panic(fmt.Errorf("couldn't create %s: %w", description, err))
 	}
 
-	kubeconfig.Proxy = func(req *http.Request) (*url.URL, error) {
-		return proxy, nil
+	return cluster
+}
+
+// RemoteClusterProbe periodically checks that every upstream of the remote cluster is reachable,
+// and exports the result as metrics.
+type RemoteClusterProbe struct {
+	Log           logr.Logger
+	RemoteCluster cluster.Cluster
+	Period        time.Duration
+}
+
+func (p *RemoteClusterProbe) NeedLeaderElection() bool {
+	// every replica should be able to reach the remote cluster
+	return false
+}
+
+func (p *RemoteClusterProbe) Start(ctx context.Context) error {
+	debug := p.Log.V(controllers.DebugLevel)
+
+	upstreams := AllUpstreams(p.RemoteCluster)
+	discoveryClients := make([]*discovery.DiscoveryClient, len(upstreams))
+
+	for i, upstream := range upstreams {
+		discoveryClient, err := discovery.NewDiscoveryClientForConfig(upstream.Cluster.GetConfig())
+
+		if err != nil {
+			return fmt.Errorf("couldn't create discovery client of upstream %s: %w", upstream.Name, err)
+		}
+
+		discoveryClients[i] = discoveryClient
 	}
 
-	cluster, err := cluster.New(kubeconfig, opts...)
+	period := p.Period
 
-	if err != nil {
-		panic(fmt.Errorf("couldn't create remote cluster: %w", err))
+	if period <= 0 {
+		period = RemoteClusterProbePeriod
 	}
 
-	return cluster
+	go func() {
+		for {
+			for i, upstream := range upstreams {
+				if _, err := discoveryClients[i].ServerVersion(); err != nil {
+					debug.Error(err, "Upstream cluster is unreachable.", "upstream", upstream.Name)
+					metrics.RemoteClusterUp.WithLabelValues(upstream.Name).Set(0)
+				} else {
+					metrics.RemoteClusterUp.WithLabelValues(upstream.Name).Set(1)
+					metrics.RemoteClusterLastContact.WithLabelValues(upstream.Name).SetToCurrentTime()
+				}
+			}
+
+			timeoutCtx, timeoutCancel := context.WithTimeout(context.Background(), period)
+
+			select {
+			case <-timeoutCtx.Done():
+				timeoutCancel()
+			case <-ctx.Done():
+				timeoutCancel()
+				return
+			}
+		}
+	}()
+
+	return nil
 }
@@ -28,6 +28,8 @@ type ConfigMapReconciler struct {
 	Recorder      record.EventRecorder
 	RemoteCluster cluster.Cluster
 	Envs          []string
+	Transformer   *Transformer
+	Drift         *DriftTracker
 
 	mirror *MirroringReconciler[*corev1.ConfigMap]
 }
@@ -77,6 +79,8 @@ func (r *ConfigMapReconciler) SetupWithManager(mgr ctrl.Manager, predicates ...p
 		Recorder:      r.Recorder,
 		RemoteCluster: r.RemoteCluster,
 		Envs:          r.Envs,
+		Transformer:   r.Transformer,
+		Drift:         r.Drift,
 		KindGenerator: r.kindGenerator,
 		KindMerger:    r.kindMerger,
 	}
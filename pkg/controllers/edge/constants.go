@@ -1,6 +1,34 @@
 package edge
 
+import "time"
+
 const (
 	ConfigPath     = "/var/run/secrets/edge.jevv.dev/config"
 	KubeconfigFile = "kubeconfig"
+
+	// the kubeconfigs of the other upstreams, named after them
+	UpstreamsConfigPath = "/var/run/secrets/edge.jevv.dev/upstreams"
+
+	ProxyCredentialsPath = "/var/run/secrets/edge.jevv.dev/proxy"
+
+	// the peers the edge proxy can offload to, kept up to date in a ConfigMap mounted in the proxy
+	ProxyPeersPath = "/var/run/edge.jevv.dev/peers"
+	ProxyPeersKey  = "peers.json"
+
+	RemoteClusterProbePeriod = time.Second * 15
+
+	RemoteSnapshotPeriod          = time.Second * 30
+	RemoteSnapshotSyncCheckPeriod = time.Second
+	RemoteSnapshotDataKey         = "objects.json.gz"
+	RemoteSnapshotIndexKey        = "chunks"
+
+	// objects are split in chunks of about this many bytes of JSON, so each Secret stays well under
+	// the 1 MiB limit once compressed
+	RemoteSnapshotChunkSize = 512 * 1024
+
+	EdgeProxyPort = 8080
+
+	DefaultProxyConcurrency = 8
+	DefaultProxyMinScale    = 3
+	DefaultProxyMaxScale    = 5
 )
@@ -0,0 +1,206 @@
+package edge
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"edge.jevv.dev/pkg/controllers"
+)
+
+type DriftPolicy string
+
+const (
+	// local changes are reverted to the remote object right away
+	DriftPolicyRevert DriftPolicy = "revert"
+	// local changes are kept until the remote object changes
+	DriftPolicyKeepLocal DriftPolicy = "keep-local"
+	// like keep-local, but local changes are reported as warnings
+	DriftPolicyAlertOnly DriftPolicy = "alert-only"
+)
+
+const (
+	ReasonDriftReverted = "DriftReverted"
+	ReasonDriftKept     = "DriftKept"
+	ReasonDriftDetected = "DriftDetected"
+	ReasonPinned        = "Pinned"
+)
+
+func ParseDriftPolicy(value string) (DriftPolicy, error) {
+	switch policy := DriftPolicy(strings.TrimSpace(value)); policy {
+	case "":
+		return DriftPolicyRevert, nil
+	case DriftPolicyRevert, DriftPolicyKeepLocal, DriftPolicyAlertOnly:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown drift policy %q, expected %s, %s or %s", value, DriftPolicyRevert, DriftPolicyKeepLocal, DriftPolicyAlertOnly)
+	}
+}
+
+// ParseDriftPolicies parses comma separated kind=policy pairs, with kinds like ConfigMap or
+// Service.serving.knative.dev.
+func ParseDriftPolicies(value string) (map[schema.GroupKind]DriftPolicy, error) {
+	policies := make(map[schema.GroupKind]DriftPolicy)
+
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+
+		kind, value, found := strings.Cut(pair, "=")
+
+		if !found || strings.TrimSpace(kind) == "" {
+			return nil, fmt.Errorf("invalid drift policy %q, expected kind=policy", pair)
+		}
+
+		policy, err := ParseDriftPolicy(value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		policies[schema.ParseGroupKind(strings.TrimSpace(kind))] = policy
+	}
+
+	return policies, nil
+}
+
+type driftState string
+
+const (
+	driftStateDrifted driftState = "drifted"
+	driftStatePinned  driftState = "pinned"
+)
+
+// DriftTracker keeps the drift policy of each kind, and which local objects currently differ from
+// the remote ones, either because their changes were kept or because they're pinned.
+type DriftTracker struct {
+	DefaultPolicy DriftPolicy
+	Policies      map[schema.GroupKind]DriftPolicy
+
+	lock    sync.RWMutex
+	objects map[string]driftState
+}
+
+func (t *DriftTracker) Policy(gk schema.GroupKind) DriftPolicy {
+	if t == nil {
+		return DriftPolicyRevert
+	}
+
+	if policy, exists := t.Policies[gk]; exists {
+		return policy
+	}
+
+	if t.DefaultPolicy == "" {
+		return DriftPolicyRevert
+	}
+
+	return t.DefaultPolicy
+}
+
+func (t *DriftTracker) set(key string, state driftState) {
+	if t == nil {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.objects == nil {
+		t.objects = make(map[string]driftState)
+	}
+
+	if state == "" {
+		delete(t.objects, key)
+	} else {
+		t.objects[key] = state
+	}
+}
+
+// Summary returns the local objects whose changes were kept and the pinned ones, sorted.
+func (t *DriftTracker) Summary() (drifted, pinned []string) {
+	if t == nil {
+		return nil, nil
+	}
+
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	for key, state := range t.objects {
+		if state == driftStatePinned {
+			pinned = append(pinned, key)
+		} else {
+			drifted = append(drifted, key)
+		}
+	}
+
+	sort.Strings(drifted)
+	sort.Strings(pinned)
+
+	return drifted, pinned
+}
+
+func driftKey(gk schema.GroupKind, key client.ObjectKey) string {
+	return fmt.Sprintf("%s %s", gk.String(), key.String())
+}
+
+func isPinned(obj client.Object) bool {
+	return strings.ToLower(obj.GetAnnotations()[controllers.PinnedAnnotation]) == "true"
+}
+
+// setAnnotation sets the annotation, or removes it if it doesn't exist. Unstructured objects return
+// a copy of their annotations, so they're always set back.
+func setAnnotation(obj client.Object, key, value string, exists bool) {
+	annotations := obj.GetAnnotations()
+
+	if !exists {
+		if _, found := annotations[key]; found {
+			delete(annotations, key)
+			obj.SetAnnotations(annotations)
+		}
+
+		return
+	}
+
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	annotations[key] = value
+	obj.SetAnnotations(annotations)
+}
+
+// desiredHash hashes the object as it's mirrored from the remote one, without any local state, so
+// local changes can be told apart from changes of the remote object or its transformations.
+func (r *MirroringReconciler[T]) desiredHash(ctx context.Context, remoteKind T) (string, error) {
+	desired := r.KindGenerator()
+
+	if err := r.KindMerger(remoteKind, desired); err != nil {
+		return "", err
+	}
+
+	if r.Transformer != nil {
+		if err := r.Transformer.Transform(ctx, desired); err != nil {
+			return "", err
+		}
+	}
+
+	data, err := json.Marshal(desired)
+
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(data)
+
+	return hex.EncodeToString(hash[:]), nil
+}
@@ -0,0 +1,262 @@
+package edge
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"edge.jevv.dev/pkg/controllers"
+)
+
+var _ = Describe("drift", func() {
+	configMapKind := schema.GroupKind{Kind: "ConfigMap"}
+	kserviceKind := schema.GroupKind{Group: "serving.knative.dev", Kind: "Service"}
+
+	DescribeTable("parsing drift policies",
+		func(value string, expected map[schema.GroupKind]DriftPolicy, fails bool) {
+			policies, err := ParseDriftPolicies(value)
+
+			if fails {
+				Expect(err).To(HaveOccurred())
+				return
+			}
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(policies).To(Equal(expected))
+		},
+		Entry("empty", "", map[schema.GroupKind]DriftPolicy{}, false),
+		Entry("core kind", "ConfigMap=keep-local", map[schema.GroupKind]DriftPolicy{configMapKind: DriftPolicyKeepLocal}, false),
+		Entry("kinds with groups", "ConfigMap=alert-only, Service.serving.knative.dev = revert", map[schema.GroupKind]DriftPolicy{
+			configMapKind: DriftPolicyAlertOnly,
+			kserviceKind:  DriftPolicyRevert,
+		}, false),
+		Entry("empty policy", "ConfigMap=", map[schema.GroupKind]DriftPolicy{configMapKind: DriftPolicyRevert}, false),
+		Entry("empty pairs", ",ConfigMap=keep-local,", map[schema.GroupKind]DriftPolicy{configMapKind: DriftPolicyKeepLocal}, false),
+		Entry("last pair wins", "ConfigMap=keep-local,ConfigMap=revert", map[schema.GroupKind]DriftPolicy{configMapKind: DriftPolicyRevert}, false),
+		Entry("missing policy", "ConfigMap", nil, true),
+		Entry("missing kind", "=revert", nil, true),
+		Entry("unknown policy", "ConfigMap=ignore", nil, true),
+	)
+
+	It("uses the policy of the kind, then the default one", func() {
+		tracker := &DriftTracker{
+			DefaultPolicy: DriftPolicyAlertOnly,
+			Policies:      map[schema.GroupKind]DriftPolicy{configMapKind: DriftPolicyKeepLocal},
+		}
+
+		Expect(tracker.Policy(configMapKind)).To(Equal(DriftPolicyKeepLocal))
+		Expect(tracker.Policy(kserviceKind)).To(Equal(DriftPolicyAlertOnly))
+		Expect((&DriftTracker{}).Policy(kserviceKind)).To(Equal(DriftPolicyRevert))
+
+		var unset *DriftTracker
+		Expect(unset.Policy(configMapKind)).To(Equal(DriftPolicyRevert))
+	})
+
+	It("summarizes drifted and pinned objects", func() {
+		tracker := &DriftTracker{}
+
+		tracker.set("b", driftStateDrifted)
+		tracker.set("a", driftStateDrifted)
+		tracker.set("c", driftStatePinned)
+		tracker.set("d", driftStateDrifted)
+		tracker.set("d", "")
+
+		drifted, pinned := tracker.Summary()
+		Expect(drifted).To(Equal([]string{"a", "b"}))
+		Expect(pinned).To(Equal([]string{"c"}))
+	})
+
+	Describe("mirroring local changes", func() {
+		var (
+			ctx      context.Context
+			local    client.Client
+			remote   client.Client
+			recorder *record.FakeRecorder
+			tracker  *DriftTracker
+			mirror   *MirroringReconciler[*corev1.ConfigMap]
+		)
+
+		key := client.ObjectKey{Name: "config", Namespace: "default"}
+		trackerKey := driftKey(configMapKind, key)
+
+		reconcile := func() {
+			_, err := mirror.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		localData := func() map[string]string {
+			configMap := &corev1.ConfigMap{}
+			Expect(local.Get(ctx, key, configMap)).To(Succeed())
+
+			return configMap.Data
+		}
+
+		updateData := func(c client.Client, data map[string]string, annotations map[string]string) {
+			configMap := &corev1.ConfigMap{}
+			Expect(c.Get(ctx, key, configMap)).To(Succeed())
+
+			configMap.Data = data
+
+			for k, v := range annotations {
+				metav1.SetMetaDataAnnotation(&configMap.ObjectMeta, k, v)
+			}
+
+			Expect(c.Update(ctx, configMap)).To(Succeed())
+		}
+
+		events := func() []string {
+			var received []string
+
+			for len(recorder.Events) > 0 {
+				received = append(received, <-recorder.Events)
+			}
+
+			return received
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+
+			local = fake.NewClientBuilder().WithScheme(scheme).Build()
+			remote = fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      key.Name,
+					Namespace: key.Namespace,
+					Labels:    map[string]string{controllers.EnvironmentLabel: "testA"},
+				},
+				Data: map[string]string{"foo": "remote"},
+			}).Build()
+
+			recorder = record.NewFakeRecorder(10)
+			tracker = &DriftTracker{}
+
+			mirror = &MirroringReconciler[*corev1.ConfigMap]{
+				Client:        local,
+				Log:           logr.Discard(),
+				Scheme:        scheme,
+				Recorder:      recorder,
+				RemoteCluster: &stubCluster{client: remote},
+				KindGenerator: (&ConfigMapReconciler{}).kindGenerator,
+				KindMerger:    (&ConfigMapReconciler{}).kindMerger,
+				Drift:         tracker,
+				Envs:          []string{"testA"},
+			}
+
+			reconcile()
+			Expect(localData()).To(Equal(map[string]string{"foo": "remote"}))
+			Expect(events()).To(BeEmpty())
+		})
+
+		It("stores the hash of the mirrored object", func() {
+			configMap := &corev1.ConfigMap{}
+			Expect(local.Get(ctx, key, configMap)).To(Succeed())
+
+			remoteConfigMap := &corev1.ConfigMap{}
+			Expect(remote.Get(ctx, key, remoteConfigMap)).To(Succeed())
+
+			hash, err := mirror.desiredHash(ctx, remoteConfigMap)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(configMap.Annotations).To(HaveKeyWithValue(controllers.MirroredHashAnnotation, hash))
+
+			By("hashing the remote object again")
+			Expect(mirror.desiredHash(ctx, remoteConfigMap)).To(Equal(hash))
+
+			By("hashing a changed remote object")
+			remoteConfigMap.Data["foo"] = "changed"
+			Expect(mirror.desiredHash(ctx, remoteConfigMap)).NotTo(Equal(hash))
+		})
+
+		It("doesn't report drift without local changes", func() {
+			reconcile()
+
+			Expect(events()).To(BeEmpty())
+			Expect(tracker.Summary()).To(BeEmpty())
+		})
+
+		It("reverts local changes by default", func() {
+			updateData(local, map[string]string{"foo": "local"}, nil)
+
+			reconcile()
+
+			Expect(localData()).To(Equal(map[string]string{"foo": "remote"}))
+			Expect(events()).To(ConsistOf(ContainSubstring(ReasonDriftReverted)))
+			Expect(tracker.Summary()).To(BeEmpty())
+		})
+
+		DescribeTable("keeping local changes",
+			func(policy DriftPolicy, reason string) {
+				tracker.Policies = map[schema.GroupKind]DriftPolicy{configMapKind: policy}
+
+				updateData(local, map[string]string{"foo": "local"}, nil)
+
+				reconcile()
+
+				Expect(localData()).To(Equal(map[string]string{"foo": "local"}))
+				Expect(events()).To(ConsistOf(ContainSubstring(reason)))
+
+				drifted, _ := tracker.Summary()
+				Expect(drifted).To(Equal([]string{trackerKey}))
+
+				By("mirroring the next change of the remote object")
+				updateData(remote, map[string]string{"foo": "remote", "bar": "new"}, nil)
+
+				reconcile()
+
+				Expect(localData()).To(Equal(map[string]string{"foo": "remote", "bar": "new"}))
+				Expect(events()).To(BeEmpty())
+				Expect(tracker.Summary()).To(BeEmpty())
+			},
+			Entry("keep-local", DriftPolicyKeepLocal, ReasonDriftKept),
+			Entry("alert-only", DriftPolicyAlertOnly, ReasonDriftDetected),
+		)
+
+		It("skips pinned objects", func() {
+			updateData(local, map[string]string{"foo": "local"}, map[string]string{controllers.PinnedAnnotation: "true"})
+
+			reconcile()
+
+			Expect(localData()).To(Equal(map[string]string{"foo": "local"}))
+			Expect(events()).To(BeEmpty())
+
+			_, pinned := tracker.Summary()
+			Expect(pinned).To(Equal([]string{trackerKey}))
+
+			By("changing the remote object")
+			updateData(remote, map[string]string{"foo": "remote", "bar": "new"}, nil)
+
+			reconcile()
+
+			Expect(localData()).To(Equal(map[string]string{"foo": "local"}))
+			Expect(events()).To(ConsistOf(ContainSubstring(ReasonPinned)))
+
+			By("unpinning the local object")
+			updateData(local, map[string]string{"foo": "local"}, map[string]string{controllers.PinnedAnnotation: "false"})
+
+			reconcile()
+
+			Expect(localData()).To(Equal(map[string]string{"foo": "remote", "bar": "new"}))
+			Expect(tracker.Summary()).To(BeEmpty())
+
+			configMap := &corev1.ConfigMap{}
+			Expect(local.Get(ctx, key, configMap)).To(Succeed())
+			Expect(configMap.Annotations).NotTo(HaveKey(controllers.PinnedAnnotation))
+		})
+	})
+})
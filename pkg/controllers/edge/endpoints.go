@@ -0,0 +1,122 @@
+package edge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"edge.jevv.dev/pkg/controllers"
+
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+)
+
+const (
+	// the healthy endpoint with the lowest round trip time
+	EndpointSelectionLatency = "latency"
+	// a healthy endpoint picked randomly by weight, endpoints without one are only used when none
+	// of the weighted ones is healthy
+	EndpointSelectionWeighted = "weighted"
+)
+
+// RemoteEndpoint is a remote cluster the edge proxy of a service can offload to. The proxy probes
+// the endpoints itself for their round trip time and health, since rendering the results into
+// the proxy would roll out a new revision every time they change.
+type RemoteEndpoint struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Host   string `json:"host"`
+	Weight int64  `json:"weight,omitempty"`
+
+	// peers are other edge clusters of the region, which the proxy prefers over the remote
+	// clusters while any of them is healthy. They're not rendered in the proxy but read from its
+	// peers ConfigMap, which changes with their utilization.
+	Peer bool `json:"peer,omitempty"`
+}
+
+// getRemoteEndpoints returns the upstreams which have the service, in the order of their
+// priorities, skipping the ones without a url. There's only the one the service was mirrored from
+// when there's a single remote cluster.
+func (r *KServiceReconciler) getRemoteEndpoints(ctx context.Context, service *servingv1.Service) ([]RemoteEndpoint, error) {
+	name := service.Annotations[controllers.UpstreamAnnotation]
+
+	if name == "" {
+		name = PrimaryUpstream
+	}
+
+	endpoints := []RemoteEndpoint{}
+
+	if url := service.Annotations[controllers.RemoteUrlAnnotation]; url != "" {
+		endpoints = append(endpoints, RemoteEndpoint{
+			Name: name,
+			URL:  url,
+			Host: service.Annotations[controllers.RemoteHostAnnotation],
+		})
+	} else {
+		r.Log.V(controllers.DebugLevel).Info("upstream has no url, not offloading to it", "upstream", name, "service", client.ObjectKeyFromObject(service))
+	}
+
+	multi, ok := r.RemoteCluster.(*MultiCluster)
+
+	if !ok {
+		return endpoints, nil
+	}
+
+	for _, upstream := range multi.Upstreams {
+		if upstream.Name == name {
+			continue
+		}
+
+		remoteService := &servingv1.Service{}
+
+		if err := upstream.Cluster.GetClient().Get(ctx, types.NamespacedName{Name: service.Name, Namespace: service.Namespace}, remoteService); err != nil {
+			if isMissing(err) {
+				continue
+			}
+
+			return nil, fmt.Errorf("couldn't get service from upstream %s: %w", upstream.Name, err)
+		}
+
+		if remoteService.Status.URL == nil {
+			continue
+		}
+
+		// the remote url is the ingress of the primary upstream, which doesn't serve this host
+		if upstream.URL == "" {
+			r.Log.V(controllers.DebugLevel).Info("upstream has no url, not offloading to it", "upstream", upstream.Name, "service", client.ObjectKeyFromObject(service))
+			continue
+		}
+
+		endpoints = append(endpoints, RemoteEndpoint{
+			Name: upstream.Name,
+			URL:  upstream.URL,
+			Host: remoteService.Status.URL.Host,
+		})
+	}
+
+	return endpoints, nil
+}
+
+// remoteEndpointsEnv renders the endpoints with their weights for the REMOTE_ENDPOINTS env of the
+// proxy, empty when there's nothing to pick from, unless peers can be added later. It's empty
+// without any remote endpoint too, which the proxy doesn't accept even with peers.
+func remoteEndpointsEnv(endpoints []RemoteEndpoint, weights map[string]int64, peers bool) (string, error) {
+	if len(endpoints) == 0 || (len(endpoints) < 2 && !peers) {
+		return "", nil
+	}
+
+	for i := range endpoints {
+		endpoints[i].Weight = weights[endpoints[i].Name]
+	}
+
+	data, err := json.Marshal(endpoints)
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
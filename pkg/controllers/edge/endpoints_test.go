@@ -0,0 +1,32 @@
+package edge
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("remote endpoints", func() {
+	hub := RemoteEndpoint{Name: "hub", URL: "http://hub.example.com", Host: "app.default.hub.example.com"}
+	cloud := RemoteEndpoint{Name: "cloud", URL: "http://cloud.example.com", Host: "app.default.cloud.example.com"}
+
+	DescribeTable("rendering the env of the proxy",
+		func(endpoints []RemoteEndpoint, weights map[string]int64, peers bool, expected string) {
+			env, err := remoteEndpointsEnv(endpoints, weights, peers)
+			Expect(err).NotTo(HaveOccurred())
+
+			if expected == "" {
+				Expect(env).To(BeEmpty())
+			} else {
+				Expect(env).To(MatchJSON(expected))
+			}
+		},
+		Entry("no endpoint", nil, nil, false, ""),
+		Entry("no endpoint with peers", nil, nil, true, ""),
+		Entry("empty endpoints with peers", []RemoteEndpoint{}, nil, true, ""),
+		Entry("a single endpoint", []RemoteEndpoint{hub}, nil, false, ""),
+		Entry("a single endpoint with peers", []RemoteEndpoint{hub}, nil, true,
+			`[{"name":"hub","url":"http://hub.example.com","host":"app.default.hub.example.com"}]`),
+		Entry("several endpoints with weights", []RemoteEndpoint{hub, cloud}, map[string]int64{"cloud": 2, "other": 1}, false,
+			`[{"name":"hub","url":"http://hub.example.com","host":"app.default.hub.example.com"},{"name":"cloud","url":"http://cloud.example.com","host":"app.default.cloud.example.com","weight":2}]`),
+	)
+})
@@ -13,8 +13,10 @@ import (
 
 	// "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
+	edgev1alpha1 "edge.jevv.dev/pkg/apis/edge/v1alpha1"
 	"edge.jevv.dev/pkg/controllers"
 	"edge.jevv.dev/pkg/controllers/utils"
+	"edge.jevv.dev/pkg/workoffload/health"
 
 	corev1 "k8s.io/api/core/v1"
 	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
@@ -81,7 +83,35 @@ func (r *KServiceReconciler) reconcileKConfiguration(ctx context.Context, servic
 
 	// TODO: check if we should update
 	if !shouldDelete {
-		r.buildConfiguration(configurationNamespacedName, configuration, service)
+		if err := r.reconcileProxyCredentials(ctx, service.Namespace); err != nil {
+			// the proxy can't authenticate without them, better not to route to it
+			debug.Error(err, "couldn't copy edge proxy credentials")
+			return ctrl.Result{}, err
+		}
+
+		policy, err := r.getProxyPolicy(ctx, service)
+
+		if err != nil {
+			// rendering the proxy without it could offload requests the policy rejects
+			debug.Error(err, "couldn't resolve edge proxy policy")
+			return ctrl.Result{}, err
+		}
+
+		endpoints, err := r.getRemoteEndpoints(ctx, service)
+
+		if err != nil {
+			debug.Error(err, "couldn't collect remote endpoints")
+			return ctrl.Result{}, err
+		}
+
+		if r.peerOffloadEnabled() {
+			// the proxy keeps offloading to the remote clusters without the peers
+			if err := r.reconcilePeerEndpoints(ctx, service, configurationNamespacedName); err != nil {
+				debug.Error(err, "couldn't update peer endpoints")
+			}
+		}
+
+		r.buildConfiguration(configurationNamespacedName, configuration, service, policy, endpoints)
 
 		shouldUpdate = !reflect.DeepEqual(localConfiguration, configuration)
 
@@ -120,6 +150,12 @@ func (r *KServiceReconciler) reconcileKConfiguration(ctx context.Context, servic
 	} else if shouldDelete {
 		log.Info("Deleting edge proxy route.", "name", configurationNamespacedName)
 
+		if r.peerOffloadEnabled() {
+			if err := r.deletePeerEndpoints(ctx, configurationNamespacedName); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
 		if err := r.Delete(ctx, configuration); err != nil {
 			if apierrors.IsNotFound(err) {
 				return ctrl.Result{}, nil
@@ -137,7 +173,7 @@ func (r *KServiceReconciler) reconcileKConfiguration(ctx context.Context, servic
 	return ctrl.Result{}, nil
 }
 
-func (r *KServiceReconciler) buildConfiguration(namespacedName types.NamespacedName, configuration *servingv1.Configuration, service *servingv1.Service) {
+func (r *KServiceReconciler) buildConfiguration(namespacedName types.NamespacedName, configuration *servingv1.Configuration, service *servingv1.Service, policy *edgev1alpha1.EdgeProxyPolicy, endpoints []RemoteEndpoint) {
 	serviceAnnotations := service.Annotations
 
 	if serviceAnnotations == nil {
@@ -185,21 +221,99 @@ func (r *KServiceReconciler) buildConfiguration(namespacedName types.NamespacedN
 		configuration.Spec.Template.Spec.PodSpec.Containers = containers
 	}
 
-	var concurrency int64 = 8
+	settings, err := GetProxySettings(serviceAnnotations)
+
+	if err != nil {
+		r.Log.V(controllers.InfoLevel).Info("Ignoring invalid edge proxy annotations.", "service", namespacedName, "error", err.Error())
+	}
+
+	if err := settings.ApplyPolicy(policy); err != nil {
+		r.Log.Error(err, "couldn't apply edge proxy policy", "service", namespacedName)
+	}
+
+	concurrency := settings.Concurrency
 	configuration.Spec.Template.Spec.ContainerConcurrency = &concurrency
 
 	container := &containers[0]
 
-	// TODO: set concurrency and timeout
 	container.Name = "edge-proxy"
 	container.Image = r.ProxyImage
+	// the upstream the service was mirrored from is skipped when it has no url, so another one
+	// which has the service is offloaded to instead
+	remoteUrl, remoteHost := "", ""
+
+	if len(endpoints) > 0 {
+		remoteUrl, remoteHost = endpoints[0].URL, endpoints[0].Host
+	}
+
 	container.Env = []corev1.EnvVar{
-		{Name: "REMOTE_URL", Value: serviceAnnotations[controllers.RemoteUrlAnnotation]},
-		{Name: "REMOTE_HOST", Value: serviceAnnotations[controllers.RemoteHostAnnotation]},
-		{Name: "REMOTE_PROXY", Value: r.HttpProxy},
-		// {Name: "HTTP_PROXY", Value: r.HttpProxy},
-		// {Name: "HTTPS_PROXY", Value: r.HttpsProxy},
-		// {Name: "NO_PROXY", Value: r.NoProxy},
+		{Name: "REMOTE_URL", Value: remoteUrl},
+		{Name: "REMOTE_HOST", Value: remoteHost},
+		{Name: "HTTP_PROXY", Value: r.HttpProxy},
+		{Name: "HTTPS_PROXY", Value: r.HttpsProxy},
+		{Name: "NO_PROXY", Value: r.NoProxy},
+	}
+
+	container.Env = append(container.Env, settings.Env()...)
+
+	if remoteEndpoints, err := remoteEndpointsEnv(endpoints, settings.EndpointWeights, r.peerOffloadEnabled()); err != nil {
+		r.Log.Error(err, "couldn't render remote endpoints", "service", namespacedName)
+	} else if remoteEndpoints != "" {
+		container.Env = append(container.Env, corev1.EnvVar{Name: "REMOTE_ENDPOINTS", Value: remoteEndpoints})
+	}
+	container.Resources = settings.Resources
+
+	// knative only talks HTTP/2 to the proxy if the port says so, which gRPC services need
+	if portName := getServicePortName(service); portName != "" {
+		container.Ports = []corev1.ContainerPort{{Name: portName, ContainerPort: EdgeProxyPort}}
+	} else {
+		container.Ports = nil
+	}
+
+	if r.OtlpEndpoint != "" {
+		container.Env = append(container.Env, corev1.EnvVar{Name: "OTEL_EXPORTER_OTLP_ENDPOINT", Value: r.OtlpEndpoint})
+	}
+
+	container.VolumeMounts = nil
+	configuration.Spec.Template.Spec.Volumes = nil
+
+	if r.ProxyCredentials != "" {
+		container.Env = append(container.Env,
+			corev1.EnvVar{Name: "REMOTE_CREDENTIALS_PATH", Value: ProxyCredentialsPath},
+			corev1.EnvVar{Name: "REMOTE_TOKEN_HEADER", Value: r.ProxyTokenHeader},
+		)
+
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name: "proxy-credentials", MountPath: ProxyCredentialsPath, ReadOnly: true,
+		})
+
+		configuration.Spec.Template.Spec.Volumes = append(configuration.Spec.Template.Spec.Volumes, corev1.Volume{
+			Name: "proxy-credentials",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: controllers.ProxyCredentialsSecretName},
+			},
+		})
+	}
+
+	// mounted rather than rendered, the kubelet updates the file when the peers change
+	if r.peerOffloadEnabled() {
+		optional := true
+
+		container.Env = append(container.Env, corev1.EnvVar{Name: "PEER_ENDPOINTS_FILE", Value: fmt.Sprintf("%s/%s", ProxyPeersPath, ProxyPeersKey)})
+
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name: "proxy-peers", MountPath: ProxyPeersPath, ReadOnly: true,
+		})
+
+		configuration.Spec.Template.Spec.Volumes = append(configuration.Spec.Template.Spec.Volumes, corev1.Volume{
+			Name: "proxy-peers",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: peersConfigMapName(namespacedName).Name},
+					Optional:             &optional,
+				},
+			},
+		})
 	}
 
 	specLabels := configuration.Spec.Template.Labels
@@ -220,6 +334,31 @@ func (r *KServiceReconciler) buildConfiguration(namespacedName types.NamespacedN
 		configuration.Spec.Template.Annotations = specAnnotations
 	}
 
-	specAnnotations["autoscaling.knative.dev/min-scale"] = "3"
-	specAnnotations["autoscaling.knative.dev/max-scale"] = "5"
+	// the proxy serves its metrics on a separate port, away from the ones used by queue-proxy
+	specAnnotations["prometheus.io/scrape"] = "true"
+	specAnnotations["prometheus.io/port"] = fmt.Sprint(health.ProxyHealthPort)
+
+	specAnnotations["autoscaling.knative.dev/min-scale"] = fmt.Sprint(settings.MinScale)
+	specAnnotations["autoscaling.knative.dev/max-scale"] = fmt.Sprint(settings.MaxScale)
+
+	// keep track of the policy each revision of the proxy was rendered from
+	if settings.PolicyName != "" {
+		specAnnotations[controllers.ProxyPolicyAnnotation] = settings.PolicyName
+		specAnnotations[controllers.ProxyPolicyGenerationAnnotation] = fmt.Sprint(settings.PolicyGeneration)
+	} else {
+		delete(specAnnotations, controllers.ProxyPolicyAnnotation)
+		delete(specAnnotations, controllers.ProxyPolicyGenerationAnnotation)
+	}
+}
+
+func getServicePortName(service *servingv1.Service) string {
+	for _, container := range service.Spec.Template.Spec.Containers {
+		for _, port := range container.Ports {
+			if port.Name == "h2c" {
+				return port.Name
+			}
+		}
+	}
+
+	return ""
 }
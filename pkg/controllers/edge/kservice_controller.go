@@ -21,6 +21,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
+	edgev1alpha1 "edge.jevv.dev/pkg/apis/edge/v1alpha1"
 	"edge.jevv.dev/pkg/controllers"
 	"edge.jevv.dev/pkg/controllers/utils"
 	"edge.jevv.dev/pkg/workoffload/store"
@@ -41,12 +42,28 @@ type KServiceReconciler struct {
 	RemoteCluster cluster.Cluster
 	RemoteUrl     string
 
-	ProxyImage string
-	Envs       []string
-	Store      *store.Store
-	HttpProxy  string
-	HttpsProxy string
-	NoProxy    string
+	ProxyImage  string
+	Envs        []string
+	Transformer *Transformer
+	Drift       *DriftTracker
+	Store       store.TrafficStore
+	HttpProxy   string
+	HttpsProxy  string
+	NoProxy     string
+
+	OtlpEndpoint string
+
+	// ProxyCredentials is the secret in the system namespace with the credentials of the edge
+	// proxies, which are read through Reader since it's not managed
+	ProxyCredentials string
+	ProxyTokenHeader string
+	Reader           client.Reader
+
+	// PeerOffload lets the proxies offload to the other edge clusters of the region which use less
+	// than PeerMaxUtilization percent of their cpu and memory, before the remote clusters
+	ClusterName        string
+	PeerOffload        bool
+	PeerMaxUtilization int64
 
 	mirror *MirroringReconciler[*servingv1.Service]
 }
@@ -96,7 +113,7 @@ func (r *KServiceReconciler) kindMerger(src, dst *servingv1.Service) error {
 		annotations[controllers.RemoteHostAnnotation] = src.Status.URL.Host
 	}
 
-	annotations[controllers.RemoteUrlAnnotation] = r.RemoteUrl
+	annotations[controllers.RemoteUrlAnnotation] = r.remoteUrl(src)
 
 	srcAnnotations := src.Annotations
 
@@ -124,6 +141,19 @@ func (r *KServiceReconciler) kindMerger(src, dst *servingv1.Service) error {
 	return nil
 }
 
+// remoteUrl returns the url of the upstream the service was mirrored from, so it's offloaded to the
+// same cluster. It's empty for a secondary upstream without one, since the remote url is the
+// ingress of the primary upstream, which doesn't serve its hosts.
+func (r *KServiceReconciler) remoteUrl(service *servingv1.Service) string {
+	if multi, ok := r.RemoteCluster.(*MultiCluster); ok {
+		if upstream := multi.Upstream(service.Annotations[controllers.UpstreamAnnotation]); upstream != nil && upstream.Name != PrimaryUpstream {
+			return upstream.URL
+		}
+	}
+
+	return r.RemoteUrl
+}
+
 func (r *KServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	//////// debug controller time
 	start := time.Now()
@@ -162,6 +192,10 @@ func (r *KServiceReconciler) SetupWithManager(mgr ctrl.Manager, predicates ...pr
 		return fmt.Errorf("no traffic split store provided")
 	}
 
+	if r.ProxyCredentials != "" && r.Reader == nil {
+		return fmt.Errorf("no api reader provided for the edge proxy credentials")
+	}
+
 	r.mirror = &MirroringReconciler[*servingv1.Service]{
 		Log:               r.Log.WithName("mirror"),
 		Client:            r.Client,
@@ -169,6 +203,8 @@ func (r *KServiceReconciler) SetupWithManager(mgr ctrl.Manager, predicates ...pr
 		Recorder:          r.Recorder,
 		RemoteCluster:     r.RemoteCluster,
 		Envs:              r.Envs,
+		Transformer:       r.Transformer,
+		Drift:             r.Drift,
 		KindGenerator:     r.kindGenerator,
 		KindMerger:        r.kindMerger,
 		KindPreProcessors: &[]kindPreProcessor[*servingv1.Service]{r.reconcileKConfiguration, r.reconcileKService},
@@ -183,5 +219,10 @@ func (r *KServiceReconciler) SetupWithManager(mgr ctrl.Manager, predicates ...pr
 				predicate.NewPredicateFuncs(utils.IsEdgeProxyConfiguration),
 			),
 		).
+		Watches(
+			newRemoteSource(&edgev1alpha1.EdgeProxyPolicy{}, r.RemoteCluster),
+			handler.EnqueueRequestsFromMapFunc(r.findServicesFromProxyPolicy),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
 		Complete(r)
 }
@@ -79,7 +79,7 @@ func (r *KServiceReconciler) reconcileKService(ctx context.Context, service *ser
 			previousTrafficStr := service.Annotations[controllers.EdgeProxyTrafficAnnotation]
 			previousTraffic, err := strconv.ParseInt(previousTrafficStr, 10, 64)
 
-			if err != nil {
+			if err == nil {
 				trafficSplit = previousTraffic
 			}
 		}
@@ -0,0 +1,242 @@
+package edge
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"edge.jevv.dev/pkg/controllers"
+	"edge.jevv.dev/pkg/controllers/utils"
+
+	edgev1alpha1 "edge.jevv.dev/pkg/apis/edge/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+)
+
+// KServiceStatusReconciler reports the status of the mirrored Knative Services back to the remote
+// cluster, as one EdgeServiceStatus per service and EdgeCluster.
+type KServiceStatusReconciler struct {
+	client.Client
+
+	Log           logr.Logger
+	Scheme        *runtime.Scheme
+	Recorder      record.EventRecorder
+	RemoteCluster cluster.Cluster
+	ClusterName   string
+}
+
+type KServiceStatusChangedPredicate struct {
+	predicate.Funcs
+}
+
+func (KServiceStatusChangedPredicate) Update(e event.UpdateEvent) bool {
+	var ok bool
+	var oldService *servingv1.Service
+	var newService *servingv1.Service
+
+	if e.ObjectOld == nil {
+		return e.ObjectNew != nil
+	}
+
+	if oldService, ok = e.ObjectOld.(*servingv1.Service); !ok {
+		return false
+	}
+
+	if newService, ok = e.ObjectNew.(*servingv1.Service); !ok {
+		return false
+	}
+
+	if oldService.Annotations[controllers.EdgeProxyTrafficAnnotation] != newService.Annotations[controllers.EdgeProxyTrafficAnnotation] {
+		return true
+	}
+
+	return !reflect.DeepEqual(oldService.Status, newService.Status)
+}
+
+func (r *KServiceStatusReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.V(controllers.InfoLevel)
+	debug := r.Log.V(controllers.DebugLevel)
+
+	statusName := utils.GetEdgeServiceStatusNamespacedName(req.NamespacedName, r.ClusterName)
+
+	var service servingv1.Service
+
+	if err := r.Get(ctx, req.NamespacedName, &service); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+
+		// the service is no longer on the edge, so its status shouldn't be either
+		edgeServiceStatus := &edgev1alpha1.EdgeServiceStatus{}
+		edgeServiceStatus.Name = statusName.Name
+		edgeServiceStatus.Namespace = statusName.Namespace
+
+		log.Info("Deleting remote service status.", "name", statusName.String())
+
+		// the upstream the service came from isn't known anymore
+		for _, upstream := range upstreamClusters(r.RemoteCluster) {
+			if err := upstream.GetClient().Delete(ctx, edgeServiceStatus); err != nil && !apierrors.IsNotFound(err) {
+				return ctrl.Result{}, err
+			}
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	if !IsManagedObject(&service) {
+		return ctrl.Result{}, nil
+	}
+
+	var remoteService servingv1.Service
+
+	if err := r.RemoteCluster.GetClient().Get(ctx, req.NamespacedName, &remoteService); err != nil {
+		if apierrors.IsNotFound(err) {
+			// the mirror will delete the local service, which will trigger the status removal
+			return ctrl.Result{}, nil
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	// the status is reported to the upstream the service was mirrored from, next to it
+	upstream := upstreamCluster(r.RemoteCluster, &remoteService)
+	remoteClient := upstream.GetClient()
+
+	shouldCreate := false
+	edgeServiceStatus := &edgev1alpha1.EdgeServiceStatus{}
+
+	// service statuses aren't labeled with an environment, so they're not in the remote cache
+	if err := upstream.GetAPIReader().Get(ctx, statusName, edgeServiceStatus); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+
+		shouldCreate = true
+	}
+
+	if shouldCreate {
+		log.Info("Creating remote service status.", "name", statusName.String())
+
+		r.buildEdgeServiceStatus(statusName.Name, &remoteService, edgeServiceStatus)
+
+		if err := remoteClient.Create(ctx, edgeServiceStatus); err != nil {
+			if apierrors.IsAlreadyExists(err) {
+				return ctrl.Result{Requeue: true}, nil
+			}
+
+			return ctrl.Result{}, err
+		}
+	}
+
+	status := r.buildStatus(&service)
+
+	// don't report if nothing changed
+	previousStatus := edgeServiceStatus.Status
+	previousStatus.LastReportedAt = status.LastReportedAt
+
+	if !shouldCreate && reflect.DeepEqual(previousStatus, status) {
+		debug.Info("remote service status unchanged", "name", statusName.String())
+		return ctrl.Result{}, nil
+	}
+
+	edgeServiceStatus.Status = status
+
+	debug.Info("Updating remote service status.", "name", statusName.String(), "ready", status.Ready)
+
+	if err := remoteClient.Status().Update(ctx, edgeServiceStatus); err != nil {
+		if apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+			return ctrl.Result{Requeue: true}, nil
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *KServiceStatusReconciler) buildEdgeServiceStatus(name string, remoteService *servingv1.Service, edgeServiceStatus *edgev1alpha1.EdgeServiceStatus) {
+	edgeServiceStatus.Name = name
+	edgeServiceStatus.Namespace = remoteService.Namespace
+
+	labels := map[string]string{
+		controllers.KServiceLabel:    remoteService.Name,
+		controllers.EdgeClusterLabel: r.ClusterName,
+	}
+
+	if env, exists := remoteService.Labels[controllers.EnvironmentLabel]; exists {
+		labels[controllers.EnvironmentLabel] = env
+	}
+
+	edgeServiceStatus.Labels = labels
+	edgeServiceStatus.Spec = edgev1alpha1.EdgeServiceStatusSpec{
+		ServiceName: remoteService.Name,
+		ClusterName: r.ClusterName,
+	}
+
+	// remove the status when the remote service is removed
+	controllerutil.SetOwnerReference(remoteService, edgeServiceStatus, r.Scheme)
+}
+
+func (r *KServiceStatusReconciler) buildStatus(service *servingv1.Service) edgev1alpha1.EdgeServiceStatusStatus {
+	status := edgev1alpha1.EdgeServiceStatusStatus{
+		LastReportedAt:            time.Now().UTC().Format(time.RFC3339),
+		Ready:                     metav1.ConditionUnknown,
+		LatestCreatedRevisionName: service.Status.LatestCreatedRevisionName,
+		LatestReadyRevisionName:   service.Status.LatestReadyRevisionName,
+		ObservedGeneration:        service.Status.ObservedGeneration,
+	}
+
+	if condition := service.Status.GetCondition(apis.ConditionReady); condition != nil {
+		status.Ready = metav1.ConditionStatus(condition.Status)
+		status.Reason = condition.Reason
+		status.Message = condition.Message
+	}
+
+	if service.Status.URL != nil {
+		status.URL = service.Status.URL.String()
+	}
+
+	if annotations := service.Annotations; annotations != nil {
+		status.RemoteResourceVersion = annotations[controllers.LastRemoteGenerationAnnotation]
+
+		if traffic, err := strconv.ParseInt(annotations[controllers.EdgeProxyTrafficAnnotation], 10, 64); err == nil {
+			status.OffloadTraffic = &traffic
+		}
+	}
+
+	return status
+}
+
+func (r *KServiceStatusReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.ClusterName == "" {
+		return fmt.Errorf("no cluster name provided")
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("kservice-status").
+		For(
+			&servingv1.Service{},
+			builder.WithPredicates(
+				IsManagedByEdgeControllers,
+				KServiceStatusChangedPredicate{},
+			),
+		).
+		Complete(r)
+}
@@ -0,0 +1,286 @@
+package edge
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	edgev1alpha1 "edge.jevv.dev/pkg/apis/edge/v1alpha1"
+	"edge.jevv.dev/pkg/controllers"
+	"edge.jevv.dev/pkg/controllers/utils"
+
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+)
+
+func testService(name string, labels map[string]string) *servingv1.Service {
+	return &servingv1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    labels,
+		},
+		Spec: servingv1.ServiceSpec{
+			ConfigurationSpec: servingv1.ConfigurationSpec{
+				Template: servingv1.RevisionTemplateSpec{
+					Spec: servingv1.RevisionSpec{
+						PodSpec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{Image: "gcr.io/knative-samples/helloworld-go"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func readyStatus(status corev1.ConditionStatus, reason string) servingv1.ServiceStatus {
+	return servingv1.ServiceStatus{
+		Status: duckv1.Status{
+			ObservedGeneration: 2,
+			Conditions:         duckv1.Conditions{{Type: apis.ConditionReady, Status: status, Reason: reason}},
+		},
+		ConfigurationStatusFields: servingv1.ConfigurationStatusFields{
+			LatestCreatedRevisionName: "app-00002",
+			LatestReadyRevisionName:   "app-00001",
+		},
+		RouteStatusFields: servingv1.RouteStatusFields{
+			URL: apis.HTTP("app.default.edge.example.com"),
+		},
+	}
+}
+
+var _ = Describe("knative service status controller", func() {
+	const (
+		timeout  = time.Second * 1
+		interval = time.Millisecond * 250
+	)
+
+	DescribeTable("filtering updates",
+		func(change func(service *servingv1.Service), expected bool) {
+			oldService := testService("app", nil)
+			newService := oldService.DeepCopy()
+			change(newService)
+
+			Expect(KServiceStatusChangedPredicate{}.Update(event.UpdateEvent{ObjectOld: oldService, ObjectNew: newService})).To(Equal(expected))
+		},
+		Entry("unchanged", func(service *servingv1.Service) {}, false),
+		Entry("spec changes", func(service *servingv1.Service) {
+			service.Spec.Template.Spec.Containers[0].Image = "gcr.io/knative-samples/other"
+		}, false),
+		Entry("status changes", func(service *servingv1.Service) {
+			service.Status = readyStatus(corev1.ConditionTrue, "")
+		}, true),
+		Entry("offloaded traffic changes", func(service *servingv1.Service) {
+			service.Annotations = map[string]string{controllers.EdgeProxyTrafficAnnotation: "20"}
+		}, true),
+	)
+
+	It("builds the status of the service", func() {
+		traffic := int64(20)
+
+		service := testService("app", nil)
+		service.Annotations = map[string]string{
+			controllers.LastRemoteGenerationAnnotation: "42",
+			controllers.EdgeProxyTrafficAnnotation:     "20",
+		}
+		service.Status = readyStatus(corev1.ConditionFalse, "RevisionFailed")
+
+		status := (&KServiceStatusReconciler{}).buildStatus(service)
+
+		Expect(status.LastReportedAt).NotTo(BeEmpty())
+		status.LastReportedAt = ""
+
+		Expect(status).To(Equal(edgev1alpha1.EdgeServiceStatusStatus{
+			Ready:                     metav1.ConditionFalse,
+			Reason:                    "RevisionFailed",
+			URL:                       "http://app.default.edge.example.com",
+			LatestCreatedRevisionName: "app-00002",
+			LatestReadyRevisionName:   "app-00001",
+			ObservedGeneration:        2,
+			RemoteResourceVersion:     "42",
+			OffloadTraffic:            &traffic,
+		}))
+
+		By("building the status of a service Knative didn't get to yet")
+		status = (&KServiceStatusReconciler{}).buildStatus(testService("app", nil))
+		Expect(status.Ready).To(Equal(metav1.ConditionUnknown))
+		Expect(status.OffloadTraffic).To(BeNil())
+	})
+
+	Describe("reporting the status", func() {
+		var (
+			ctx    context.Context
+			local  client.Client
+			remote client.Client
+			r      *KServiceStatusReconciler
+		)
+
+		key := types.NamespacedName{Name: "app", Namespace: "default"}
+		statusKey := utils.GetEdgeServiceStatusNamespacedName(key, "edge-1")
+
+		reconcile := func() {
+			_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		reported := func() *edgev1alpha1.EdgeServiceStatus {
+			edgeServiceStatus := &edgev1alpha1.EdgeServiceStatus{}
+			Expect(remote.Get(ctx, statusKey, edgeServiceStatus)).To(Succeed())
+
+			return edgeServiceStatus
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(servingv1.AddToScheme(scheme)).To(Succeed())
+			Expect(edgev1alpha1.AddToScheme(scheme)).To(Succeed())
+
+			localService := testService(key.Name, map[string]string{controllers.ManagedLabel: "true"})
+			localService.Status = readyStatus(corev1.ConditionTrue, "")
+
+			local = fake.NewClientBuilder().WithScheme(scheme).WithObjects(localService).Build()
+			remote = fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				testService(key.Name, map[string]string{controllers.EnvironmentLabel: "testA"}),
+			).Build()
+
+			r = &KServiceStatusReconciler{
+				Client:        local,
+				Log:           logr.Discard(),
+				Scheme:        scheme,
+				RemoteCluster: &stubCluster{client: remote},
+				ClusterName:   "edge-1",
+			}
+		})
+
+		It("creates the status next to the remote service", func() {
+			reconcile()
+
+			edgeServiceStatus := reported()
+			Expect(edgeServiceStatus.Labels).To(Equal(map[string]string{
+				controllers.KServiceLabel:    key.Name,
+				controllers.EdgeClusterLabel: "edge-1",
+				controllers.EnvironmentLabel: "testA",
+			}))
+			Expect(edgeServiceStatus.Spec).To(Equal(edgev1alpha1.EdgeServiceStatusSpec{ServiceName: key.Name, ClusterName: "edge-1"}))
+			Expect(edgeServiceStatus.OwnerReferences).To(ConsistOf(HaveField("Name", key.Name)))
+			Expect(edgeServiceStatus.Status.Ready).To(Equal(metav1.ConditionTrue))
+			Expect(edgeServiceStatus.Status.LatestReadyRevisionName).To(Equal("app-00001"))
+		})
+
+		It("only updates the status when it changed", func() {
+			reconcile()
+			resourceVersion := reported().ResourceVersion
+
+			reconcile()
+			Expect(reported().ResourceVersion).To(Equal(resourceVersion))
+
+			By("changing the status of the local service")
+			service := &servingv1.Service{}
+			Expect(local.Get(ctx, key, service)).To(Succeed())
+			service.Status = readyStatus(corev1.ConditionFalse, "RevisionFailed")
+			Expect(local.Update(ctx, service)).To(Succeed())
+
+			reconcile()
+
+			edgeServiceStatus := reported()
+			Expect(edgeServiceStatus.ResourceVersion).NotTo(Equal(resourceVersion))
+			Expect(edgeServiceStatus.Status.Ready).To(Equal(metav1.ConditionFalse))
+			Expect(edgeServiceStatus.Status.Reason).To(Equal("RevisionFailed"))
+		})
+
+		It("deletes the status of services which aren't on the edge anymore", func() {
+			reconcile()
+
+			Expect(local.Delete(ctx, testService(key.Name, nil))).To(Succeed())
+
+			reconcile()
+
+			Expect(apierrors.IsNotFound(remote.Get(ctx, statusKey, &edgev1alpha1.EdgeServiceStatus{}))).To(BeTrue())
+		})
+
+		It("doesn't report local services which aren't mirrored", func() {
+			service := &servingv1.Service{}
+			Expect(local.Get(ctx, key, service)).To(Succeed())
+			service.Labels = nil
+			Expect(local.Update(ctx, service)).To(Succeed())
+
+			reconcile()
+
+			Expect(apierrors.IsNotFound(remote.Get(ctx, statusKey, &edgev1alpha1.EdgeServiceStatus{}))).To(BeTrue())
+		})
+	})
+
+	Context("when mirroring a service", func() {
+		It("should report its status to the remote cluster", func() {
+			ctx := context.Background()
+
+			namespacedName := types.NamespacedName{Name: "service-status-test-1", Namespace: "default"}
+			statusName := utils.GetEdgeServiceStatusNamespacedName(namespacedName, testClusterName)
+
+			service := testService(namespacedName.Name, map[string]string{
+				controllers.AppLabel:         "knative-edge",
+				controllers.EnvironmentLabel: "testA",
+			})
+
+			Expect(remoteClusterClient.Create(ctx, service)).Should(Succeed())
+
+			edgeServiceStatus := &edgev1alpha1.EdgeServiceStatus{}
+
+			Eventually(func(g Gomega) {
+				g.Expect(remoteClusterClient.Get(ctx, statusName, edgeServiceStatus)).Should(Succeed())
+				g.Expect(edgeServiceStatus.Spec.ServiceName).To(Equal(namespacedName.Name))
+				g.Expect(edgeServiceStatus.Spec.ClusterName).To(Equal(testClusterName))
+				g.Expect(edgeServiceStatus.Status.Ready).To(Equal(metav1.ConditionUnknown))
+			}, timeout, interval).Should(Succeed())
+
+			By("updating the status of the mirrored service")
+			Eventually(func() error {
+				mirroredService := &servingv1.Service{}
+
+				if err := edgeClusterClient.Get(ctx, namespacedName, mirroredService); err != nil {
+					return err
+				}
+
+				mirroredService.Status = readyStatus(corev1.ConditionTrue, "")
+
+				return edgeClusterClient.Status().Update(ctx, mirroredService)
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				g.Expect(remoteClusterClient.Get(ctx, statusName, edgeServiceStatus)).Should(Succeed())
+				g.Expect(edgeServiceStatus.Status.Ready).To(Equal(metav1.ConditionTrue))
+				g.Expect(edgeServiceStatus.Status.URL).To(Equal("http://app.default.edge.example.com"))
+			}, timeout, interval).Should(Succeed())
+
+			By("deleting the service")
+			Expect(remoteClusterClient.Delete(ctx, service)).Should(Succeed())
+
+			Eventually(func() bool {
+				return apierrors.IsNotFound(remoteClusterClient.Get(ctx, statusName, edgeServiceStatus))
+			}, timeout, interval).Should(BeTrue())
+		})
+	})
+})
@@ -4,23 +4,31 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/go-logr/logr"
 
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/tools/record"
 
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/cluster"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
-	"sigs.k8s.io/controller-runtime/pkg/source"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	edgev1alpha1 "edge.jevv.dev/pkg/apis/edge/v1alpha1"
 	"edge.jevv.dev/pkg/controllers"
 	"edge.jevv.dev/pkg/controllers/utils"
+	"edge.jevv.dev/pkg/metrics"
 )
 
 type kindGenerator[T client.Object] func() T
@@ -39,6 +47,11 @@ type MirroringReconciler[T client.Object] struct {
 	KindMerger        kindMerger[T]
 	KindPreProcessors *[]kindPreProcessor[T]
 
+	// Transformer changes the merged objects before they're applied, if set
+	Transformer *Transformer
+	// Drift decides what happens to local changes of the mirrored objects, reverting them if unset
+	Drift *DriftTracker
+
 	Envs []string
 }
 
@@ -60,10 +73,16 @@ func (r *MirroringReconciler[T]) Reconcile(ctx context.Context, req ctrl.Request
 
 	localKind, remoteKind := r.KindGenerator(), r.KindGenerator()
 
+	gk := r.groupKind(localKind)
+	key := driftKey(gk, req.NamespacedName)
+
 	shouldCreate := false
 	shouldUpdate := false
 	shouldDelete := false
 
+	// only remote changes count towards the mirroring lag
+	remoteChanged := false
+
 	if err := r.RemoteCluster.GetClient().Get(ctx, req.NamespacedName, remoteKind); err != nil {
 		if apierrors.IsNotFound(err) {
 			shouldDelete = true
@@ -77,6 +96,8 @@ func (r *MirroringReconciler[T]) Reconcile(ctx context.Context, req ctrl.Request
 			return result, err
 		}
 
+		r.Drift.set(key, "")
+
 		// exit early if both local and remote kind don't exist
 		if shouldDelete {
 			return result, nil
@@ -88,6 +109,19 @@ func (r *MirroringReconciler[T]) Reconcile(ctx context.Context, req ctrl.Request
 		shouldDelete = true
 	}
 
+	// pinned objects keep their local changes until they're unpinned, even if the remote one is deleted
+	if !shouldCreate && isPinned(localKind) {
+		r.Drift.set(key, driftStatePinned)
+
+		if shouldDelete || localKind.GetAnnotations()[controllers.LastRemoteGenerationAnnotation] != remoteKind.GetResourceVersion() {
+			r.Recorder.Event(localKind, corev1.EventTypeNormal, ReasonPinned, "Remote changes aren't mirrored while the object is pinned.")
+		}
+
+		debug.Info("Skipping pinned local resource.", "resource", req.NamespacedName.String())
+
+		return result, nil
+	}
+
 	// make a copy to compare after the changes
 	localKindCopy, ok := localKind.DeepCopyObject().(T)
 
@@ -112,10 +146,55 @@ func (r *MirroringReconciler[T]) Reconcile(ctx context.Context, req ctrl.Request
 
 		if remoteGeneration != lastRemoteGeneration {
 			shouldUpdate = lastRemoteGenerationExists
+			remoteChanged = true
 		}
 
 		r.KindMerger(remoteKind, localKindCopy)
 
+		if r.Transformer != nil {
+			if err := r.Transformer.Transform(ctx, localKindCopy); err != nil {
+				metrics.MirrorErrors.WithLabelValues(r.kindName(localKindCopy), metrics.OperationTransform).Inc()
+				return result, err
+			}
+		}
+
+		// pins are only set on the edge
+		setAnnotation(localKindCopy, controllers.PinnedAnnotation, "", false)
+
+		utils.UpdateLastRemoteGenerationAnnotation(localKindCopy, remoteKind)
+		utils.UpdateLabels(localKindCopy)
+
+		if r.hasDrifted(ctx, remoteKind, localKind, localKindCopy) {
+			policy := r.Drift.Policy(gk)
+			metrics.MirrorDrift.WithLabelValues(r.kindName(localKind), string(policy)).Inc()
+
+			switch policy {
+			case DriftPolicyKeepLocal, DriftPolicyAlertOnly:
+				if policy == DriftPolicyAlertOnly {
+					log.Info("Local resource differs from the remote one, keeping local changes.", "name", req.NamespacedName.String())
+					r.Recorder.Event(localKind, corev1.EventTypeWarning, ReasonDriftDetected, "Local changes differ from the remote object, they're kept until it changes.")
+				} else {
+					debug.Info("Keeping local changes.", "resource", req.NamespacedName.String())
+					r.Recorder.Event(localKind, corev1.EventTypeNormal, ReasonDriftKept, "Local changes are kept until the remote object changes.")
+				}
+
+				// drop the merged changes, but keep tracking the remote object
+				if kept, ok := localKind.DeepCopyObject().(T); ok {
+					localKindCopy = kept
+					utils.UpdateLastRemoteGenerationAnnotation(localKindCopy, remoteKind)
+				}
+
+				r.Drift.set(key, driftStateDrifted)
+			default:
+				log.Info("Reverting local changes.", "name", req.NamespacedName.String())
+				r.Recorder.Event(localKind, corev1.EventTypeWarning, ReasonDriftReverted, "Local changes were reverted to the remote object.")
+
+				r.Drift.set(key, "")
+			}
+		} else {
+			r.Drift.set(key, "")
+		}
+
 		if r.KindPreProcessors != nil {
 			for _, preprocessor := range *r.KindPreProcessors {
 				res, err := preprocessor(ctx, localKindCopy)
@@ -136,9 +215,6 @@ func (r *MirroringReconciler[T]) Reconcile(ctx context.Context, req ctrl.Request
 			debug.Info("preprocessor end", "resource", req.NamespacedName.String(), "result", result)
 		}
 
-		utils.UpdateLastRemoteGenerationAnnotation(localKindCopy, remoteKind)
-		utils.UpdateLabels(localKindCopy)
-
 		shouldUpdate = shouldUpdate || !reflect.DeepEqual(localKind, localKindCopy)
 
 		// if shouldUpdate {
@@ -156,6 +232,8 @@ func (r *MirroringReconciler[T]) Reconcile(ctx context.Context, req ctrl.Request
 	debug.Info("debug result", "resource", req.NamespacedName.String(), "result", result)
 	debug.Info("debug bool", "resource", req.NamespacedName.String(), "shouldCreate", shouldCreate, "shouldUpdate", shouldUpdate, "shouldDelete", shouldDelete)
 
+	kind := r.kindName(localKindCopy)
+
 	if shouldCreate {
 		log.Info("Creating local resource.", "name", req.NamespacedName.String())
 		if err := r.Create(ctx, localKindCopy); err != nil {
@@ -163,8 +241,12 @@ func (r *MirroringReconciler[T]) Reconcile(ctx context.Context, req ctrl.Request
 				return ctrl.Result{Requeue: true}, nil
 			}
 
+			metrics.MirrorErrors.WithLabelValues(kind, metrics.OperationCreate).Inc()
 			return result, err
 		}
+
+		metrics.MirrorOperations.WithLabelValues(kind, metrics.OperationCreate).Inc()
+		metrics.MirrorLag.WithLabelValues(kind).Observe(time.Since(lastChangeTime(remoteKind)).Seconds())
 	} else if shouldDelete {
 		log.Info("Deleting local resource.", "name", req.NamespacedName.String())
 		if err := r.Delete(ctx, localKindCopy); err != nil {
@@ -172,8 +254,12 @@ func (r *MirroringReconciler[T]) Reconcile(ctx context.Context, req ctrl.Request
 				return result, nil
 			}
 
+			metrics.MirrorErrors.WithLabelValues(kind, metrics.OperationDelete).Inc()
 			return result, err
 		}
+
+		metrics.MirrorOperations.WithLabelValues(kind, metrics.OperationDelete).Inc()
+		r.Drift.set(key, "")
 	} else if shouldUpdate {
 		log.Info("Updating local resource.", "name", req.NamespacedName.String())
 		if err := r.Update(ctx, localKindCopy); err != nil {
@@ -181,17 +267,89 @@ func (r *MirroringReconciler[T]) Reconcile(ctx context.Context, req ctrl.Request
 				return ctrl.Result{Requeue: true}, nil
 			}
 
+			metrics.MirrorErrors.WithLabelValues(kind, metrics.OperationUpdate).Inc()
 			return result, err
 		}
+
+		metrics.MirrorOperations.WithLabelValues(kind, metrics.OperationUpdate).Inc()
+
+		if remoteChanged {
+			metrics.MirrorLag.WithLabelValues(kind).Observe(time.Since(lastChangeTime(remoteKind)).Seconds())
+		}
 	}
 
 	return result, nil
 }
 
+// hasDrifted sets the hash of the mirrored object on the merged one, and compares them if the
+// mirrored object didn't change since the last update, in which case only local changes can make
+// them differ.
+func (r *MirroringReconciler[T]) hasDrifted(ctx context.Context, remoteKind, localKind, mergedKind T) bool {
+	hash, err := r.desiredHash(ctx, remoteKind)
+
+	if err != nil {
+		r.Log.V(controllers.DebugLevel).Info("Couldn't hash mirrored resource, local changes won't be detected.", "error", err.Error())
+		return false
+	}
+
+	annotations := localKind.GetAnnotations()
+	lastHash, lastHashExists := annotations[controllers.MirroredHashAnnotation]
+
+	setAnnotation(mergedKind, controllers.MirroredHashAnnotation, hash, true)
+
+	if !lastHashExists || lastHash != hash {
+		return false
+	}
+
+	// the mergers can drop the annotation of the last update, which isn't mirrored
+	lastGeneration, lastGenerationExists := annotations[controllers.LastGenerationAnnotation]
+	setAnnotation(mergedKind, controllers.LastGenerationAnnotation, lastGeneration, lastGenerationExists)
+
+	return !reflect.DeepEqual(localKind, mergedKind)
+}
+
+func (r *MirroringReconciler[T]) groupKind(obj T) schema.GroupKind {
+	gvk, err := apiutil.GVKForObject(obj, r.Scheme)
+
+	if err != nil {
+		return schema.GroupKind{Kind: "unknown"}
+	}
+
+	return gvk.GroupKind()
+}
+
+func (r *MirroringReconciler[T]) kindName(obj T) string {
+	gvk, err := apiutil.GVKForObject(obj, r.Scheme)
+
+	if err != nil {
+		return "unknown"
+	}
+
+	return gvk.Kind
+}
+
+// lastChangeTime is the time of the last write to the object, as tracked by its managed fields.
+// Writes to the status subresource don't change what's mirrored, so they're left out.
+func lastChangeTime(obj client.Object) time.Time {
+	lastChange := obj.GetCreationTimestamp().Time
+
+	for _, entry := range obj.GetManagedFields() {
+		if entry.Subresource != "" {
+			continue
+		}
+
+		if entry.Time != nil && entry.Time.After(lastChange) {
+			lastChange = entry.Time.Time
+		}
+	}
+
+	return lastChange
+}
+
 func (r *MirroringReconciler[T]) NewControllerManagedBy(mgr ctrl.Manager, predicates ...predicate.Predicate) *builder.Builder {
 	predicates = append(predicates, predicate.ResourceVersionChangedPredicate{})
 
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		// local watch
 		For(
 			r.KindGenerator(),
@@ -202,10 +360,89 @@ func (r *MirroringReconciler[T]) NewControllerManagedBy(mgr ctrl.Manager, predic
 		).
 		// remote watch
 		Watches(
-			source.NewKindWithCache(r.KindGenerator(), r.RemoteCluster.GetCache()),
+			newRemoteSource(r.KindGenerator(), r.RemoteCluster),
 			&handler.EnqueueRequestForObject{},
 			builder.WithPredicates(
 				predicates...,
 			),
 		)
+
+	if r.Transformer != nil {
+		b = b.Watches(
+			newRemoteSource(&edgev1alpha1.MirrorTransformation{}, r.RemoteCluster),
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsFromTransformation),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		)
+	}
+
+	return b
+}
+
+// findObjectsFromTransformation mirrors the remote objects again when a transformation of their
+// kind changes.
+func (r *MirroringReconciler[T]) findObjectsFromTransformation(obj client.Object) []reconcile.Request {
+	transformation, ok := obj.(*edgev1alpha1.MirrorTransformation)
+
+	if !ok {
+		return []reconcile.Request{}
+	}
+
+	gvk, err := apiutil.GVKForObject(r.KindGenerator(), r.Scheme)
+
+	if err != nil || !r.Transformer.selectsKind(transformation, gvk.GroupKind()) {
+		return []reconcile.Request{}
+	}
+
+	list, err := newObjectList(gvk, r.Scheme)
+
+	if err == nil {
+		err = r.RemoteCluster.GetClient().List(context.Background(), list)
+	}
+
+	if err != nil {
+		r.Log.Error(err, "Couldn't list remote objects to transform them again.", "transformation", transformation.Name)
+		return []reconcile.Request{}
+	}
+
+	items, err := meta.ExtractList(list)
+
+	if err != nil {
+		return []reconcile.Request{}
+	}
+
+	requests := make([]reconcile.Request, 0, len(items))
+
+	for _, item := range items {
+		if kind, ok := item.(client.Object); ok {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(kind)})
+		}
+	}
+
+	return requests
+}
+
+// newObjectList creates an empty list of the kind, unstructured if the scheme doesn't know it.
+func newObjectList(gvk schema.GroupVersionKind, scheme *runtime.Scheme) (client.ObjectList, error) {
+	listGVK := gvk.GroupVersion().WithKind(gvk.Kind + "List")
+
+	if !scheme.Recognizes(listGVK) {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(listGVK)
+
+		return list, nil
+	}
+
+	obj, err := scheme.New(listGVK)
+
+	if err != nil {
+		return nil, err
+	}
+
+	list, ok := obj.(client.ObjectList)
+
+	if !ok {
+		return nil, fmt.Errorf("%s isn't a list", listGVK.String())
+	}
+
+	return list, nil
 }
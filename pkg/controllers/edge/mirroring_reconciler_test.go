@@ -0,0 +1,44 @@
+package edge
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("mirroring reconciler", func() {
+	created := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	at := func(d time.Duration) *metav1.Time {
+		t := metav1.NewTime(created.Add(d))
+		return &t
+	}
+
+	DescribeTable("last change time",
+		func(entries []metav1.ManagedFieldsEntry, expected time.Time) {
+			configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+				CreationTimestamp: metav1.NewTime(created),
+				ManagedFields:     entries,
+			}}
+
+			Expect(lastChangeTime(configMap)).To(BeTemporally("==", expected))
+		},
+		Entry("without managed fields", nil, created),
+		Entry("latest write of the managers", []metav1.ManagedFieldsEntry{
+			{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationUpdate, Time: at(time.Hour)},
+			{Manager: "helm", Operation: metav1.ManagedFieldsOperationApply, Time: at(2 * time.Hour)},
+			{Manager: "unknown"},
+		}, created.Add(2*time.Hour)),
+		Entry("leaving out status writes", []metav1.ManagedFieldsEntry{
+			{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationUpdate, Time: at(time.Hour)},
+			{Manager: "controller", Operation: metav1.ManagedFieldsOperationUpdate, Time: at(3 * time.Hour), Subresource: "status"},
+		}, created.Add(time.Hour)),
+		Entry("only status writes", []metav1.ManagedFieldsEntry{
+			{Manager: "controller", Operation: metav1.ManagedFieldsOperationUpdate, Time: at(time.Hour), Subresource: "status"},
+		}, created),
+	)
+})
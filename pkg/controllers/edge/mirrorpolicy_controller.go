@@ -0,0 +1,357 @@
+package edge
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	edgev1alpha1 "edge.jevv.dev/pkg/apis/edge/v1alpha1"
+	"edge.jevv.dev/pkg/controllers"
+)
+
+// the kinds which have their own mirroring controller
+var builtInMirrorKinds = map[schema.GroupKind]bool{
+	{Group: "", Kind: "Namespace"}:                  true,
+	{Group: "", Kind: "ConfigMap"}:                  true,
+	{Group: "", Kind: "Secret"}:                     true,
+	{Group: "serving.knative.dev", Kind: "Service"}: true,
+}
+
+const mirrorPolicyRetryPeriod = time.Minute
+
+type mirrorRules struct {
+	Policy  string
+	Include []string
+	Exclude []string
+}
+
+// unstructuredMirror mirrors the objects of a kind listed in a MirrorPolicy. Controllers can't be
+// removed from the manager, so it stops mirroring once no policy lists the kind anymore.
+type unstructuredMirror struct {
+	Log           logr.Logger
+	RemoteCluster cluster.Cluster
+
+	gvk    schema.GroupVersionKind
+	mirror *MirroringReconciler[*unstructured.Unstructured]
+	events chan event.GenericEvent
+
+	lock  sync.RWMutex
+	rules *mirrorRules
+}
+
+func (m *unstructuredMirror) kindGenerator() *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(m.gvk)
+
+	return obj
+}
+
+func (m *unstructuredMirror) getRules() *mirrorRules {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return m.rules
+}
+
+// setRules updates the rules, and mirrors the objects again if they changed.
+func (m *unstructuredMirror) setRules(ctx context.Context, rules *mirrorRules) {
+	m.lock.Lock()
+	previous := m.rules
+	m.rules = rules
+	m.lock.Unlock()
+
+	if previous == nil || rules == nil || reflect.DeepEqual(previous, rules) {
+		return
+	}
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(m.gvk.GroupVersion().WithKind(m.gvk.Kind + "List"))
+
+	if err := m.RemoteCluster.GetClient().List(ctx, list); err != nil {
+		m.Log.Error(err, "Couldn't list remote objects to mirror them again.")
+		return
+	}
+
+	go func() {
+		for i := range list.Items {
+			m.events <- event.GenericEvent{Object: &list.Items[i]}
+		}
+	}()
+}
+
+// getField returns a copy of the field of an object, split in its path.
+func getField(content map[string]interface{}, path []string) (interface{}, bool) {
+	value, found, err := unstructured.NestedFieldCopy(content, path...)
+
+	return value, found && err == nil
+}
+
+func setField(content map[string]interface{}, value interface{}, path []string) {
+	// only fails if a parent isn't an object, in which case the field can't be set anyway
+	_ = unstructured.SetNestedField(content, value, path...)
+}
+
+// copyField copies the field from src to dst, or removes it from dst if src doesn't have it.
+func copyField(src, dst map[string]interface{}, field string) {
+	path := strings.Split(field, ".")
+
+	if value, found := getField(src, path); found {
+		setField(dst, value, path)
+	} else {
+		unstructured.RemoveNestedField(dst, path...)
+	}
+}
+
+// kindMerger copies the fields of the remote object selected by the rules. Fields which aren't
+// copied keep their local values.
+func (m *unstructuredMirror) kindMerger(src, dst *unstructured.Unstructured) error {
+	rules := m.getRules()
+
+	if src == nil || rules == nil {
+		return nil
+	}
+
+	srcContent := src.DeepCopy().UnstructuredContent()
+	dstContent := dst.UnstructuredContent()
+	localContent := dst.DeepCopy().UnstructuredContent()
+
+	for _, content := range []map[string]interface{}{srcContent, localContent} {
+		delete(content, "apiVersion")
+		delete(content, "kind")
+		delete(content, "metadata")
+		delete(content, "status")
+	}
+
+	var content map[string]interface{}
+
+	if len(rules.Include) == 0 {
+		content = srcContent
+	} else {
+		// the excluded fields are copied from the local content, so it can't be changed here
+		content = runtime.DeepCopyJSON(localContent)
+
+		for _, field := range rules.Include {
+			copyField(srcContent, content, field)
+		}
+	}
+
+	for _, field := range rules.Exclude {
+		copyField(localContent, content, field)
+	}
+
+	for key := range dstContent {
+		if key != "metadata" && key != "status" {
+			delete(dstContent, key)
+		}
+	}
+
+	for key, value := range content {
+		dstContent[key] = value
+	}
+
+	dst.SetGroupVersionKind(m.gvk)
+	dst.SetName(src.GetName())
+	dst.SetNamespace(src.GetNamespace())
+	dst.SetLabels(src.GetLabels())
+	dst.SetAnnotations(src.GetAnnotations())
+
+	return nil
+}
+
+func (m *unstructuredMirror) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	if m.getRules() == nil {
+		return ctrl.Result{}, nil
+	}
+
+	// unstructured objects aren't read through the managed cache, so local objects which weren't
+	// mirrored have to be skipped here
+	local := m.kindGenerator()
+
+	if err := m.mirror.Get(ctx, req.NamespacedName, local); err == nil {
+		if !IsManagedObject(local) {
+			m.Log.V(controllers.DebugLevel).Info("Skipping local object which isn't mirrored.", "resource", req.NamespacedName.String())
+			return ctrl.Result{}, nil
+		}
+	} else if !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+
+	return m.mirror.Reconcile(ctx, req)
+}
+
+// MirrorPolicyReconciler starts mirroring the kinds listed in the MirrorPolicies of the remote
+// cluster, besides the ones which have their own controller.
+type MirrorPolicyReconciler struct {
+	client.Client
+
+	Log           logr.Logger
+	Scheme        *runtime.Scheme
+	Recorder      record.EventRecorder
+	RemoteCluster cluster.Cluster
+	Envs          []string
+	Transformer   *Transformer
+	Drift         *DriftTracker
+
+	mgr        ctrl.Manager
+	predicates []predicate.Predicate
+
+	lock    sync.Mutex
+	mirrors map[schema.GroupVersionKind]*unstructuredMirror
+}
+
+func (r *MirrorPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.V(controllers.InfoLevel)
+
+	// the kinds of all policies are reconciled together, since they can overlap
+	policies := &edgev1alpha1.MirrorPolicyList{}
+
+	if err := r.RemoteCluster.GetClient().List(ctx, policies); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	sort.Slice(policies.Items, func(i, j int) bool {
+		return policies.Items[i].Name < policies.Items[j].Name
+	})
+
+	desired := make(map[schema.GroupVersionKind]*mirrorRules)
+
+	for _, policy := range policies.Items {
+		for _, resource := range policy.Spec.Resources {
+			gvk := resource.GroupVersionKind()
+
+			if builtInMirrorKinds[gvk.GroupKind()] || gvk.Group == edgev1alpha1.GroupVersion.Group {
+				log.Info("Ignoring kind which can't be mirrored through a policy.", "policy", policy.Name, "kind", gvk.String())
+				continue
+			}
+
+			if existing, exists := desired[gvk]; exists {
+				log.Info("Ignoring kind already mirrored by another policy.", "policy", policy.Name, "kind", gvk.String(), "mirroredBy", existing.Policy)
+				continue
+			}
+
+			desired[gvk] = &mirrorRules{
+				Policy:  policy.Name,
+				Include: resource.IncludeFields,
+				Exclude: resource.ExcludeFields,
+			}
+		}
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	retry := false
+
+	for gvk, rules := range desired {
+		m, exists := r.mirrors[gvk]
+
+		if !exists {
+			// the kind might not be installed in the edge cluster yet
+			if _, err := r.mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+				log.Info("Kind isn't available in the edge cluster, will try again later.", "kind", gvk.String(), "error", err.Error())
+				retry = true
+
+				continue
+			}
+
+			var err error
+
+			if m, err = r.startMirror(gvk); err != nil {
+				return ctrl.Result{}, fmt.Errorf("couldn't start mirroring %s: %w", gvk.String(), err)
+			}
+
+			log.Info("Started mirroring kind.", "kind", gvk.String(), "policy", rules.Policy)
+		}
+
+		m.setRules(ctx, rules)
+	}
+
+	for gvk, m := range r.mirrors {
+		if _, exists := desired[gvk]; !exists && m.getRules() != nil {
+			log.Info("Stopped mirroring kind.", "kind", gvk.String())
+			m.setRules(ctx, nil)
+		}
+	}
+
+	if retry {
+		return ctrl.Result{RequeueAfter: mirrorPolicyRetryPeriod}, nil
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *MirrorPolicyReconciler) startMirror(gvk schema.GroupVersionKind) (*unstructuredMirror, error) {
+	name := strings.ToLower(gvk.GroupKind().String())
+
+	m := &unstructuredMirror{
+		Log:           r.Log.WithName(name),
+		RemoteCluster: r.RemoteCluster,
+		gvk:           gvk,
+		events:        make(chan event.GenericEvent),
+	}
+
+	m.mirror = &MirroringReconciler[*unstructured.Unstructured]{
+		Log:           m.Log.WithName("mirror"),
+		Client:        r.Client,
+		Scheme:        r.Scheme,
+		Recorder:      r.Recorder,
+		RemoteCluster: r.RemoteCluster,
+		Envs:          r.Envs,
+		Transformer:   r.Transformer,
+		Drift:         r.Drift,
+		KindGenerator: m.kindGenerator,
+		KindMerger:    m.kindMerger,
+	}
+
+	err := m.mirror.NewControllerManagedBy(r.mgr, r.predicates...).
+		Named(fmt.Sprintf("mirror-%s", name)).
+		Watches(&source.Channel{Source: m.events}, &handler.EnqueueRequestForObject{}).
+		Complete(m)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if r.mirrors == nil {
+		r.mirrors = make(map[schema.GroupVersionKind]*unstructuredMirror)
+	}
+
+	r.mirrors[gvk] = m
+
+	return m, nil
+}
+
+func (r *MirrorPolicyReconciler) SetupWithManager(mgr ctrl.Manager, predicates ...predicate.Predicate) error {
+	r.mgr = mgr
+	r.predicates = predicates
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("mirrorpolicy").
+		Watches(
+			newRemoteSource(&edgev1alpha1.MirrorPolicy{}, r.RemoteCluster),
+			&handler.EnqueueRequestForObject{},
+			builder.WithPredicates(predicates...),
+		).
+		Complete(r)
+}
@@ -0,0 +1,207 @@
+package edge
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	edgev1alpha1 "edge.jevv.dev/pkg/apis/edge/v1alpha1"
+	"edge.jevv.dev/pkg/controllers"
+)
+
+var _ = Describe("mirror policy controller", func() {
+	const (
+		timeout  = time.Second * 1
+		duration = time.Second * 2
+		interval = time.Millisecond * 250
+	)
+
+	gvk := schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}
+
+	widget := func(metadata map[string]interface{}, spec map[string]interface{}) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"metadata": metadata,
+			"spec":     spec,
+			"status":   map[string]interface{}{"phase": "Ready"},
+		}}
+		obj.SetGroupVersionKind(gvk)
+
+		return obj
+	}
+
+	DescribeTable("merging fields",
+		func(rules *mirrorRules, expected map[string]interface{}) {
+			src := widget(map[string]interface{}{
+				"name":        "widget",
+				"namespace":   "default",
+				"labels":      map[string]interface{}{"from": "remote"},
+				"annotations": map[string]interface{}{"from": "remote"},
+			}, map[string]interface{}{
+				"size":     "large",
+				"color":    "red",
+				"template": map[string]interface{}{"image": "remote"},
+			})
+
+			dst := widget(map[string]interface{}{
+				"name":            "widget",
+				"namespace":       "default",
+				"resourceVersion": "7",
+				"labels":          map[string]interface{}{"from": "local"},
+			}, map[string]interface{}{
+				"size":       "small",
+				"volumeName": "local",
+				"template":   map[string]interface{}{"image": "local", "pullPolicy": "Always"},
+			})
+			dst.Object["status"] = map[string]interface{}{"phase": "Pending"}
+
+			m := &unstructuredMirror{gvk: gvk, rules: rules}
+			Expect(m.kindMerger(src, dst)).To(Succeed())
+
+			Expect(dst.Object["spec"]).To(Equal(expected))
+			Expect(dst.GetLabels()).To(Equal(map[string]string{"from": "remote"}))
+			Expect(dst.GetAnnotations()).To(Equal(map[string]string{"from": "remote"}))
+			Expect(dst.GetResourceVersion()).To(Equal("7"))
+			Expect(dst.Object["status"]).To(Equal(map[string]interface{}{"phase": "Pending"}))
+		},
+		Entry("every field", &mirrorRules{}, map[string]interface{}{
+			"size":     "large",
+			"color":    "red",
+			"template": map[string]interface{}{"image": "remote"},
+		}),
+		Entry("included fields", &mirrorRules{Include: []string{"spec.color", "spec.template.image"}}, map[string]interface{}{
+			"size":       "small",
+			"color":      "red",
+			"volumeName": "local",
+			"template":   map[string]interface{}{"image": "remote", "pullPolicy": "Always"},
+		}),
+		Entry("included fields missing from the remote object", &mirrorRules{Include: []string{"spec.volumeName"}}, map[string]interface{}{
+			"size":     "small",
+			"template": map[string]interface{}{"image": "local", "pullPolicy": "Always"},
+		}),
+		Entry("excluded fields", &mirrorRules{Exclude: []string{"spec.volumeName", "spec.template.image"}}, map[string]interface{}{
+			"size":       "large",
+			"color":      "red",
+			"volumeName": "local",
+			"template":   map[string]interface{}{"image": "local"},
+		}),
+		Entry("excluded fields among the included ones", &mirrorRules{Include: []string{"spec.template"}, Exclude: []string{"spec.template.pullPolicy"}}, map[string]interface{}{
+			"size":       "small",
+			"volumeName": "local",
+			"template":   map[string]interface{}{"image": "remote", "pullPolicy": "Always"},
+		}),
+	)
+
+	It("doesn't merge without rules", func() {
+		dst := widget(map[string]interface{}{"name": "widget"}, map[string]interface{}{"size": "small"})
+
+		m := &unstructuredMirror{gvk: gvk}
+		Expect(m.kindMerger(widget(map[string]interface{}{"name": "widget"}, map[string]interface{}{"size": "large"}), dst)).To(Succeed())
+		Expect(dst.Object["spec"]).To(Equal(map[string]interface{}{"size": "small"}))
+
+		Expect(m.Reconcile(context.Background(), ctrl.Request{})).To(Equal(ctrl.Result{}))
+	})
+
+	It("ignores the kinds which have their own controller", func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(edgev1alpha1.AddToScheme(scheme)).To(Succeed())
+
+		remote := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&edgev1alpha1.MirrorPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "built-in"},
+			Spec: edgev1alpha1.MirrorPolicySpec{Resources: []edgev1alpha1.MirrorPolicyResource{
+				{Version: "v1", Kind: "ConfigMap"},
+				{Group: "serving.knative.dev", Version: "v1", Kind: "Service"},
+				{Group: edgev1alpha1.GroupVersion.Group, Version: "v1alpha1", Kind: "MirrorPolicy"},
+			}},
+		}).Build()
+
+		// nothing is mirrored, so the manager isn't needed
+		r := &MirrorPolicyReconciler{Log: logr.Discard(), RemoteCluster: &stubCluster{client: remote}}
+
+		Expect(r.Reconcile(context.Background(), ctrl.Request{})).To(Equal(ctrl.Result{}))
+		Expect(r.mirrors).To(BeEmpty())
+	})
+
+	Context("when creating a mirror policy", func() {
+		It("should mirror the kinds of the policy", func() {
+			ctx := context.Background()
+
+			namespacedName := types.NamespacedName{Name: "limitrange-test-1", Namespace: "default"}
+			labels := map[string]string{
+				controllers.AppLabel:         "knative-edge",
+				controllers.EnvironmentLabel: "testA",
+			}
+
+			limitRange := &corev1.LimitRange{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      namespacedName.Name,
+					Namespace: namespacedName.Namespace,
+					Labels:    labels,
+				},
+				Spec: corev1.LimitRangeSpec{
+					Limits: []corev1.LimitRangeItem{{
+						Type:    corev1.LimitTypeContainer,
+						Default: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+					}},
+				},
+			}
+
+			Expect(remoteClusterClient.Create(ctx, limitRange)).Should(Succeed())
+			DeferCleanup(func() {
+				Expect(remoteClusterClient.Delete(ctx, limitRange)).Should(Succeed())
+			})
+
+			mirroredLimitRange := &corev1.LimitRange{}
+
+			Consistently(func() error {
+				return edgeClusterClient.Get(ctx, namespacedName, mirroredLimitRange)
+			}, duration, interval).ShouldNot(Succeed())
+
+			By("creating a mirror policy")
+			policy := &edgev1alpha1.MirrorPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "mirrorpolicy-test-1", Labels: labels},
+				Spec: edgev1alpha1.MirrorPolicySpec{Resources: []edgev1alpha1.MirrorPolicyResource{
+					{Version: "v1", Kind: "LimitRange"},
+				}},
+			}
+
+			Expect(remoteClusterClient.Create(ctx, policy)).Should(Succeed())
+
+			Eventually(func(g Gomega) {
+				g.Expect(edgeClusterClient.Get(ctx, namespacedName, mirroredLimitRange)).Should(Succeed())
+				g.Expect(IsManagedObject(mirroredLimitRange)).To(BeTrue())
+				g.Expect(mirroredLimitRange.Spec.Limits[0].Default.Cpu().String()).To(Equal("500m"))
+			}, timeout, interval).Should(Succeed())
+
+			By("deleting the mirror policy")
+			Expect(remoteClusterClient.Delete(ctx, policy)).Should(Succeed())
+
+			// the deletion of the policy has to be seen before the next change
+			time.Sleep(duration)
+
+			limitRange.Spec.Limits[0].Default[corev1.ResourceCPU] = resource.MustParse("1")
+			Expect(remoteClusterClient.Update(ctx, limitRange)).Should(Succeed())
+
+			Consistently(func(g Gomega) {
+				g.Expect(edgeClusterClient.Get(ctx, namespacedName, mirroredLimitRange)).Should(Succeed())
+				g.Expect(mirroredLimitRange.Spec.Limits[0].Default.Cpu().String()).To(Equal("500m"))
+			}, duration, interval).Should(Succeed())
+		})
+	})
+})
@@ -0,0 +1,283 @@
+package edge
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"edge.jevv.dev/pkg/controllers"
+)
+
+// PrimaryUpstream is the name of the remote cluster of the kubeconfig at ConfigPath, which has the
+// EdgeCluster.
+const PrimaryUpstream = "primary"
+
+// Upstream is one of the remote clusters the edge cluster mirrors from.
+type Upstream struct {
+	Name     string
+	Priority int
+
+	// URL the edge proxies offload to for the services of this upstream, which is only mirrored
+	// from when empty
+	URL string
+
+	Cluster cluster.Cluster
+}
+
+// ParseUpstreams parses comma separated name=priority=url upstreams. The priority is required and
+// can be negative, to read from the upstream after the primary one, which has priority 0. The url
+// is optional.
+func ParseUpstreams(value string) ([]Upstream, error) {
+	var upstreams []Upstream
+	names := map[string]bool{PrimaryUpstream: true}
+
+	for _, entry := range strings.Split(value, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+
+		name, rest, found := strings.Cut(strings.TrimSpace(entry), "=")
+
+		if !found || name == "" {
+			return nil, fmt.Errorf("invalid upstream %q, expected name=priority=url", entry)
+		}
+
+		if names[name] {
+			return nil, fmt.Errorf("duplicate upstream %q", name)
+		}
+
+		names[name] = true
+
+		value, url, _ := strings.Cut(rest, "=")
+		priority, err := strconv.Atoi(value)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid priority of upstream %q: %w", name, err)
+		}
+
+		upstreams = append(upstreams, Upstream{Name: name, Priority: priority, URL: url})
+	}
+
+	return upstreams, nil
+}
+
+// MultiCluster is a remote cluster made of several upstreams, e.g. a regional hub and a global
+// cloud. Reads go through the upstreams in the order of their priorities, so when an object exists
+// in more than one of them, the one of the upstream with the highest priority wins. The objects
+// read are annotated with the name of their upstream.
+//
+// Everything else, like writes, the cache and the config, goes to the primary upstream.
+type MultiCluster struct {
+	cluster.Cluster
+
+	Upstreams []Upstream
+
+	client client.Client
+}
+
+// NewMultiCluster sorts the upstreams by priority, the primary one first among equal priorities.
+func NewMultiCluster(primary Upstream, upstreams ...Upstream) *MultiCluster {
+	primary.Name = PrimaryUpstream
+	upstreams = append([]Upstream{primary}, upstreams...)
+
+	sort.SliceStable(upstreams, func(i, j int) bool {
+		return upstreams[i].Priority > upstreams[j].Priority
+	})
+
+	return &MultiCluster{
+		Cluster:   primary.Cluster,
+		Upstreams: upstreams,
+		client:    &multiClient{Client: primary.Cluster.GetClient(), upstreams: upstreams},
+	}
+}
+
+func (c *MultiCluster) GetClient() client.Client {
+	return c.client
+}
+
+// Upstream returns the upstream with the given name, or nil if there isn't any.
+func (c *MultiCluster) Upstream(name string) *Upstream {
+	for i := range c.Upstreams {
+		if c.Upstreams[i].Name == name {
+			return &c.Upstreams[i]
+		}
+	}
+
+	return nil
+}
+
+// upstreamCluster returns the cluster the remote object was read from.
+func upstreamCluster(remoteCluster cluster.Cluster, obj client.Object) cluster.Cluster {
+	if multi, ok := remoteCluster.(*MultiCluster); ok {
+		if upstream := multi.Upstream(obj.GetAnnotations()[controllers.UpstreamAnnotation]); upstream != nil {
+			return upstream.Cluster
+		}
+	}
+
+	return remoteCluster
+}
+
+// AllUpstreams returns the upstreams of the remote cluster, which is the primary one alone unless
+// it's a MultiCluster.
+func AllUpstreams(remoteCluster cluster.Cluster) []Upstream {
+	if multi, ok := remoteCluster.(*MultiCluster); ok {
+		return multi.Upstreams
+	}
+
+	return []Upstream{{Name: PrimaryUpstream, Cluster: remoteCluster}}
+}
+
+// upstreamClusters returns the clusters of all upstreams.
+func upstreamClusters(remoteCluster cluster.Cluster) []cluster.Cluster {
+	upstreams := AllUpstreams(remoteCluster)
+	clusters := make([]cluster.Cluster, 0, len(upstreams))
+
+	for _, upstream := range upstreams {
+		clusters = append(clusters, upstream.Cluster)
+	}
+
+	return clusters
+}
+
+type multiClient struct {
+	client.Client
+
+	upstreams []Upstream
+}
+
+// isMissing tells if the object isn't in the upstream, or its kind isn't installed there.
+func isMissing(err error) bool {
+	return apierrors.IsNotFound(err) || meta.IsNoMatchError(err)
+}
+
+func (c *multiClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	var lastErr error
+
+	for _, upstream := range c.upstreams {
+		err := upstream.Cluster.GetClient().Get(ctx, key, obj, opts...)
+
+		if isMissing(err) {
+			lastErr = err
+			continue
+		}
+
+		// a lower priority upstream can't be used while it's unknown whether this one has the object
+		if err != nil {
+			return fmt.Errorf("couldn't get %s from upstream %s: %w", key.String(), upstream.Name, err)
+		}
+
+		setAnnotation(obj, controllers.UpstreamAnnotation, upstream.Name, true)
+
+		return nil
+	}
+
+	return lastErr
+}
+
+func (c *multiClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	var items []runtime.Object
+	var lastErr error
+
+	listed := false
+	seen := make(map[types.NamespacedName]bool)
+
+	for _, upstream := range c.upstreams {
+		upstreamList := newEmptyList(list)
+
+		if err := upstream.Cluster.GetClient().List(ctx, upstreamList, opts...); err != nil {
+			if meta.IsNoMatchError(err) {
+				lastErr = err
+				continue
+			}
+
+			return fmt.Errorf("couldn't list from upstream %s: %w", upstream.Name, err)
+		}
+
+		listed = true
+
+		objects, err := meta.ExtractList(upstreamList)
+
+		if err != nil {
+			return err
+		}
+
+		for _, object := range objects {
+			obj, ok := object.(client.Object)
+
+			if !ok {
+				continue
+			}
+
+			key := client.ObjectKeyFromObject(obj)
+
+			if seen[key] {
+				continue
+			}
+
+			seen[key] = true
+			setAnnotation(obj, controllers.UpstreamAnnotation, upstream.Name, true)
+
+			items = append(items, obj)
+		}
+	}
+
+	if !listed {
+		return lastErr
+	}
+
+	return meta.SetList(list, items)
+}
+
+func newEmptyList(list client.ObjectList) client.ObjectList {
+	if _, ok := list.(*unstructured.UnstructuredList); ok {
+		empty := &unstructured.UnstructuredList{}
+		empty.SetGroupVersionKind(list.GetObjectKind().GroupVersionKind())
+
+		return empty
+	}
+
+	return reflect.New(reflect.TypeOf(list).Elem()).Interface().(client.ObjectList)
+}
+
+// multiSource watches the objects of a kind in every upstream.
+type multiSource struct {
+	sources []source.Source
+}
+
+func (s *multiSource) Start(ctx context.Context, handler handler.EventHandler, queue workqueue.RateLimitingInterface, prct ...predicate.Predicate) error {
+	for _, src := range s.sources {
+		if err := src.Start(ctx, handler, queue, prct...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *multiSource) WaitForSync(ctx context.Context) error {
+	for _, src := range s.sources {
+		if syncing, ok := src.(source.SyncingSource); ok {
+			if err := syncing.WaitForSync(ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,217 @@
+package edge
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"edge.jevv.dev/pkg/controllers"
+)
+
+// failingClient fails every read with err.
+type failingClient struct {
+	client.Client
+	err error
+}
+
+func (c *failingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	return c.err
+}
+
+func (c *failingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return c.err
+}
+
+var _ = Describe("multi cluster", func() {
+	DescribeTable("parsing upstreams",
+		func(value string, expected []Upstream, message string) {
+			upstreams, err := ParseUpstreams(value)
+
+			if message != "" {
+				Expect(err).To(MatchError(ContainSubstring(message)))
+				return
+			}
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(upstreams).To(Equal(expected))
+		},
+		Entry("empty", "", nil, ""),
+		Entry("without url", "hub=10", []Upstream{{Name: "hub", Priority: 10}}, ""),
+		Entry("with url", "hub=10=hub.example.com", []Upstream{{Name: "hub", Priority: 10, URL: "hub.example.com"}}, ""),
+		Entry("with empty url", "hub=10=", []Upstream{{Name: "hub", Priority: 10}}, ""),
+		Entry("negative priority", "fallback=-1", []Upstream{{Name: "fallback", Priority: -1}}, ""),
+		Entry("keeps the order", " hub=10=hub.example.com , ,cloud=-5", []Upstream{
+			{Name: "hub", Priority: 10, URL: "hub.example.com"},
+			{Name: "cloud", Priority: -5},
+		}, ""),
+		Entry("missing priority", "hub", nil, "invalid upstream"),
+		Entry("empty priority", "hub=", nil, "invalid priority of upstream \"hub\""),
+		Entry("empty priority with url", "hub==hub.example.com", nil, "invalid priority of upstream \"hub\""),
+		Entry("invalid priority", "hub=high", nil, "invalid priority of upstream \"hub\""),
+		Entry("missing name", "=10", nil, "invalid upstream"),
+		Entry("duplicate name", "hub=10,hub=5", nil, "duplicate upstream \"hub\""),
+		Entry("name of the primary upstream", "primary=10", nil, "duplicate upstream \"primary\""),
+	)
+
+	Describe("reading from upstreams", func() {
+		// the upstreams of the entries are built with the tree
+		scheme := runtime.NewScheme()
+		utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+
+		newConfigMap := func(name, value string) *corev1.ConfigMap {
+			return &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+				Data:       map[string]string{"from": value},
+			}
+		}
+
+		newUpstream := func(name string, priority int, objects ...client.Object) Upstream {
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+			return Upstream{Name: name, Priority: priority, Cluster: &stubCluster{client: c}}
+		}
+
+		failingUpstream := func(name string, priority int, err error) Upstream {
+			return Upstream{Name: name, Priority: priority, Cluster: &stubCluster{client: &failingClient{err: err}}}
+		}
+
+		noMatch := &meta.NoKindMatchError{GroupKind: corev1.SchemeGroupVersion.WithKind("ConfigMap").GroupKind()}
+		notFound := apierrors.NewNotFound(corev1.Resource("configmaps"), "shared")
+
+		It("sorts the upstreams by priority, the primary one first among equal priorities", func() {
+			multi := NewMultiCluster(newUpstream("", 0), newUpstream("cloud", -1), newUpstream("edge", 0), newUpstream("hub", 10))
+
+			var names []string
+
+			for _, upstream := range multi.Upstreams {
+				names = append(names, upstream.Name)
+			}
+
+			Expect(names).To(Equal([]string{"hub", PrimaryUpstream, "edge", "cloud"}))
+			Expect(multi.Upstream("edge").Name).To(Equal("edge"))
+			Expect(multi.Upstream("missing")).To(BeNil())
+		})
+
+		DescribeTable("getting objects",
+			func(upstreams []Upstream, expected string, message string) {
+				multi := NewMultiCluster(upstreams[0], upstreams[1:]...)
+
+				configMap := &corev1.ConfigMap{}
+				err := multi.GetClient().Get(context.Background(), client.ObjectKey{Name: "shared", Namespace: "default"}, configMap)
+
+				if message != "" {
+					Expect(err).To(MatchError(ContainSubstring(message)))
+					return
+				}
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(configMap.Data["from"]).To(Equal(expected))
+				Expect(configMap.Annotations).To(HaveKeyWithValue(controllers.UpstreamAnnotation, expected))
+			},
+			Entry("from the highest priority", []Upstream{
+				newUpstream("", 0, newConfigMap("shared", PrimaryUpstream)),
+				newUpstream("hub", 10, newConfigMap("shared", "hub")),
+			}, "hub", ""),
+			Entry("from the primary upstream among equal priorities", []Upstream{
+				newUpstream("", 0, newConfigMap("shared", PrimaryUpstream)),
+				newUpstream("edge", 0, newConfigMap("shared", "edge")),
+			}, PrimaryUpstream, ""),
+			Entry("falling through missing objects", []Upstream{
+				newUpstream("", 0, newConfigMap("shared", PrimaryUpstream)),
+				newUpstream("hub", 10),
+			}, PrimaryUpstream, ""),
+			Entry("falling through missing kinds", []Upstream{
+				newUpstream("", 0, newConfigMap("shared", PrimaryUpstream)),
+				failingUpstream("hub", 10, noMatch),
+			}, PrimaryUpstream, ""),
+			Entry("falling through not found errors", []Upstream{
+				failingUpstream("", 10, notFound),
+				newUpstream("cloud", -1, newConfigMap("shared", "cloud")),
+			}, "cloud", ""),
+			Entry("missing everywhere", []Upstream{
+				newUpstream("", 0),
+				failingUpstream("hub", 10, noMatch),
+			}, "", "not found"),
+			Entry("missing kind everywhere", []Upstream{
+				failingUpstream("", 0, noMatch),
+				failingUpstream("hub", 10, noMatch),
+			}, "", "no matches for kind"),
+			Entry("failing upstream", []Upstream{
+				newUpstream("", 0, newConfigMap("shared", PrimaryUpstream)),
+				failingUpstream("hub", 10, fmt.Errorf("connection refused")),
+			}, "", "couldn't get default/shared from upstream hub: connection refused"),
+		)
+
+		DescribeTable("listing objects",
+			func(upstreams []Upstream, expected map[string]string, message string) {
+				multi := NewMultiCluster(upstreams[0], upstreams[1:]...)
+
+				list := &corev1.ConfigMapList{}
+				err := multi.GetClient().List(context.Background(), list)
+
+				if message != "" {
+					Expect(err).To(MatchError(ContainSubstring(message)))
+					return
+				}
+
+				Expect(err).NotTo(HaveOccurred())
+
+				listed := make(map[string]string)
+
+				for _, item := range list.Items {
+					Expect(item.Annotations).To(HaveKeyWithValue(controllers.UpstreamAnnotation, item.Data["from"]))
+					listed[item.Name] = item.Data["from"]
+				}
+
+				Expect(list.Items).To(HaveLen(len(listed)))
+				Expect(listed).To(Equal(expected))
+			},
+			Entry("de-duplicating by priority", []Upstream{
+				newUpstream("", 0, newConfigMap("shared", PrimaryUpstream), newConfigMap("primary-only", PrimaryUpstream)),
+				newUpstream("hub", 10, newConfigMap("shared", "hub"), newConfigMap("hub-only", "hub")),
+				newUpstream("cloud", -1, newConfigMap("shared", "cloud"), newConfigMap("cloud-only", "cloud")),
+			}, map[string]string{
+				"shared":       "hub",
+				"hub-only":     "hub",
+				"primary-only": PrimaryUpstream,
+				"cloud-only":   "cloud",
+			}, ""),
+			Entry("skipping missing kinds", []Upstream{
+				newUpstream("", 0, newConfigMap("shared", PrimaryUpstream)),
+				failingUpstream("hub", 10, noMatch),
+			}, map[string]string{"shared": PrimaryUpstream}, ""),
+			Entry("missing kind everywhere", []Upstream{
+				failingUpstream("", 0, noMatch),
+				failingUpstream("hub", 10, noMatch),
+			}, nil, "no matches for kind"),
+			Entry("failing upstream", []Upstream{
+				newUpstream("", 0, newConfigMap("shared", PrimaryUpstream)),
+				failingUpstream("hub", 10, fmt.Errorf("connection refused")),
+			}, nil, "couldn't list from upstream hub: connection refused"),
+		)
+
+		It("finds the cluster of mirrored objects", func() {
+			primary, hub := newUpstream("", 0), newUpstream("hub", 10)
+			multi := NewMultiCluster(primary, hub)
+
+			fromHub := newConfigMap("shared", "hub")
+			fromHub.Annotations = map[string]string{controllers.UpstreamAnnotation: "hub"}
+
+			Expect(upstreamCluster(multi, fromHub)).To(BeIdenticalTo(hub.Cluster))
+			Expect(upstreamCluster(multi, newConfigMap("shared", PrimaryUpstream))).To(BeIdenticalTo(multi))
+			Expect(upstreamCluster(primary.Cluster, fromHub)).To(BeIdenticalTo(primary.Cluster))
+		})
+	})
+})
@@ -0,0 +1,108 @@
+package edge
+
+import (
+	"context"
+
+	"k8s.io/client-go/util/workqueue"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// OfflineCluster is a remote cluster which reads from the snapshot of its objects until its cache
+// has synced them, so the edge controllers keep working while the remote cluster is unreachable.
+// Writes still go to the remote cluster.
+type OfflineCluster struct {
+	cluster.Cluster
+
+	Snapshot *RemoteSnapshot
+
+	client client.Client
+}
+
+func NewOfflineCluster(remoteCluster cluster.Cluster, snapshot *RemoteSnapshot) *OfflineCluster {
+	return &OfflineCluster{
+		Cluster:  remoteCluster,
+		Snapshot: snapshot,
+		client:   &offlineClient{Client: remoteCluster.GetClient(), snapshot: snapshot},
+	}
+}
+
+func (c *OfflineCluster) GetClient() client.Client {
+	return c.client
+}
+
+// Runnable starts the remote cluster without the manager waiting for its cache to sync first,
+// which it does for clusters added directly.
+func (c *OfflineCluster) Runnable() manager.Runnable {
+	return &offlineClusterRunnable{cluster: c.Cluster}
+}
+
+type offlineClusterRunnable struct {
+	cluster cluster.Cluster
+}
+
+func (r *offlineClusterRunnable) Start(ctx context.Context) error {
+	return r.cluster.Start(ctx)
+}
+
+func (r *offlineClusterRunnable) NeedLeaderElection() bool {
+	return false
+}
+
+type offlineClient struct {
+	client.Client
+
+	snapshot *RemoteSnapshot
+}
+
+func (c *offlineClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if c.snapshot.Serves(obj) {
+		return c.snapshot.Get(ctx, key, obj, opts...)
+	}
+
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+func (c *offlineClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if c.snapshot.Serves(list) {
+		return c.snapshot.List(ctx, list, opts...)
+	}
+
+	return c.Client.List(ctx, list, opts...)
+}
+
+// offlineSource watches the remote objects without making the controller wait for the remote
+// cache to sync, which doesn't happen while the remote cluster is unreachable.
+type offlineSource struct {
+	kind source.SyncingSource
+}
+
+func (s *offlineSource) Start(ctx context.Context, handler handler.EventHandler, queue workqueue.RateLimitingInterface, prct ...predicate.Predicate) error {
+	return s.kind.Start(ctx, handler, queue, prct...)
+}
+
+// newRemoteSource watches the objects of the kind in the remote cluster.
+func newRemoteSource(obj client.Object, remoteCluster cluster.Cluster) source.Source {
+	if multi, ok := remoteCluster.(*MultiCluster); ok {
+		sources := make([]source.Source, 0, len(multi.Upstreams))
+
+		for _, upstream := range multi.Upstreams {
+			sources = append(sources, newRemoteSource(obj.DeepCopyObject().(client.Object), upstream.Cluster))
+		}
+
+		return &multiSource{sources: sources}
+	}
+
+	kind := source.NewKindWithCache(obj, remoteCluster.GetCache())
+
+	if _, ok := remoteCluster.(*OfflineCluster); ok {
+		return &offlineSource{kind: kind}
+	}
+
+	return kind
+}
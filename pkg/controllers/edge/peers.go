@@ -0,0 +1,226 @@
+package edge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	edgev1alpha1 "edge.jevv.dev/pkg/apis/edge/v1alpha1"
+	"edge.jevv.dev/pkg/controllers"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+)
+
+const (
+	// peers which didn't report for three heartbeats are skipped, even if the cloud didn't mark
+	// them as unreachable yet
+	peerStaleAfter = 90 * time.Second
+
+	DefaultPeerMaxUtilization = 70
+)
+
+func (r *KServiceReconciler) peerOffloadEnabled() bool {
+	return r.PeerOffload && r.ClusterName != ""
+}
+
+func peersConfigMapName(configurationName types.NamespacedName) types.NamespacedName {
+	return types.NamespacedName{Name: configurationName.Name + "-peers", Namespace: configurationName.Namespace}
+}
+
+// reconcilePeerEndpoints writes the peers which can take the traffic of the service to the
+// ConfigMap the proxy reads them from. The service is reconciled periodically, so the proxy
+// follows the utilization of the peers without being rolled out again.
+func (r *KServiceReconciler) reconcilePeerEndpoints(ctx context.Context, service *servingv1.Service, configurationName types.NamespacedName) error {
+	name := peersConfigMapName(configurationName)
+
+	data, err := json.Marshal(r.getPeerEndpoints(ctx, service))
+
+	if err != nil {
+		return fmt.Errorf("couldn't encode peer endpoints: %w", err)
+	}
+
+	exists := true
+	var configMap corev1.ConfigMap
+
+	// not managed, so it isn't in the cache
+	if err := r.Reader.Get(ctx, name, &configMap); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("couldn't retrieve peer endpoints: %w", err)
+		}
+
+		exists = false
+	}
+
+	if exists && configMap.Data[ProxyPeersKey] == string(data) {
+		return nil
+	}
+
+	configMap.Name = name.Name
+	configMap.Namespace = name.Namespace
+	configMap.Data = map[string]string{ProxyPeersKey: string(data)}
+	configMap.Labels = map[string]string{
+		controllers.CreatedByLabel: "knative-edge",
+		controllers.ManagedByLabel: "knative-edge",
+	}
+
+	if !exists {
+		if err := r.Create(ctx, &configMap); err != nil && !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("couldn't create peer endpoints: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := r.Update(ctx, &configMap); err != nil {
+		return fmt.Errorf("couldn't update peer endpoints: %w", err)
+	}
+
+	return nil
+}
+
+func (r *KServiceReconciler) deletePeerEndpoints(ctx context.Context, configurationName types.NamespacedName) error {
+	configMap := corev1.ConfigMap{}
+	configMap.Name = peersConfigMapName(configurationName).Name
+	configMap.Namespace = configurationName.Namespace
+
+	if err := r.Delete(ctx, &configMap); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("couldn't delete peer endpoints: %w", err)
+	}
+
+	return nil
+}
+
+// getPeerEndpoints returns the other edge clusters of the region which have spare capacity and the
+// service ready, sorted by name.
+//
+// Looking up the peers is best effort, the service is still offloaded to the remote clusters when
+// it fails.
+func (r *KServiceReconciler) getPeerEndpoints(ctx context.Context, service *servingv1.Service) []RemoteEndpoint {
+	debug := r.Log.V(controllers.DebugLevel)
+
+	if !r.peerOffloadEnabled() {
+		return nil
+	}
+
+	peers, err := r.getPeers(ctx)
+
+	if err != nil {
+		debug.Error(err, "couldn't list peer edge clusters")
+		return nil
+	}
+
+	if len(peers) == 0 {
+		return nil
+	}
+
+	var statuses edgev1alpha1.EdgeServiceStatusList
+
+	// statuses are reported to the upstream the service is mirrored from, and aren't cached
+	reader := upstreamCluster(r.RemoteCluster, service).GetAPIReader()
+
+	if err := reader.List(ctx, &statuses, client.InNamespace(service.Namespace), client.MatchingLabels{controllers.KServiceLabel: service.Name}); err != nil {
+		debug.Error(err, "couldn't list service statuses of peer edge clusters", "service", client.ObjectKeyFromObject(service))
+		return nil
+	}
+
+	var endpoints []RemoteEndpoint
+
+	for _, status := range statuses.Items {
+		peer, exists := peers[status.Spec.ClusterName]
+
+		if !exists || status.Status.Ready != metav1.ConditionTrue || status.Status.URL == "" {
+			continue
+		}
+
+		serviceUrl, err := url.Parse(status.Status.URL)
+
+		if err != nil || serviceUrl.Host == "" {
+			continue
+		}
+
+		endpoints = append(endpoints, RemoteEndpoint{
+			Name: peer.Name,
+			URL:  peer.Status.PeerURL,
+			Host: serviceUrl.Host,
+			Peer: true,
+		})
+	}
+
+	sort.Slice(endpoints, func(i, j int) bool {
+		return endpoints[i].Name < endpoints[j].Name
+	})
+
+	return endpoints
+}
+
+// getPeers returns the edge clusters of the same region which can take traffic, by name.
+func (r *KServiceReconciler) getPeers(ctx context.Context) (map[string]*edgev1alpha1.EdgeCluster, error) {
+	var edgeClusters edgev1alpha1.EdgeClusterList
+
+	// EdgeClusters aren't labeled with an environment, so they're not in the remote cache
+	if err := r.RemoteCluster.GetAPIReader().List(ctx, &edgeClusters); err != nil {
+		return nil, err
+	}
+
+	var region string
+
+	for i := range edgeClusters.Items {
+		if edgeCluster := &edgeClusters.Items[i]; edgeCluster.Name == r.ClusterName && edgeCluster.Spec.Region != nil {
+			region = *edgeCluster.Spec.Region
+		}
+	}
+
+	if region == "" {
+		return nil, nil
+	}
+
+	peers := make(map[string]*edgev1alpha1.EdgeCluster)
+
+	for i := range edgeClusters.Items {
+		peer := &edgeClusters.Items[i]
+
+		if peer.Name == r.ClusterName || peer.Spec.Region == nil || *peer.Spec.Region != region {
+			continue
+		}
+
+		if r.canOffloadTo(peer) {
+			peers[peer.Name] = peer
+		}
+	}
+
+	return peers, nil
+}
+
+// canOffloadTo tells whether the peer is reporting and has spare capacity. Peers which don't report
+// their utilization aren't offloaded to.
+func (r *KServiceReconciler) canOffloadTo(peer *edgev1alpha1.EdgeCluster) bool {
+	status := &peer.Status
+
+	if status.Phase != edgev1alpha1.EdgeClusterPhaseReady || status.PeerURL == "" || status.Utilization == nil {
+		return false
+	}
+
+	lastReportedAt, err := time.Parse(time.RFC3339, status.LastReportedAt)
+
+	if err != nil || time.Since(lastReportedAt) > peerStaleAfter {
+		return false
+	}
+
+	maxUtilization := r.PeerMaxUtilization
+
+	if maxUtilization <= 0 {
+		maxUtilization = DefaultPeerMaxUtilization
+	}
+
+	return status.Utilization.Cpu < maxUtilization && status.Utilization.Memory < maxUtilization
+}
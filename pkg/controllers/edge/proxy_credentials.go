@@ -0,0 +1,68 @@
+package edge
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"edge.jevv.dev/pkg/controllers"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// reconcileProxyCredentials copies the credentials of the edge proxies to the namespace of a
+// service, since pods can only mount secrets from their own namespace. The copies aren't labeled
+// as managed, otherwise they would be deleted for not existing in the remote cluster.
+func (r *KServiceReconciler) reconcileProxyCredentials(ctx context.Context, namespace string) error {
+	if r.ProxyCredentials == "" || namespace == controllers.SystemNamespace {
+		return nil
+	}
+
+	var src corev1.Secret
+
+	// neither secret is managed, so they aren't in the cache
+	if err := r.Reader.Get(ctx, types.NamespacedName{Name: r.ProxyCredentials, Namespace: controllers.SystemNamespace}, &src); err != nil {
+		return fmt.Errorf("couldn't retrieve edge proxy credentials: %w", err)
+	}
+
+	exists := true
+	var dst corev1.Secret
+
+	if err := r.Reader.Get(ctx, types.NamespacedName{Name: controllers.ProxyCredentialsSecretName, Namespace: namespace}, &dst); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("couldn't retrieve edge proxy credentials in %s: %w", namespace, err)
+		}
+
+		exists = false
+	}
+
+	if exists && reflect.DeepEqual(dst.Data, src.Data) {
+		return nil
+	}
+
+	dst.Name = controllers.ProxyCredentialsSecretName
+	dst.Namespace = namespace
+	dst.Type = corev1.SecretTypeOpaque
+	dst.Data = src.Data
+	dst.Labels = map[string]string{
+		controllers.CreatedByLabel: "knative-edge",
+		controllers.ManagedByLabel: "knative-edge",
+	}
+
+	if !exists {
+		if err := r.Create(ctx, &dst); err != nil && !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("couldn't create edge proxy credentials in %s: %w", namespace, err)
+		}
+
+		return nil
+	}
+
+	if err := r.Update(ctx, &dst); err != nil {
+		return fmt.Errorf("couldn't update edge proxy credentials in %s: %w", namespace, err)
+	}
+
+	return nil
+}
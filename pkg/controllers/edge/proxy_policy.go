@@ -0,0 +1,174 @@
+package edge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	edgev1alpha1 "edge.jevv.dev/pkg/apis/edge/v1alpha1"
+	"edge.jevv.dev/pkg/controllers"
+
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+)
+
+func getProxyPolicyName(service *servingv1.Service) (string, bool) {
+	if name, exists := service.Annotations[controllers.ProxyPolicyAnnotation]; exists && name != "" {
+		return name, true
+	}
+
+	return controllers.DefaultProxyPolicyName, false
+}
+
+// getProxyPolicy returns the EdgeProxyPolicy of the service from the remote cluster, or nil if
+// it doesn't use one. A policy selected by the service must exist, otherwise the proxy would
+// offload requests the policy is supposed to reject.
+func (r *KServiceReconciler) getProxyPolicy(ctx context.Context, service *servingv1.Service) (*edgev1alpha1.EdgeProxyPolicy, error) {
+	name, selected := getProxyPolicyName(service)
+	policy := &edgev1alpha1.EdgeProxyPolicy{}
+
+	if err := r.RemoteCluster.GetClient().Get(ctx, types.NamespacedName{Name: name, Namespace: service.Namespace}, policy); err != nil {
+		if apierrors.IsNotFound(err) && !selected {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("couldn't get edge proxy policy %s: %w", name, err)
+	}
+
+	if err := validateProxyPolicy(policy); err != nil {
+		return nil, fmt.Errorf("invalid edge proxy policy %s: %w", name, err)
+	}
+
+	return policy, nil
+}
+
+func validateHeaderRewrite(field string, rewrite *edgev1alpha1.EdgeProxyHeaderRewrite, errs *[]string) {
+	if rewrite == nil {
+		return
+	}
+
+	for name, value := range rewrite.Set {
+		if !httpguts.ValidHeaderFieldName(name) || !httpguts.ValidHeaderFieldValue(value) {
+			*errs = append(*errs, fmt.Sprintf("%s.set has invalid header %q", field, name))
+		}
+	}
+
+	for _, name := range rewrite.Remove {
+		if !httpguts.ValidHeaderFieldName(name) {
+			*errs = append(*errs, fmt.Sprintf("%s.remove has invalid header %q", field, name))
+		}
+	}
+}
+
+func validateProxyPolicy(policy *edgev1alpha1.EdgeProxyPolicy) error {
+	var errs []string
+
+	spec := policy.Spec
+
+	if spec.Timeout != nil && spec.Timeout.Duration <= 0 {
+		errs = append(errs, "timeout must be a positive duration")
+	}
+
+	if spec.IdleTimeout != nil && spec.IdleTimeout.Duration <= 0 {
+		errs = append(errs, "idleTimeout must be a positive duration")
+	}
+
+	validateHeaderRewrite("requestHeaders", spec.RequestHeaders, &errs)
+	validateHeaderRewrite("responseHeaders", spec.ResponseHeaders, &errs)
+
+	for _, method := range spec.AllowedMethods {
+		if !httpguts.ValidHeaderFieldName(method) {
+			errs = append(errs, fmt.Sprintf("allowedMethods has invalid method %q", method))
+		}
+	}
+
+	for _, path := range spec.AllowedPaths {
+		if !strings.HasPrefix(path, "/") {
+			errs = append(errs, fmt.Sprintf("allowedPaths has invalid path %q", path))
+		}
+	}
+
+	if spec.RateLimit != nil && (spec.RateLimit.RequestsPerSecond <= 0 || spec.RateLimit.Burst < 0) {
+		errs = append(errs, "rateLimit must be positive")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// ApplyPolicy renders the policy into the settings. Timeouts set through annotations of the
+// service take precedence over the ones of the policy.
+func (s *ProxySettings) ApplyPolicy(policy *edgev1alpha1.EdgeProxyPolicy) error {
+	if policy == nil {
+		return nil
+	}
+
+	spec := policy.Spec.DeepCopy()
+
+	if s.Timeout == "" && spec.Timeout != nil {
+		s.Timeout = spec.Timeout.Duration.String()
+	}
+
+	if spec.IdleTimeout != nil {
+		s.IdleTimeout = spec.IdleTimeout.Duration.String()
+	}
+
+	// the proxy reads the timeouts from their own env
+	spec.Timeout = nil
+	spec.IdleTimeout = nil
+
+	rendered, err := json.Marshal(spec)
+
+	if err != nil {
+		return fmt.Errorf("couldn't render edge proxy policy: %w", err)
+	}
+
+	s.PolicyName = policy.Name
+	s.PolicyGeneration = policy.Generation
+	s.Policy = string(rendered)
+
+	return nil
+}
+
+func (r *KServiceReconciler) findServicesFromProxyPolicy(obj client.Object) []reconcile.Request {
+	var ok bool
+	var policy *edgev1alpha1.EdgeProxyPolicy
+
+	if policy, ok = obj.(*edgev1alpha1.EdgeProxyPolicy); !ok {
+		return []reconcile.Request{}
+	}
+
+	services := &servingv1.ServiceList{}
+
+	if err := r.List(context.Background(), services, client.InNamespace(policy.Namespace)); err != nil {
+		r.Log.Error(err, "couldn't list services of edge proxy policy", "policy", client.ObjectKeyFromObject(policy))
+		return []reconcile.Request{}
+	}
+
+	requests := make([]reconcile.Request, 0)
+
+	for i := range services.Items {
+		service := &services.Items[i]
+
+		if name, _ := getProxyPolicyName(service); name != policy.Name {
+			continue
+		}
+
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: service.Name, Namespace: service.Namespace},
+		})
+	}
+
+	return requests
+}
@@ -0,0 +1,218 @@
+package edge
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	edgev1alpha1 "edge.jevv.dev/pkg/apis/edge/v1alpha1"
+	"edge.jevv.dev/pkg/controllers"
+
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+)
+
+func duration(d time.Duration) *metav1.Duration {
+	return &metav1.Duration{Duration: d}
+}
+
+var _ = Describe("proxy policy", func() {
+	DescribeTable("validating policies",
+		func(spec edgev1alpha1.EdgeProxyPolicySpec, errs ...string) {
+			err := validateProxyPolicy(&edgev1alpha1.EdgeProxyPolicy{Spec: spec})
+
+			if len(errs) == 0 {
+				Expect(err).NotTo(HaveOccurred())
+				return
+			}
+
+			Expect(err).To(HaveOccurred())
+
+			for _, message := range errs {
+				Expect(err.Error()).To(ContainSubstring(message))
+			}
+		},
+		Entry("empty", edgev1alpha1.EdgeProxyPolicySpec{}),
+		Entry("valid", edgev1alpha1.EdgeProxyPolicySpec{
+			Timeout:         duration(time.Minute),
+			IdleTimeout:     duration(time.Hour),
+			RequestHeaders:  &edgev1alpha1.EdgeProxyHeaderRewrite{Set: map[string]string{"X-Site": "edge-1"}, Remove: []string{"Cookie"}},
+			ResponseHeaders: &edgev1alpha1.EdgeProxyHeaderRewrite{Remove: []string{"Server"}},
+			AllowedMethods:  []string{"GET", "HEAD"},
+			AllowedPaths:    []string{"/", "/api/"},
+			RateLimit:       &edgev1alpha1.EdgeProxyRateLimit{RequestsPerSecond: 10},
+		}),
+		Entry("invalid timeouts", edgev1alpha1.EdgeProxyPolicySpec{
+			Timeout:     duration(0),
+			IdleTimeout: duration(-time.Second),
+		}, "timeout must be a positive duration", "idleTimeout must be a positive duration"),
+		Entry("invalid headers", edgev1alpha1.EdgeProxyPolicySpec{
+			RequestHeaders:  &edgev1alpha1.EdgeProxyHeaderRewrite{Set: map[string]string{"X-Site": "edge\n1"}},
+			ResponseHeaders: &edgev1alpha1.EdgeProxyHeaderRewrite{Remove: []string{"Bad Header"}},
+		}, `requestHeaders.set has invalid header "X-Site"`, `responseHeaders.remove has invalid header "Bad Header"`),
+		Entry("invalid methods and paths", edgev1alpha1.EdgeProxyPolicySpec{
+			AllowedMethods: []string{"GET", "NOT ALLOWED"},
+			AllowedPaths:   []string{"api"},
+		}, `allowedMethods has invalid method "NOT ALLOWED"`, `allowedPaths has invalid path "api"`),
+		Entry("rate limit without rate", edgev1alpha1.EdgeProxyPolicySpec{
+			RateLimit: &edgev1alpha1.EdgeProxyRateLimit{Burst: 10},
+		}, "rateLimit must be positive"),
+		Entry("negative burst", edgev1alpha1.EdgeProxyPolicySpec{
+			RateLimit: &edgev1alpha1.EdgeProxyRateLimit{RequestsPerSecond: 10, Burst: -1},
+		}, "rateLimit must be positive"),
+	)
+
+	DescribeTable("applying policies",
+		func(annotations map[string]string, policy *edgev1alpha1.EdgeProxyPolicy, expected ProxySettings) {
+			settings, err := GetProxySettings(annotations)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(settings.ApplyPolicy(policy)).To(Succeed())
+
+			expected.Concurrency, expected.MinScale, expected.MaxScale = DefaultProxyConcurrency, DefaultProxyMinScale, DefaultProxyMaxScale
+			Expect(settings).To(Equal(expected))
+		},
+		Entry("without policy", nil, nil, ProxySettings{}),
+		Entry("timeouts of the policy", nil, &edgev1alpha1.EdgeProxyPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "default", Generation: 3},
+			Spec: edgev1alpha1.EdgeProxyPolicySpec{
+				Timeout:        duration(time.Minute),
+				IdleTimeout:    duration(time.Hour),
+				AllowedMethods: []string{"GET"},
+			},
+		}, ProxySettings{
+			Timeout:          "1m0s",
+			IdleTimeout:      "1h0m0s",
+			Policy:           `{"allowedMethods":["GET"]}`,
+			PolicyName:       "default",
+			PolicyGeneration: 3,
+		}),
+		Entry("timeout of the annotation over the one of the policy", map[string]string{
+			controllers.ProxyTimeoutAnnotation: "30s",
+		}, &edgev1alpha1.EdgeProxyPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "strict", Generation: 1},
+			Spec: edgev1alpha1.EdgeProxyPolicySpec{
+				Timeout:   duration(time.Minute),
+				RateLimit: &edgev1alpha1.EdgeProxyRateLimit{RequestsPerSecond: 5, Burst: 10},
+			},
+		}, ProxySettings{
+			Timeout:          "30s",
+			Policy:           `{"rateLimit":{"requestsPerSecond":5,"burst":10}}`,
+			PolicyName:       "strict",
+			PolicyGeneration: 1,
+		}),
+		Entry("empty policy", nil, &edgev1alpha1.EdgeProxyPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		}, ProxySettings{
+			Policy:     `{}`,
+			PolicyName: "default",
+		}),
+	)
+
+	It("doesn't change the policy", func() {
+		policy := &edgev1alpha1.EdgeProxyPolicy{
+			Spec: edgev1alpha1.EdgeProxyPolicySpec{Timeout: duration(time.Minute), IdleTimeout: duration(time.Hour)},
+		}
+
+		settings := ProxySettings{}
+		Expect(settings.ApplyPolicy(policy)).To(Succeed())
+
+		Expect(policy.Spec.Timeout).To(Equal(duration(time.Minute)))
+		Expect(policy.Spec.IdleTimeout).To(Equal(duration(time.Hour)))
+	})
+
+	Describe("getting the policy of a service", func() {
+		var r *KServiceReconciler
+
+		newService := func(policy string) *servingv1.Service {
+			service := &servingv1.Service{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"}}
+
+			if policy != "" {
+				service.Annotations = map[string]string{controllers.ProxyPolicyAnnotation: policy}
+			}
+
+			return service
+		}
+
+		BeforeEach(func() {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(edgev1alpha1.AddToScheme(scheme)).To(Succeed())
+
+			remote := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				&edgev1alpha1.EdgeProxyPolicy{
+					ObjectMeta: metav1.ObjectMeta{Name: "strict", Namespace: "default"},
+					Spec:       edgev1alpha1.EdgeProxyPolicySpec{AllowedMethods: []string{"GET"}},
+				},
+				&edgev1alpha1.EdgeProxyPolicy{
+					ObjectMeta: metav1.ObjectMeta{Name: "broken", Namespace: "default"},
+					Spec:       edgev1alpha1.EdgeProxyPolicySpec{AllowedPaths: []string{"api"}},
+				},
+				&edgev1alpha1.EdgeProxyPolicy{
+					ObjectMeta: metav1.ObjectMeta{Name: controllers.DefaultProxyPolicyName, Namespace: "other"},
+				},
+			).Build()
+
+			r = &KServiceReconciler{RemoteCluster: &stubCluster{client: remote}}
+		})
+
+		It("gets the selected policy", func() {
+			policy, err := r.getProxyPolicy(context.Background(), newService("strict"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(policy.Spec.AllowedMethods).To(Equal([]string{"GET"}))
+		})
+
+		It("doesn't need a default policy", func() {
+			Expect(r.getProxyPolicy(context.Background(), newService(""))).To(BeNil())
+		})
+
+		It("gets the default policy of the namespace", func() {
+			service := newService("")
+			service.Namespace = "other"
+
+			policy, err := r.getProxyPolicy(context.Background(), service)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(policy.Name).To(Equal(controllers.DefaultProxyPolicyName))
+		})
+
+		It("fails without the selected policy", func() {
+			_, err := r.getProxyPolicy(context.Background(), newService("missing"))
+			Expect(err).To(MatchError(ContainSubstring("couldn't get edge proxy policy missing")))
+		})
+
+		It("fails with an invalid policy", func() {
+			_, err := r.getProxyPolicy(context.Background(), newService("broken"))
+			Expect(err).To(MatchError(ContainSubstring(`invalid edge proxy policy broken: allowedPaths has invalid path "api"`)))
+		})
+
+		It("finds the services of a policy", func() {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(servingv1.AddToScheme(scheme)).To(Succeed())
+
+			strict, defaulted, other := newService("strict"), newService(""), newService("strict")
+			defaulted.Name = "defaulted"
+			other.Namespace = "other"
+
+			r.Client = fake.NewClientBuilder().WithScheme(scheme).WithObjects(strict, defaulted, other).Build()
+
+			policy := &edgev1alpha1.EdgeProxyPolicy{ObjectMeta: metav1.ObjectMeta{Name: "strict", Namespace: "default"}}
+			Expect(r.findServicesFromProxyPolicy(policy)).To(ConsistOf(
+				HaveField("NamespacedName", client.ObjectKeyFromObject(strict)),
+			))
+
+			policy.Name = controllers.DefaultProxyPolicyName
+			Expect(r.findServicesFromProxyPolicy(policy)).To(ConsistOf(
+				HaveField("NamespacedName", client.ObjectKeyFromObject(defaulted)),
+			))
+		})
+	})
+})
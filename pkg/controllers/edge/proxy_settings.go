@@ -0,0 +1,258 @@
+package edge
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpguts"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"edge.jevv.dev/pkg/controllers"
+)
+
+// ProxySettings of the edge proxy of a service, read from the edge.jevv.dev/proxy-* annotations
+// of the service. Settings left empty use the defaults of the proxy.
+type ProxySettings struct {
+	Timeout     string
+	Concurrency int64
+	MinScale    int64
+	MaxScale    int64
+	Resources   corev1.ResourceRequirements
+
+	// extra headers sent to the remote service, as Name=value,Name=value
+	Headers string
+
+	RetryAttempts           string
+	RetryBackoff            string
+	CircuitBreakerThreshold string
+	CircuitBreakerTimeout   string
+
+	// how the proxy picks among several remote endpoints, and their weights by name
+	EndpointSelection string
+	EndpointWeights   map[string]int64
+
+	// rendered from the EdgeProxyPolicy of the service, see ApplyPolicy
+	IdleTimeout      string
+	Policy           string
+	PolicyName       string
+	PolicyGeneration int64
+}
+
+// Env of the edge proxy for these settings, only for the ones which are set.
+func (s ProxySettings) Env() []corev1.EnvVar {
+	env := make([]corev1.EnvVar, 0)
+
+	for _, item := range []struct{ name, value string }{
+		{"REMOTE_TIMEOUT", s.Timeout},
+		{"EXTRA_HEADERS", s.Headers},
+		{"RETRY_ATTEMPTS", s.RetryAttempts},
+		{"RETRY_BACKOFF", s.RetryBackoff},
+		{"CIRCUIT_BREAKER_THRESHOLD", s.CircuitBreakerThreshold},
+		{"CIRCUIT_BREAKER_TIMEOUT", s.CircuitBreakerTimeout},
+		{"ENDPOINT_SELECTION", s.EndpointSelection},
+		{"UPGRADE_IDLE_TIMEOUT", s.IdleTimeout},
+		{"PROXY_POLICY", s.Policy},
+		{"PROXY_POLICY_NAME", s.PolicyName},
+	} {
+		if item.value != "" {
+			env = append(env, corev1.EnvVar{Name: item.name, Value: item.value})
+		}
+	}
+
+	return env
+}
+
+func parseDurationAnnotation(annotations map[string]string, key string, errs *[]string) string {
+	value, exists := annotations[key]
+
+	if !exists {
+		return ""
+	}
+
+	if duration, err := time.ParseDuration(value); err != nil || duration <= 0 {
+		*errs = append(*errs, fmt.Sprintf("%s must be a positive duration", key))
+		return ""
+	}
+
+	return value
+}
+
+func parseIntAnnotation(annotations map[string]string, key string, defaultValue int64, errs *[]string) int64 {
+	value, exists := annotations[key]
+
+	if !exists {
+		return defaultValue
+	}
+
+	number, err := strconv.ParseInt(value, 10, 64)
+
+	if err != nil || number < 0 {
+		*errs = append(*errs, fmt.Sprintf("%s must be a non-negative integer", key))
+		return defaultValue
+	}
+
+	return number
+}
+
+func parseOptionalIntAnnotation(annotations map[string]string, key string, errs *[]string) string {
+	if _, exists := annotations[key]; !exists {
+		return ""
+	}
+
+	if number := parseIntAnnotation(annotations, key, -1, errs); number >= 0 {
+		return fmt.Sprint(number)
+	}
+
+	return ""
+}
+
+func parseQuantityAnnotation(annotations map[string]string, key string, list corev1.ResourceList, name corev1.ResourceName, errs *[]string) {
+	value, exists := annotations[key]
+
+	if !exists {
+		return
+	}
+
+	quantity, err := resource.ParseQuantity(value)
+
+	if err != nil {
+		*errs = append(*errs, fmt.Sprintf("%s must be a resource quantity", key))
+		return
+	}
+
+	list[name] = quantity
+}
+
+// parseHeaders normalizes the headers, sorted so the proxy env doesn't change between reconciles.
+func parseHeaders(value string) (string, error) {
+	headers := make([]string, 0)
+
+	for _, header := range strings.Split(value, ",") {
+		if strings.TrimSpace(header) == "" {
+			continue
+		}
+
+		name, headerValue, found := strings.Cut(header, "=")
+		name = strings.TrimSpace(name)
+
+		if !found || !httpguts.ValidHeaderFieldName(name) || !httpguts.ValidHeaderFieldValue(headerValue) {
+			return "", fmt.Errorf("invalid header %q", header)
+		}
+
+		headers = append(headers, fmt.Sprintf("%s=%s", name, strings.TrimSpace(headerValue)))
+	}
+
+	sort.Strings(headers)
+
+	return strings.Join(headers, ","), nil
+}
+
+// parseWeights parses name=weight pairs.
+func parseWeights(value string) (map[string]int64, error) {
+	weights := make(map[string]int64)
+
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+
+		name, weightValue, found := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+
+		if !found || name == "" {
+			return nil, fmt.Errorf("invalid weight %q, expected name=weight", pair)
+		}
+
+		weight, err := strconv.ParseInt(strings.TrimSpace(weightValue), 10, 64)
+
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("weight of %s must be a non-negative integer", name)
+		}
+
+		weights[name] = weight
+	}
+
+	return weights, nil
+}
+
+// GetProxySettings reads the proxy settings from the annotations of a service. Invalid
+// annotations are ignored, and reported in the error.
+func GetProxySettings(annotations map[string]string) (ProxySettings, error) {
+	var errs []string
+
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	settings := ProxySettings{
+		Timeout:                 parseDurationAnnotation(annotations, controllers.ProxyTimeoutAnnotation, &errs),
+		Concurrency:             parseIntAnnotation(annotations, controllers.ProxyConcurrencyAnnotation, DefaultProxyConcurrency, &errs),
+		MinScale:                parseIntAnnotation(annotations, controllers.ProxyMinScaleAnnotation, DefaultProxyMinScale, &errs),
+		MaxScale:                parseIntAnnotation(annotations, controllers.ProxyMaxScaleAnnotation, DefaultProxyMaxScale, &errs),
+		RetryAttempts:           parseOptionalIntAnnotation(annotations, controllers.ProxyRetryAttemptsAnnotation, &errs),
+		RetryBackoff:            parseDurationAnnotation(annotations, controllers.ProxyRetryBackoffAnnotation, &errs),
+		CircuitBreakerThreshold: parseOptionalIntAnnotation(annotations, controllers.ProxyCircuitBreakerThresholdAnnotation, &errs),
+		CircuitBreakerTimeout:   parseDurationAnnotation(annotations, controllers.ProxyCircuitBreakerTimeoutAnnotation, &errs),
+	}
+
+	// max-scale 0 means unlimited for knative
+	if settings.MaxScale > 0 && settings.MinScale > settings.MaxScale {
+		errs = append(errs, fmt.Sprintf("%s can't be greater than %s", controllers.ProxyMinScaleAnnotation, controllers.ProxyMaxScaleAnnotation))
+		settings.MinScale, settings.MaxScale = DefaultProxyMinScale, DefaultProxyMaxScale
+	}
+
+	requests, limits := make(corev1.ResourceList), make(corev1.ResourceList)
+
+	parseQuantityAnnotation(annotations, controllers.ProxyCpuRequestAnnotation, requests, corev1.ResourceCPU, &errs)
+	parseQuantityAnnotation(annotations, controllers.ProxyCpuLimitAnnotation, limits, corev1.ResourceCPU, &errs)
+	parseQuantityAnnotation(annotations, controllers.ProxyMemoryRequestAnnotation, requests, corev1.ResourceMemory, &errs)
+	parseQuantityAnnotation(annotations, controllers.ProxyMemoryLimitAnnotation, limits, corev1.ResourceMemory, &errs)
+
+	if len(requests) > 0 {
+		settings.Resources.Requests = requests
+	}
+
+	if len(limits) > 0 {
+		settings.Resources.Limits = limits
+	}
+
+	if value, exists := annotations[controllers.ProxyHeadersAnnotation]; exists {
+		headers, err := parseHeaders(value)
+
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", controllers.ProxyHeadersAnnotation, err))
+		} else {
+			settings.Headers = headers
+		}
+	}
+
+	if value, exists := annotations[controllers.ProxyEndpointSelectionAnnotation]; exists {
+		switch value {
+		case EndpointSelectionLatency, EndpointSelectionWeighted:
+			settings.EndpointSelection = value
+		default:
+			errs = append(errs, fmt.Sprintf("%s must be %s or %s", controllers.ProxyEndpointSelectionAnnotation, EndpointSelectionLatency, EndpointSelectionWeighted))
+		}
+	}
+
+	if value, exists := annotations[controllers.ProxyEndpointWeightsAnnotation]; exists {
+		weights, err := parseWeights(value)
+
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", controllers.ProxyEndpointWeightsAnnotation, err))
+		} else {
+			settings.EndpointWeights = weights
+		}
+	}
+
+	if len(errs) > 0 {
+		return settings, fmt.Errorf("invalid proxy annotations: %s", strings.Join(errs, "; "))
+	}
+
+	return settings, nil
+}
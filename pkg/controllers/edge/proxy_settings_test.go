@@ -0,0 +1,157 @@
+package edge
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"edge.jevv.dev/pkg/controllers"
+)
+
+var _ = Describe("proxy settings", func() {
+	defaults := ProxySettings{
+		Concurrency: DefaultProxyConcurrency,
+		MinScale:    DefaultProxyMinScale,
+		MaxScale:    DefaultProxyMaxScale,
+	}
+
+	withDefaults := func(change func(settings *ProxySettings)) ProxySettings {
+		settings := defaults
+		change(&settings)
+
+		return settings
+	}
+
+	DescribeTable("reading annotations",
+		func(annotations map[string]string, expected ProxySettings, errs ...string) {
+			settings, err := GetProxySettings(annotations)
+
+			if len(errs) == 0 {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(HaveOccurred())
+
+				for _, message := range errs {
+					Expect(err.Error()).To(ContainSubstring(message))
+				}
+			}
+
+			Expect(settings).To(Equal(expected))
+		},
+		Entry("without annotations", nil, defaults),
+		Entry("unrelated annotations", map[string]string{"serving.knative.dev/creator": "admin"}, defaults),
+		Entry("every setting", map[string]string{
+			controllers.ProxyTimeoutAnnotation:                 "30s",
+			controllers.ProxyConcurrencyAnnotation:             "16",
+			controllers.ProxyMinScaleAnnotation:                "0",
+			controllers.ProxyMaxScaleAnnotation:                "10",
+			controllers.ProxyCpuRequestAnnotation:              "100m",
+			controllers.ProxyCpuLimitAnnotation:                "1",
+			controllers.ProxyMemoryRequestAnnotation:           "64Mi",
+			controllers.ProxyMemoryLimitAnnotation:             "128Mi",
+			controllers.ProxyHeadersAnnotation:                 " X-Site = edge-1 ,Authorization=Bearer token,",
+			controllers.ProxyRetryAttemptsAnnotation:           "0",
+			controllers.ProxyRetryBackoffAnnotation:            "250ms",
+			controllers.ProxyCircuitBreakerThresholdAnnotation: "5",
+			controllers.ProxyCircuitBreakerTimeoutAnnotation:   "1m",
+			controllers.ProxyEndpointSelectionAnnotation:       EndpointSelectionWeighted,
+			controllers.ProxyEndpointWeightsAnnotation:         "hub=3, cloud=1",
+		}, ProxySettings{
+			Timeout:     "30s",
+			Concurrency: 16,
+			MinScale:    0,
+			MaxScale:    10,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("100m"),
+					corev1.ResourceMemory: resource.MustParse("64Mi"),
+				},
+				Limits: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("1"),
+					corev1.ResourceMemory: resource.MustParse("128Mi"),
+				},
+			},
+			Headers:                 "Authorization=Bearer token,X-Site=edge-1",
+			RetryAttempts:           "0",
+			RetryBackoff:            "250ms",
+			CircuitBreakerThreshold: "5",
+			CircuitBreakerTimeout:   "1m",
+			EndpointSelection:       EndpointSelectionWeighted,
+			EndpointWeights:         map[string]int64{"hub": 3, "cloud": 1},
+		}),
+		Entry("unlimited max-scale", map[string]string{
+			controllers.ProxyMinScaleAnnotation: "10",
+			controllers.ProxyMaxScaleAnnotation: "0",
+		}, withDefaults(func(s *ProxySettings) { s.MinScale, s.MaxScale = 10, 0 })),
+		Entry("min-scale greater than max-scale", map[string]string{
+			controllers.ProxyMinScaleAnnotation: "6",
+			controllers.ProxyMaxScaleAnnotation: "2",
+		}, defaults, controllers.ProxyMinScaleAnnotation+" can't be greater than "+controllers.ProxyMaxScaleAnnotation),
+		Entry("min-scale greater than the default max-scale", map[string]string{
+			controllers.ProxyMinScaleAnnotation: "6",
+		}, defaults, controllers.ProxyMinScaleAnnotation+" can't be greater than "+controllers.ProxyMaxScaleAnnotation),
+		Entry("invalid durations", map[string]string{
+			controllers.ProxyTimeoutAnnotation:               "30",
+			controllers.ProxyRetryBackoffAnnotation:          "-1s",
+			controllers.ProxyCircuitBreakerTimeoutAnnotation: "0s",
+		}, defaults,
+			controllers.ProxyTimeoutAnnotation+" must be a positive duration",
+			controllers.ProxyRetryBackoffAnnotation+" must be a positive duration",
+			controllers.ProxyCircuitBreakerTimeoutAnnotation+" must be a positive duration",
+		),
+		Entry("invalid integers", map[string]string{
+			controllers.ProxyConcurrencyAnnotation:             "many",
+			controllers.ProxyMaxScaleAnnotation:                "-1",
+			controllers.ProxyRetryAttemptsAnnotation:           "1.5",
+			controllers.ProxyCircuitBreakerThresholdAnnotation: "-3",
+		}, defaults,
+			controllers.ProxyConcurrencyAnnotation+" must be a non-negative integer",
+			controllers.ProxyMaxScaleAnnotation+" must be a non-negative integer",
+			controllers.ProxyRetryAttemptsAnnotation+" must be a non-negative integer",
+			controllers.ProxyCircuitBreakerThresholdAnnotation+" must be a non-negative integer",
+		),
+		Entry("invalid quantities keep the valid ones", map[string]string{
+			controllers.ProxyCpuRequestAnnotation:    "a lot",
+			controllers.ProxyMemoryRequestAnnotation: "64Mi",
+			controllers.ProxyMemoryLimitAnnotation:   "128MB/s",
+		}, withDefaults(func(s *ProxySettings) {
+			s.Resources.Requests = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")}
+		}),
+			controllers.ProxyCpuRequestAnnotation+" must be a resource quantity",
+			controllers.ProxyMemoryLimitAnnotation+" must be a resource quantity",
+		),
+		Entry("invalid headers", map[string]string{
+			controllers.ProxyHeadersAnnotation: "X-Site=edge-1,Bad Header=value",
+		}, defaults, controllers.ProxyHeadersAnnotation+": invalid header"),
+		Entry("header without value", map[string]string{
+			controllers.ProxyHeadersAnnotation: "X-Site",
+		}, defaults, controllers.ProxyHeadersAnnotation+": invalid header"),
+		Entry("invalid endpoint selection", map[string]string{
+			controllers.ProxyEndpointSelectionAnnotation: "random",
+		}, defaults, controllers.ProxyEndpointSelectionAnnotation+" must be"),
+		Entry("invalid endpoint weights", map[string]string{
+			controllers.ProxyEndpointWeightsAnnotation: "hub=3,cloud=-1",
+		}, defaults, controllers.ProxyEndpointWeightsAnnotation+": weight of cloud must be a non-negative integer"),
+		Entry("endpoint weights without name", map[string]string{
+			controllers.ProxyEndpointWeightsAnnotation: "=3",
+		}, defaults, controllers.ProxyEndpointWeightsAnnotation+": invalid weight"),
+	)
+
+	It("only renders the env of the settings which are set", func() {
+		Expect(defaults.Env()).To(BeEmpty())
+
+		settings := withDefaults(func(s *ProxySettings) {
+			s.Timeout = "30s"
+			s.RetryAttempts = "0"
+			s.Policy = "{}"
+		})
+
+		Expect(settings.Env()).To(Equal([]corev1.EnvVar{
+			{Name: "REMOTE_TIMEOUT", Value: "30s"},
+			{Name: "RETRY_ATTEMPTS", Value: "0"},
+			{Name: "PROXY_POLICY", Value: "{}"},
+		}))
+	})
+})
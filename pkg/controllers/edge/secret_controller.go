@@ -28,6 +28,8 @@ type SecretReconciler struct {
 	Recorder      record.EventRecorder
 	RemoteCluster cluster.Cluster
 	Envs          []string
+	Transformer   *Transformer
+	Drift         *DriftTracker
 
 	mirror *MirroringReconciler[*corev1.Secret]
 }
@@ -77,6 +79,8 @@ func (r *SecretReconciler) SetupWithManager(mgr ctrl.Manager, predicates ...pred
 		Recorder:      r.Recorder,
 		RemoteCluster: r.RemoteCluster,
 		Envs:          r.Envs,
+		Transformer:   r.Transformer,
+		Drift:         r.Drift,
 		KindGenerator: r.kindGenerator,
 		KindMerger:    r.kindMerger,
 	}
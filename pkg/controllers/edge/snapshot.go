@@ -0,0 +1,733 @@
+package edge
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	klabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+
+	"edge.jevv.dev/pkg/controllers"
+	"edge.jevv.dev/pkg/metrics"
+)
+
+// ErrNoRemoteSnapshot is returned when reading a kind which the remote cache hasn't synced yet,
+// and which isn't in the snapshot either. It isn't a not found error on purpose, so the local
+// copies of the remote objects aren't deleted.
+var ErrNoRemoteSnapshot = errors.New("remote cluster not synced and no snapshot of it")
+
+// RemoteSnapshot keeps a copy of the remote objects in the local cluster, so the edge controllers
+// can start and keep reconciling while the remote cluster is unreachable. Until the remote cache
+// has synced a kind, its objects are read from the snapshot instead.
+//
+// The objects of each kind are stored in Secrets, since they include the remote secrets. They're
+// split in chunks, each in its own Secret, listed by an index Secret named after the kind. Only the
+// leader writes the snapshot.
+type RemoteSnapshot struct {
+	Log           logr.Logger
+	Client        client.Client
+	Reader        client.Reader
+	Scheme        *runtime.Scheme
+	RemoteCluster cluster.Cluster
+
+	// prefix of the names of the secrets, one index for each kind
+	Name   types.NamespacedName
+	Kinds  []client.ObjectList
+	Period time.Duration
+
+	// closed when this replica is elected leader
+	Elected <-chan struct{}
+
+	lock    sync.RWMutex
+	objects map[schema.GroupVersionKind]map[types.NamespacedName][]byte
+	synced  map[schema.GroupVersionKind]bool
+	saved   map[schema.GroupVersionKind]string
+}
+
+func (s *RemoteSnapshot) NeedLeaderElection() bool {
+	// every replica reads from it
+	return false
+}
+
+func (s *RemoteSnapshot) Start(ctx context.Context) error {
+	log := s.Log.V(controllers.InfoLevel)
+
+	if s.Client == nil || s.Reader == nil || s.RemoteCluster == nil {
+		return fmt.Errorf("no client provided for remote snapshot")
+	}
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(s.RemoteCluster.GetConfig())
+
+	if err != nil {
+		return fmt.Errorf("couldn't create remote discovery client: %w", err)
+	}
+
+	for _, list := range s.Kinds {
+		gvk, err := s.itemKind(list)
+
+		if err != nil {
+			return err
+		}
+
+		// not fatal, the remote cluster might be reachable
+		if err := s.load(ctx, gvk); err != nil {
+			s.Log.Error(err, "Couldn't load remote snapshot.", "kind", gvk.Kind)
+		} else {
+			log.Info("Loaded remote snapshot.", "kind", gvk.Kind, "objects", len(s.objects[gvk]))
+		}
+	}
+
+	period := s.Period
+
+	if period <= 0 {
+		period = RemoteSnapshotPeriod
+	}
+
+	// switch to the remote cache as soon as it's synced, it doesn't go back
+	go func() {
+		for !s.checkSynced(ctx) {
+			timeoutCtx, timeoutCancel := context.WithTimeout(context.Background(), RemoteSnapshotSyncCheckPeriod)
+
+			select {
+			case <-timeoutCtx.Done():
+				timeoutCancel()
+			case <-ctx.Done():
+				timeoutCancel()
+				return
+			}
+		}
+	}()
+
+	go func() {
+		for {
+			s.refresh(ctx, discoveryClient)
+
+			timeoutCtx, timeoutCancel := context.WithTimeout(context.Background(), period)
+
+			select {
+			case <-timeoutCtx.Done():
+				timeoutCancel()
+			case <-ctx.Done():
+				timeoutCancel()
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (s *RemoteSnapshot) itemKind(list client.ObjectList) (schema.GroupVersionKind, error) {
+	gvk, err := apiutil.GVKForObject(list, s.Scheme)
+
+	if err != nil {
+		return gvk, fmt.Errorf("couldn't get kind of %T: %w", list, err)
+	}
+
+	gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+
+	return gvk, nil
+}
+
+func (s *RemoteSnapshot) secretName(gvk schema.GroupVersionKind) types.NamespacedName {
+	return types.NamespacedName{
+		Name:      fmt.Sprintf("%s-%s", s.Name.Name, strings.ToLower(gvk.Kind)),
+		Namespace: s.Name.Namespace,
+	}
+}
+
+// refresh updates the snapshot of the kinds the remote cache has synced, if the remote cluster is
+// still reachable.
+func (s *RemoteSnapshot) refresh(ctx context.Context, discoveryClient *discovery.DiscoveryClient) {
+	debug := s.Log.V(controllers.DebugLevel)
+
+	_, err := discoveryClient.ServerVersion()
+	reachable := err == nil
+
+	leader := false
+
+	select {
+	case <-s.Elected:
+		leader = true
+	default:
+	}
+
+	for _, list := range s.Kinds {
+		gvk, err := s.itemKind(list)
+
+		if err != nil {
+			continue
+		}
+
+		s.lock.RLock()
+		synced := s.synced[gvk]
+		s.lock.RUnlock()
+
+		if !synced || !reachable {
+			debug.Info("not refreshing remote snapshot", "kind", gvk.Kind, "synced", synced, "reachable", reachable)
+			continue
+		}
+
+		list := list.DeepCopyObject().(client.ObjectList)
+
+		if err := s.RemoteCluster.GetCache().List(ctx, list); err != nil {
+			s.Log.Error(err, "Couldn't list remote objects for snapshot.", "kind", gvk.Kind)
+			continue
+		}
+
+		objects, err := encodeObjects(list)
+
+		if err != nil {
+			s.Log.Error(err, "Couldn't encode remote snapshot.", "kind", gvk.Kind)
+			continue
+		}
+
+		s.lock.Lock()
+		if s.objects == nil {
+			s.objects = make(map[schema.GroupVersionKind]map[types.NamespacedName][]byte)
+		}
+		s.objects[gvk] = objects
+		s.lock.Unlock()
+
+		if leader {
+			if err := s.save(ctx, gvk, objects); err != nil {
+				s.Log.Error(err, "Couldn't save remote snapshot.", "kind", gvk.Kind)
+			}
+		}
+	}
+}
+
+// checkSynced checks which kinds the remote cache has synced, and whether it has synced all.
+func (s *RemoteSnapshot) checkSynced(ctx context.Context) bool {
+	allSynced := true
+
+	for _, list := range s.Kinds {
+		gvk, err := s.itemKind(list)
+
+		if err != nil {
+			continue
+		}
+
+		synced := s.isSynced(ctx, gvk)
+		s.setSynced(gvk, synced)
+
+		allSynced = allSynced && synced
+	}
+
+	return allSynced
+}
+
+func (s *RemoteSnapshot) isSynced(ctx context.Context, gvk schema.GroupVersionKind) bool {
+	obj, err := s.Scheme.New(gvk)
+
+	if err != nil {
+		return false
+	}
+
+	object, ok := obj.(client.Object)
+
+	if !ok {
+		return false
+	}
+
+	// the informer waits for the sync otherwise
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	informer, err := s.RemoteCluster.GetCache().GetInformer(timeoutCtx, object)
+
+	return err == nil && informer.HasSynced()
+}
+
+func (s *RemoteSnapshot) setSynced(gvk schema.GroupVersionKind, synced bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.synced == nil {
+		s.synced = make(map[schema.GroupVersionKind]bool)
+	}
+
+	if s.synced[gvk] != synced {
+		s.Log.V(controllers.InfoLevel).Info("Switching remote reads.", "kind", gvk.Kind, "fromSnapshot", !synced)
+	}
+
+	s.synced[gvk] = synced
+
+	if synced {
+		metrics.RemoteSnapshotInUse.WithLabelValues(gvk.Kind).Set(0)
+	} else {
+		metrics.RemoteSnapshotInUse.WithLabelValues(gvk.Kind).Set(1)
+	}
+}
+
+// Serves tells whether reads of the object should be served from the snapshot.
+func (s *RemoteSnapshot) Serves(obj runtime.Object) bool {
+	gvk, err := apiutil.GVKForObject(obj, s.Scheme)
+
+	if err != nil {
+		return false
+	}
+
+	gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	for _, list := range s.Kinds {
+		if listGvk, err := s.itemKind(list); err == nil && listGvk == gvk {
+			return !s.synced[gvk]
+		}
+	}
+
+	return false
+}
+
+func (s *RemoteSnapshot) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	gvk, err := apiutil.GVKForObject(obj, s.Scheme)
+
+	if err != nil {
+		return err
+	}
+
+	s.lock.RLock()
+	objects, loaded := s.objects[gvk]
+	data, exists := objects[key]
+	s.lock.RUnlock()
+
+	if !loaded {
+		return ErrNoRemoteSnapshot
+	}
+
+	if !exists {
+		return apierrors.NewNotFound(schema.GroupResource{Group: gvk.Group, Resource: strings.ToLower(gvk.Kind)}, key.Name)
+	}
+
+	return json.Unmarshal(data, obj)
+}
+
+func (s *RemoteSnapshot) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	gvk, err := s.itemKind(list)
+
+	if err != nil {
+		return err
+	}
+
+	listOpts := client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+
+	s.lock.RLock()
+	objects, loaded := s.objects[gvk]
+
+	keys := make([]types.NamespacedName, 0, len(objects))
+
+	for key := range objects {
+		if listOpts.Namespace == "" || key.Namespace == listOpts.Namespace {
+			keys = append(keys, key)
+		}
+	}
+
+	s.lock.RUnlock()
+
+	if !loaded {
+		return ErrNoRemoteSnapshot
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+
+	items := make([]runtime.Object, 0, len(keys))
+
+	for _, key := range keys {
+		obj, err := s.Scheme.New(gvk)
+
+		if err != nil {
+			return err
+		}
+
+		if err := s.Get(ctx, key, obj.(client.Object)); err != nil {
+			// deleted in the meantime
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+
+			return err
+		}
+
+		if listOpts.LabelSelector != nil {
+			if accessor, err := meta.Accessor(obj); err != nil || !listOpts.LabelSelector.Matches(klabels.Set(accessor.GetLabels())) {
+				continue
+			}
+		}
+
+		items = append(items, obj)
+	}
+
+	return meta.SetList(list, items)
+}
+
+func (s *RemoteSnapshot) load(ctx context.Context, gvk schema.GroupVersionKind) error {
+	var index corev1.Secret
+
+	name := s.secretName(gvk)
+
+	if err := s.Reader.Get(ctx, name, &index); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+
+		return fmt.Errorf("couldn't get snapshot secret: %w", err)
+	}
+
+	objects := make(map[types.NamespacedName][]byte)
+
+	// snapshots from before the chunks keep the objects in the index
+	if data, exists := index.Data[RemoteSnapshotDataKey]; exists {
+		chunk, err := decodeSnapshot(data)
+
+		if err != nil {
+			return err
+		}
+
+		objects = chunk
+	}
+
+	for _, chunkName := range parseSnapshotIndex(index.Data[RemoteSnapshotIndexKey]) {
+		var secret corev1.Secret
+
+		if err := s.Reader.Get(ctx, types.NamespacedName{Name: chunkName, Namespace: name.Namespace}, &secret); err != nil {
+			return fmt.Errorf("couldn't get snapshot chunk %s: %w", chunkName, err)
+		}
+
+		chunk, err := decodeSnapshot(secret.Data[RemoteSnapshotDataKey])
+
+		if err != nil {
+			return fmt.Errorf("couldn't read snapshot chunk %s: %w", chunkName, err)
+		}
+
+		for key, data := range chunk {
+			objects[key] = data
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.objects == nil {
+		s.objects = make(map[schema.GroupVersionKind]map[types.NamespacedName][]byte)
+	}
+
+	// the remote cache might have been faster
+	if _, exists := s.objects[gvk]; !exists {
+		s.objects[gvk] = objects
+	}
+
+	return nil
+}
+
+// save writes the chunks before the index, and only then deletes the chunks of the previous
+// snapshot, so there's always a complete snapshot to load.
+func (s *RemoteSnapshot) save(ctx context.Context, gvk schema.GroupVersionKind, objects map[types.NamespacedName][]byte) error {
+	debug := s.Log.V(controllers.DebugLevel)
+
+	chunks, hash, err := encodeSnapshot(objects, RemoteSnapshotChunkSize)
+
+	if err != nil {
+		return err
+	}
+
+	s.lock.RLock()
+	unchanged := s.saved[gvk] == hash
+	s.lock.RUnlock()
+
+	if unchanged {
+		return nil
+	}
+
+	name := s.secretName(gvk)
+
+	var index corev1.Secret
+
+	shouldCreate := false
+
+	if err := s.Reader.Get(ctx, name, &index); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("couldn't get snapshot secret: %w", err)
+		}
+
+		shouldCreate = true
+	}
+
+	previousChunkNames := parseSnapshotIndex(index.Data[RemoteSnapshotIndexKey])
+	chunkNames := make([]string, len(chunks))
+	size := 0
+
+	for i, chunk := range chunks {
+		// named after the hash, so the chunks of the previous snapshot are kept until the index changes
+		chunkNames[i] = fmt.Sprintf("%s-%s-%d", name.Name, hash[:10], i)
+		size += len(chunk)
+
+		chunkName := types.NamespacedName{Name: chunkNames[i], Namespace: name.Namespace}
+
+		if err := s.writeChunk(ctx, chunkName, chunk); err != nil {
+			return fmt.Errorf("couldn't write snapshot chunk: %w", err)
+		}
+	}
+
+	debug.Info("persisting remote snapshot", "secret", name.String(), "objects", len(objects), "chunks", len(chunks), "size", size)
+
+	index.Name = name.Name
+	index.Namespace = name.Namespace
+	index.Type = corev1.SecretTypeOpaque
+	index.Data = map[string][]byte{RemoteSnapshotIndexKey: []byte(strings.Join(chunkNames, "\n"))}
+	setSnapshotLabels(&index)
+
+	if shouldCreate {
+		err = s.Client.Create(ctx, &index)
+	} else {
+		err = s.Client.Update(ctx, &index)
+	}
+
+	if err != nil {
+		return fmt.Errorf("couldn't write snapshot secret: %w", err)
+	}
+
+	s.deleteChunks(ctx, name.Namespace, previousChunkNames, chunkNames)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.saved == nil {
+		s.saved = make(map[schema.GroupVersionKind]string)
+	}
+
+	s.saved[gvk] = hash
+
+	metrics.RemoteSnapshotLastSaved.WithLabelValues(gvk.Kind).SetToCurrentTime()
+
+	return nil
+}
+
+func (s *RemoteSnapshot) writeChunk(ctx context.Context, name types.NamespacedName, data []byte) error {
+	secret := corev1.Secret{}
+	secret.Name = name.Name
+	secret.Namespace = name.Namespace
+	secret.Type = corev1.SecretTypeOpaque
+	secret.Data = map[string][]byte{RemoteSnapshotDataKey: data}
+	setSnapshotLabels(&secret)
+
+	err := s.Client.Create(ctx, &secret)
+
+	// left by a save which didn't get to write the index, the content is the same
+	if apierrors.IsAlreadyExists(err) {
+		return nil
+	}
+
+	return err
+}
+
+// deleteChunks deletes the chunks of the previous snapshot which aren't used anymore.
+func (s *RemoteSnapshot) deleteChunks(ctx context.Context, namespace string, previousChunkNames, chunkNames []string) {
+	current := make(map[string]bool, len(chunkNames))
+
+	for _, chunkName := range chunkNames {
+		current[chunkName] = true
+	}
+
+	for _, chunkName := range previousChunkNames {
+		if current[chunkName] {
+			continue
+		}
+
+		secret := corev1.Secret{}
+		secret.Name = chunkName
+		secret.Namespace = namespace
+
+		if err := s.Client.Delete(ctx, &secret); err != nil && !apierrors.IsNotFound(err) {
+			s.Log.Error(err, "Couldn't delete remote snapshot chunk.", "secret", chunkName)
+		}
+	}
+}
+
+// setSnapshotLabels marks the secret as not managed, otherwise it'd be deleted as it doesn't exist
+// in the remote cluster.
+func setSnapshotLabels(secret *corev1.Secret) {
+	if secret.Labels == nil {
+		secret.Labels = make(map[string]string)
+	}
+
+	secret.Labels[controllers.ManagedByLabel] = controllers.ManagedByLabelValue
+}
+
+func parseSnapshotIndex(data []byte) []string {
+	var chunkNames []string
+
+	for _, chunkName := range strings.Split(string(data), "\n") {
+		if chunkName = strings.TrimSpace(chunkName); chunkName != "" {
+			chunkNames = append(chunkNames, chunkName)
+		}
+	}
+
+	return chunkNames
+}
+
+func encodeObjects(list client.ObjectList) (map[types.NamespacedName][]byte, error) {
+	items, err := meta.ExtractList(list)
+
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make(map[types.NamespacedName][]byte, len(items))
+
+	for _, item := range items {
+		obj, ok := item.(client.Object)
+
+		if !ok {
+			continue
+		}
+
+		obj = obj.DeepCopyObject().(client.Object)
+
+		// not needed to reconcile, and takes most of the space
+		obj.SetManagedFields(nil)
+
+		data, err := json.Marshal(obj)
+
+		if err != nil {
+			return nil, err
+		}
+
+		objects[client.ObjectKeyFromObject(obj)] = data
+	}
+
+	return objects, nil
+}
+
+// encodeSnapshot encodes the objects as gzipped JSON arrays of about chunkSize bytes before
+// compression, sorted so the hash only changes with the objects. An object larger than chunkSize
+// gets a chunk of its own.
+func encodeSnapshot(objects map[types.NamespacedName][]byte, chunkSize int) ([][]byte, string, error) {
+	keys := make([]types.NamespacedName, 0, len(objects))
+
+	for key := range objects {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+
+	hash := sha256.New()
+	chunks := [][]json.RawMessage{nil}
+	size := 0
+
+	for _, key := range keys {
+		item := objects[key]
+		last := len(chunks) - 1
+
+		if size > 0 && size+len(item) > chunkSize {
+			chunks = append(chunks, nil)
+			last++
+			size = 0
+		}
+
+		chunks[last] = append(chunks[last], item)
+		size += len(item)
+
+		hash.Write(item)
+	}
+
+	encoded := make([][]byte, 0, len(chunks))
+
+	for _, items := range chunks {
+		if items == nil {
+			items = []json.RawMessage{}
+		}
+
+		data, err := json.Marshal(items)
+
+		if err != nil {
+			return nil, "", fmt.Errorf("couldn't encode snapshot: %w", err)
+		}
+
+		var buffer bytes.Buffer
+		writer := gzip.NewWriter(&buffer)
+
+		if _, err := writer.Write(data); err != nil {
+			return nil, "", fmt.Errorf("couldn't compress snapshot: %w", err)
+		}
+
+		if err := writer.Close(); err != nil {
+			return nil, "", fmt.Errorf("couldn't compress snapshot: %w", err)
+		}
+
+		encoded = append(encoded, buffer.Bytes())
+	}
+
+	return encoded, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func decodeSnapshot(data []byte) (map[types.NamespacedName][]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+
+	if err != nil {
+		return nil, fmt.Errorf("couldn't decompress snapshot: %w", err)
+	}
+
+	defer reader.Close()
+
+	decompressed, err := io.ReadAll(reader)
+
+	if err != nil {
+		return nil, fmt.Errorf("couldn't decompress snapshot: %w", err)
+	}
+
+	var items []json.RawMessage
+
+	if err := json.Unmarshal(decompressed, &items); err != nil {
+		return nil, fmt.Errorf("couldn't decode snapshot: %w", err)
+	}
+
+	objects := make(map[types.NamespacedName][]byte, len(items))
+
+	for _, item := range items {
+		var object struct {
+			Metadata struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"metadata"`
+		}
+
+		if err := json.Unmarshal(item, &object); err != nil {
+			return nil, fmt.Errorf("couldn't decode snapshot: %w", err)
+		}
+
+		objects[types.NamespacedName{Name: object.Metadata.Name, Namespace: object.Metadata.Namespace}] = item
+	}
+
+	return objects, nil
+}
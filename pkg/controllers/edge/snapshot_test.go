@@ -0,0 +1,303 @@
+package edge
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"edge.jevv.dev/pkg/controllers"
+)
+
+func snapshotObjects(count int, size int) map[types.NamespacedName][]byte {
+	objects := make(map[types.NamespacedName][]byte, count)
+
+	for i := 0; i < count; i++ {
+		key := types.NamespacedName{Name: fmt.Sprintf("config-%d", i), Namespace: "default"}
+		objects[key] = []byte(fmt.Sprintf(`{"metadata":{"name":%q,"namespace":%q},"data":{"value":%q}}`, key.Name, key.Namespace, strings.Repeat("x", size)))
+	}
+
+	return objects
+}
+
+var _ = Describe("remote snapshot", func() {
+	DescribeTable("encoding and decoding",
+		func(count int, size int, chunkSize int, expectedChunks int) {
+			objects := snapshotObjects(count, size)
+
+			chunks, hash, err := encodeSnapshot(objects, chunkSize)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(chunks).To(HaveLen(expectedChunks))
+			Expect(hash).To(HaveLen(64))
+
+			decoded := make(map[types.NamespacedName][]byte)
+
+			for _, chunk := range chunks {
+				objects, err := decodeSnapshot(chunk)
+				Expect(err).NotTo(HaveOccurred())
+
+				for key, data := range objects {
+					Expect(decoded).NotTo(HaveKey(key))
+					decoded[key] = data
+				}
+			}
+
+			Expect(decoded).To(HaveLen(count))
+
+			for key, data := range objects {
+				Expect(decoded[key]).To(MatchJSON(data))
+			}
+		},
+		Entry("no objects", 0, 0, 1024, 1),
+		Entry("one chunk", 10, 10, 1024, 1),
+		Entry("several chunks", 10, 100, 400, 5),
+		Entry("objects larger than the chunks", 3, 100, 10, 3),
+	)
+
+	It("hashes the objects regardless of their order", func() {
+		objects := snapshotObjects(10, 10)
+
+		_, hash, err := encodeSnapshot(objects, 100)
+		Expect(err).NotTo(HaveOccurred())
+
+		for i := 0; i < 5; i++ {
+			_, again, err := encodeSnapshot(objects, 100)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(again).To(Equal(hash))
+		}
+
+		objects[types.NamespacedName{Name: "config-0", Namespace: "default"}] = []byte(`{"metadata":{"name":"config-0","namespace":"default"}}`)
+
+		_, changed, err := encodeSnapshot(objects, 100)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changed).NotTo(Equal(hash))
+	})
+
+	DescribeTable("decoding invalid chunks",
+		func(data func() []byte, message string) {
+			_, err := decodeSnapshot(data())
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("uncompressed", func() []byte { return []byte(`[]`) }, "couldn't decompress snapshot"),
+		Entry("truncated", func() []byte {
+			chunks, _, err := encodeSnapshot(snapshotObjects(10, 10), 1024)
+			Expect(err).NotTo(HaveOccurred())
+
+			return chunks[0][:len(chunks[0])/2]
+		}, "couldn't decompress snapshot"),
+	)
+
+	Describe("saving and loading", func() {
+		var (
+			ctx      context.Context
+			c        client.Client
+			snapshot *RemoteSnapshot
+		)
+
+		gvk := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+		indexName := types.NamespacedName{Name: "snapshot-configmap", Namespace: controllers.SystemNamespace}
+
+		newSnapshot := func() *RemoteSnapshot {
+			return &RemoteSnapshot{
+				Log:    logr.Discard(),
+				Client: c,
+				Reader: c,
+				Scheme: c.Scheme(),
+				Name:   types.NamespacedName{Name: "snapshot", Namespace: controllers.SystemNamespace},
+				Kinds:  []client.ObjectList{&corev1.ConfigMapList{}},
+			}
+		}
+
+		chunkNames := func() []string {
+			index := &corev1.Secret{}
+			Expect(c.Get(ctx, indexName, index)).To(Succeed())
+
+			return parseSnapshotIndex(index.Data[RemoteSnapshotIndexKey])
+		}
+
+		secretNames := func() []string {
+			secrets := &corev1.SecretList{}
+			Expect(c.List(ctx, secrets, client.InNamespace(controllers.SystemNamespace))).To(Succeed())
+
+			var names []string
+
+			for _, secret := range secrets.Items {
+				Expect(secret.Labels).To(HaveKeyWithValue(controllers.ManagedByLabel, controllers.ManagedByLabelValue))
+				names = append(names, secret.Name)
+			}
+
+			return names
+		}
+
+		loaded := func() map[types.NamespacedName][]byte {
+			s := newSnapshot()
+			Expect(s.load(ctx, gvk)).To(Succeed())
+
+			return s.objects[gvk]
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+
+			c = fake.NewClientBuilder().WithScheme(scheme).Build()
+			snapshot = newSnapshot()
+		})
+
+		It("loads nothing without a snapshot", func() {
+			Expect(loaded()).To(BeEmpty())
+		})
+
+		It("keeps the index consistent with the chunks", func() {
+			objects := snapshotObjects(1000, 1000)
+
+			Expect(snapshot.save(ctx, gvk, objects)).To(Succeed())
+
+			chunks := chunkNames()
+			Expect(len(chunks)).To(BeNumerically(">", 1))
+			Expect(secretNames()).To(ConsistOf(append(chunks, indexName.Name)))
+			Expect(loaded()).To(Equal(objects))
+
+			By("saving the same objects again")
+			Expect(snapshot.save(ctx, gvk, objects)).To(Succeed())
+			Expect(chunkNames()).To(Equal(chunks))
+
+			By("saving changed objects")
+			changed := snapshotObjects(10, 10)
+			Expect(snapshot.save(ctx, gvk, changed)).To(Succeed())
+
+			changedChunks := chunkNames()
+			Expect(changedChunks).To(HaveLen(1))
+			Expect(changedChunks).NotTo(ContainElement(BeElementOf(chunks)))
+			Expect(secretNames()).To(ConsistOf(append(changedChunks, indexName.Name)))
+			Expect(loaded()).To(Equal(changed))
+		})
+
+		It("loads the previous snapshot when the index wasn't written", func() {
+			previous := snapshotObjects(10, 10)
+			Expect(snapshot.save(ctx, gvk, previous)).To(Succeed())
+
+			By("writing the chunks of the next snapshot only")
+			next := snapshotObjects(20, 10)
+			chunks, hash, err := encodeSnapshot(next, RemoteSnapshotChunkSize)
+			Expect(err).NotTo(HaveOccurred())
+
+			for i, chunk := range chunks {
+				chunkName := types.NamespacedName{Name: fmt.Sprintf("%s-%s-%d", indexName.Name, hash[:10], i), Namespace: indexName.Namespace}
+				Expect(snapshot.writeChunk(ctx, chunkName, chunk)).To(Succeed())
+			}
+
+			Expect(loaded()).To(Equal(previous))
+
+			By("saving the next snapshot over the chunks left")
+			Expect(newSnapshot().save(ctx, gvk, next)).To(Succeed())
+			Expect(loaded()).To(Equal(next))
+			Expect(secretNames()).To(ConsistOf(append(chunkNames(), indexName.Name)))
+		})
+
+		It("loads snapshots from before the chunks", func() {
+			objects := snapshotObjects(5, 10)
+
+			chunks, _, err := encodeSnapshot(objects, RemoteSnapshotChunkSize)
+			Expect(err).NotTo(HaveOccurred())
+
+			index := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: indexName.Name, Namespace: indexName.Namespace},
+				Data:       map[string][]byte{RemoteSnapshotDataKey: chunks[0]},
+			}
+			Expect(c.Create(ctx, index)).To(Succeed())
+
+			Expect(loaded()).To(Equal(objects))
+		})
+
+		DescribeTable("failing to load broken snapshots",
+			func(breakSnapshot func(chunk *corev1.Secret), message string) {
+				Expect(snapshot.save(ctx, gvk, snapshotObjects(10, 10))).To(Succeed())
+
+				chunk := &corev1.Secret{}
+				Expect(c.Get(ctx, types.NamespacedName{Name: chunkNames()[0], Namespace: indexName.Namespace}, chunk)).To(Succeed())
+
+				breakSnapshot(chunk)
+
+				s := newSnapshot()
+				Expect(s.load(ctx, gvk)).To(MatchError(ContainSubstring(message)))
+
+				// reads fail rather than returning nothing, so the local objects aren't deleted
+				Expect(s.Get(ctx, client.ObjectKey{Name: "config-0", Namespace: "default"}, &corev1.ConfigMap{})).To(MatchError(ErrNoRemoteSnapshot))
+			},
+			Entry("missing chunk", func(chunk *corev1.Secret) {
+				Expect(c.Delete(ctx, chunk)).To(Succeed())
+			}, "couldn't get snapshot chunk"),
+			Entry("truncated chunk", func(chunk *corev1.Secret) {
+				data := chunk.Data[RemoteSnapshotDataKey]
+				chunk.Data[RemoteSnapshotDataKey] = data[:len(data)-10]
+				Expect(c.Update(ctx, chunk)).To(Succeed())
+			}, "couldn't read snapshot chunk"),
+		)
+
+		It("serves the loaded objects", func() {
+			objects := make(map[types.NamespacedName][]byte)
+
+			for _, configMap := range []*corev1.ConfigMap{
+				{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default", Labels: map[string]string{"app": "a"}}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "default", Labels: map[string]string{"app": "b"}}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "c", Namespace: "other", Labels: map[string]string{"app": "a"}}},
+			} {
+				list := &corev1.ConfigMapList{Items: []corev1.ConfigMap{*configMap}}
+				encoded, err := encodeObjects(list)
+				Expect(err).NotTo(HaveOccurred())
+
+				for key, data := range encoded {
+					objects[key] = data
+				}
+			}
+
+			Expect(snapshot.Get(ctx, client.ObjectKey{Name: "a", Namespace: "default"}, &corev1.ConfigMap{})).To(MatchError(ErrNoRemoteSnapshot))
+
+			Expect(snapshot.save(ctx, gvk, objects)).To(Succeed())
+
+			s := newSnapshot()
+			Expect(s.load(ctx, gvk)).To(Succeed())
+
+			configMap := &corev1.ConfigMap{}
+			Expect(s.Get(ctx, client.ObjectKey{Name: "a", Namespace: "default"}, configMap)).To(Succeed())
+			Expect(configMap.Labels).To(HaveKeyWithValue("app", "a"))
+
+			Expect(apierrors.IsNotFound(s.Get(ctx, client.ObjectKey{Name: "d", Namespace: "default"}, configMap))).To(BeTrue())
+
+			names := func(opts ...client.ListOption) []string {
+				list := &corev1.ConfigMapList{}
+				Expect(s.List(ctx, list, opts...)).To(Succeed())
+
+				var names []string
+
+				for _, item := range list.Items {
+					names = append(names, item.Namespace+"/"+item.Name)
+				}
+
+				return names
+			}
+
+			Expect(names()).To(Equal([]string{"default/a", "default/b", "other/c"}))
+			Expect(names(client.InNamespace("default"))).To(Equal([]string{"default/a", "default/b"}))
+			Expect(names(client.MatchingLabels{"app": "a"})).To(Equal([]string{"default/a", "other/c"}))
+		})
+	})
+})
@@ -26,6 +26,9 @@ func TestRun(t *testing.T) {
 	RunSpecs(t, "Edge controller Integration Suite")
 }
 
+// the name of the edge cluster the services report their status as
+const testClusterName = "test-cluster"
+
 var stop context.CancelFunc
 
 var edgeClusterCfg *rest.Config
@@ -127,6 +130,26 @@ var _ = BeforeSuite(func() {
 		}).SetupWithManager(mgr, hasEdgeLabelPredicate)
 		Expect(err).ToNot(HaveOccurred())
 
+		err = (&MirrorPolicyReconciler{
+			Client:        mgr.GetClient(),
+			Scheme:        mgr.GetScheme(),
+			Log:           mgr.GetLogger().WithName("mirrorpolicy-controller"),
+			Recorder:      mgr.GetEventRecorderFor("mirrorpolicy-controller"),
+			RemoteCluster: remoteCluster,
+			Envs:          envs,
+		}).SetupWithManager(mgr, hasEdgeLabelPredicate)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = (&KServiceStatusReconciler{
+			Client:        mgr.GetClient(),
+			Scheme:        mgr.GetScheme(),
+			Log:           mgr.GetLogger().WithName("kservice-status-controller"),
+			Recorder:      mgr.GetEventRecorderFor("kservice-status-controller"),
+			RemoteCluster: remoteCluster,
+			ClusterName:   testClusterName,
+		}).SetupWithManager(mgr)
+		Expect(err).ToNot(HaveOccurred())
+
 		err = mgr.Start(ctx)
 		Expect(err).ToNot(HaveOccurred())
 	}()
@@ -0,0 +1,271 @@
+package edge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+
+	edgev1alpha1 "edge.jevv.dev/pkg/apis/edge/v1alpha1"
+	"edge.jevv.dev/pkg/controllers"
+)
+
+// Transformer changes the mirrored objects with the MirrorTransformations of the remote cluster,
+// before they're applied on the edge cluster.
+type Transformer struct {
+	Scheme        *runtime.Scheme
+	RemoteCluster cluster.Cluster
+
+	// ClusterName is the name of the EdgeCluster, matched against the clusters of the
+	// transformations
+	ClusterName string
+}
+
+// Transform applies the transformations which select the object, in the order of their names.
+func (t *Transformer) Transform(ctx context.Context, obj client.Object) error {
+	gvk, err := apiutil.GVKForObject(obj, t.Scheme)
+
+	if err != nil {
+		return err
+	}
+
+	transformations := &edgev1alpha1.MirrorTransformationList{}
+
+	if err := t.RemoteCluster.GetClient().List(ctx, transformations); err != nil {
+		return fmt.Errorf("couldn't list mirror transformations: %w", err)
+	}
+
+	sort.Slice(transformations.Items, func(i, j int) bool {
+		return transformations.Items[i].Name < transformations.Items[j].Name
+	})
+
+	var selected []*edgev1alpha1.MirrorTransformation
+
+	for i := range transformations.Items {
+		transformation := &transformations.Items[i]
+
+		if t.selectsKind(transformation, gvk.GroupKind()) && t.selectsObject(transformation, obj) {
+			selected = append(selected, transformation)
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil
+	}
+
+	// strategic merge patches need the go type of the object, which unstructured objects don't have
+	var patchSchema interface{}
+
+	if _, ok := obj.(*unstructured.Unstructured); !ok {
+		patchSchema = obj
+	}
+
+	data, err := json.Marshal(obj)
+
+	if err != nil {
+		return err
+	}
+
+	for _, transformation := range selected {
+		if data, err = applyTransformation(&transformation.Spec, data, patchSchema); err != nil {
+			return fmt.Errorf("couldn't apply mirror transformation %s: %w", transformation.Name, err)
+		}
+	}
+
+	name, namespace := obj.GetName(), obj.GetNamespace()
+
+	// unmarshalling merges into the existing maps, so the object is reset first
+	value := reflect.ValueOf(obj).Elem()
+	value.Set(reflect.Zero(value.Type()))
+
+	if err := json.Unmarshal(data, obj); err != nil {
+		return fmt.Errorf("couldn't decode transformed object: %w", err)
+	}
+
+	// transformations can't move the object somewhere else
+	obj.SetName(name)
+	obj.SetNamespace(namespace)
+
+	return nil
+}
+
+func (t *Transformer) selectsKind(transformation *edgev1alpha1.MirrorTransformation, gk schema.GroupKind) bool {
+	if len(transformation.Spec.Resources) == 0 {
+		return true
+	}
+
+	for _, resource := range transformation.Spec.Resources {
+		if resource.Group == gk.Group && resource.Kind == gk.Kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (t *Transformer) selectsObject(transformation *edgev1alpha1.MirrorTransformation, obj client.Object) bool {
+	if len(transformation.Spec.Clusters) > 0 && !containsString(transformation.Spec.Clusters, t.ClusterName) {
+		return false
+	}
+
+	if len(transformation.Spec.Environments) > 0 {
+		env, exists := obj.GetLabels()[controllers.EnvironmentLabel]
+
+		if !exists || !containsString(transformation.Spec.Environments, env) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applyTransformation applies the rewrites and then the patches of a transformation to the JSON
+// of an object.
+func applyTransformation(spec *edgev1alpha1.MirrorTransformationSpec, data []byte, patchSchema interface{}) ([]byte, error) {
+	content := make(map[string]interface{})
+
+	if err := json.Unmarshal(data, &content); err != nil {
+		return nil, err
+	}
+
+	if metadata, ok := content["metadata"].(map[string]interface{}); ok {
+		rewriteMetadata(metadata, "labels", spec.Labels)
+		rewriteMetadata(metadata, "annotations", spec.Annotations)
+	}
+
+	if len(spec.ImageRegistries) > 0 {
+		for key, value := range content {
+			if key != "metadata" && key != "status" {
+				content[key] = rewriteImages(value, spec.ImageRegistries)
+			}
+		}
+	}
+
+	data, err := json.Marshal(content)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if spec.MergePatch != nil && len(spec.MergePatch.Raw) > 0 {
+		if patchSchema != nil {
+			data, err = strategicpatch.StrategicMergePatch(data, spec.MergePatch.Raw, patchSchema)
+		} else {
+			data, err = jsonpatch.MergePatch(data, spec.MergePatch.Raw)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("couldn't apply merge patch: %w", err)
+		}
+	}
+
+	if len(spec.JSONPatch) > 0 {
+		operations, err := json.Marshal(spec.JSONPatch)
+
+		if err != nil {
+			return nil, err
+		}
+
+		patch, err := jsonpatch.DecodePatch(operations)
+
+		if err != nil {
+			return nil, fmt.Errorf("couldn't decode json patch: %w", err)
+		}
+
+		if data, err = patch.Apply(data); err != nil {
+			return nil, fmt.Errorf("couldn't apply json patch: %w", err)
+		}
+	}
+
+	return data, nil
+}
+
+func rewriteMetadata(metadata map[string]interface{}, field string, rewrite *edgev1alpha1.MetadataRewrite) {
+	if rewrite == nil {
+		return
+	}
+
+	values, _ := metadata[field].(map[string]interface{})
+
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+
+	for _, key := range rewrite.Remove {
+		delete(values, key)
+	}
+
+	for key, value := range rewrite.Set {
+		values[key] = value
+	}
+
+	if len(values) == 0 {
+		delete(metadata, field)
+	} else {
+		metadata[field] = values
+	}
+}
+
+// rewriteImages rewrites the registry of every image field in the value.
+func rewriteImages(value interface{}, rewrites []edgev1alpha1.ImageRegistryRewrite) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if image, ok := field.(string); ok && key == "image" {
+				v[key] = rewriteImage(image, rewrites)
+			} else {
+				v[key] = rewriteImages(field, rewrites)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = rewriteImages(v[i], rewrites)
+		}
+	}
+
+	return value
+}
+
+func rewriteImage(image string, rewrites []edgev1alpha1.ImageRegistryRewrite) string {
+	for _, rewrite := range rewrites {
+		from := strings.TrimSuffix(rewrite.From, "/")
+
+		if from == "" {
+			continue
+		}
+
+		if !strings.HasPrefix(image, from) {
+			continue
+		}
+
+		// only matches whole path segments, followed by the rest of the path, a tag or a digest
+		if rest := strings.TrimPrefix(image, from); rest == "" || strings.ContainsAny(rest[:1], "/:@") {
+			return strings.TrimSuffix(rewrite.To, "/") + rest
+		}
+	}
+
+	return image
+}
@@ -0,0 +1,303 @@
+package edge
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+
+	edgev1alpha1 "edge.jevv.dev/pkg/apis/edge/v1alpha1"
+	"edge.jevv.dev/pkg/controllers"
+)
+
+// stubCluster only serves the client of a cluster, which is its api reader too.
+type stubCluster struct {
+	cluster.Cluster
+	client client.Client
+}
+
+func (c *stubCluster) GetClient() client.Client {
+	return c.client
+}
+
+func (c *stubCluster) GetAPIReader() client.Reader {
+	return c.client
+}
+
+func raw(value string) *runtime.RawExtension {
+	return &runtime.RawExtension{Raw: []byte(value)}
+}
+
+func testDeployment(image string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "app",
+			Namespace: "default",
+			Labels: map[string]string{
+				controllers.EnvironmentLabel: "testA",
+				"team":                       "edge",
+			},
+			Annotations: map[string]string{"note": "remote"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{{Name: "init", Image: image}},
+					Containers: []corev1.Container{
+						{Name: "app", Image: image},
+						{Name: "sidecar", Image: "docker.io/envoyproxy/envoy:v1.24"},
+					},
+				},
+			},
+		},
+	}
+}
+
+var _ = Describe("mirror transformations", func() {
+	DescribeTable("rewriting image registries",
+		func(image string, expected string) {
+			rewrites := []edgev1alpha1.ImageRegistryRewrite{
+				{From: "gcr.io/project/", To: "registry.edge.local/mirror/"},
+				{From: "docker.io", To: "registry.edge.local/docker"},
+			}
+
+			Expect(rewriteImage(image, rewrites)).To(Equal(expected))
+		},
+		Entry("path", "gcr.io/project/app", "registry.edge.local/mirror/app"),
+		Entry("tag", "gcr.io/project/app:v1", "registry.edge.local/mirror/app:v1"),
+		Entry("digest", "gcr.io/project/app@sha256:abcd", "registry.edge.local/mirror/app@sha256:abcd"),
+		Entry("registry only", "docker.io", "registry.edge.local/docker"),
+		Entry("registry with tag", "docker.io:latest", "registry.edge.local/docker:latest"),
+		Entry("partial segment", "gcr.io/projectx/app", "gcr.io/projectx/app"),
+		Entry("other registry", "quay.io/project/app", "quay.io/project/app"),
+		Entry("first matching rewrite", "docker.io/library/nginx", "registry.edge.local/docker/library/nginx"),
+	)
+
+	It("skips image rewrites without a registry", func() {
+		Expect(rewriteImage("gcr.io/app", []edgev1alpha1.ImageRegistryRewrite{{From: "/", To: "registry.edge.local"}})).To(Equal("gcr.io/app"))
+	})
+
+	DescribeTable("applying to typed objects",
+		func(spec edgev1alpha1.MirrorTransformationSpec, check func(*appsv1.Deployment)) {
+			data, err := json.Marshal(testDeployment("gcr.io/project/app:v1"))
+			Expect(err).NotTo(HaveOccurred())
+
+			data, err = applyTransformation(&spec, data, &appsv1.Deployment{})
+			Expect(err).NotTo(HaveOccurred())
+
+			deployment := &appsv1.Deployment{}
+			Expect(json.Unmarshal(data, deployment)).To(Succeed())
+
+			check(deployment)
+		},
+		Entry("without changes", edgev1alpha1.MirrorTransformationSpec{}, func(d *appsv1.Deployment) {
+			Expect(d).To(Equal(testDeployment("gcr.io/project/app:v1")))
+		}),
+		Entry("labels and annotations", edgev1alpha1.MirrorTransformationSpec{
+			Labels:      &edgev1alpha1.MetadataRewrite{Set: map[string]string{"site": "edge-1", "team": "ops"}},
+			Annotations: &edgev1alpha1.MetadataRewrite{Remove: []string{"note"}},
+		}, func(d *appsv1.Deployment) {
+			Expect(d.Labels).To(Equal(map[string]string{controllers.EnvironmentLabel: "testA", "team": "ops", "site": "edge-1"}))
+			Expect(d.Annotations).To(BeEmpty())
+		}),
+		Entry("images of every container", edgev1alpha1.MirrorTransformationSpec{
+			ImageRegistries: []edgev1alpha1.ImageRegistryRewrite{{From: "gcr.io/project", To: "registry.edge.local"}},
+		}, func(d *appsv1.Deployment) {
+			Expect(d.Spec.Template.Spec.InitContainers[0].Image).To(Equal("registry.edge.local/app:v1"))
+			Expect(d.Spec.Template.Spec.Containers[0].Image).To(Equal("registry.edge.local/app:v1"))
+			Expect(d.Spec.Template.Spec.Containers[1].Image).To(Equal("docker.io/envoyproxy/envoy:v1.24"))
+		}),
+		Entry("strategic merge patch merging containers by name", edgev1alpha1.MirrorTransformationSpec{
+			MergePatch: raw(`{"spec":{"template":{"spec":{"containers":[{"name":"app","resources":{"limits":{"memory":"128Mi"}}}]}}}}`),
+		}, func(d *appsv1.Deployment) {
+			Expect(d.Spec.Template.Spec.Containers).To(HaveLen(2))
+			Expect(d.Spec.Template.Spec.Containers[0].Image).To(Equal("gcr.io/project/app:v1"))
+			Expect(d.Spec.Template.Spec.Containers[0].Resources.Limits.Memory().Equal(resource.MustParse("128Mi"))).To(BeTrue())
+		}),
+		Entry("json patch", edgev1alpha1.MirrorTransformationSpec{
+			JSONPatch: []edgev1alpha1.JSONPatchOperation{
+				{Op: "replace", Path: "/spec/template/spec/containers/1/image", Value: raw(`"registry.edge.local/envoy:v1.24"`)},
+				{Op: "add", Path: "/metadata/labels/site", Value: raw(`"edge-1"`)},
+				{Op: "remove", Path: "/spec/template/spec/initContainers"},
+			},
+		}, func(d *appsv1.Deployment) {
+			Expect(d.Spec.Template.Spec.Containers[1].Image).To(Equal("registry.edge.local/envoy:v1.24"))
+			Expect(d.Labels).To(HaveKeyWithValue("site", "edge-1"))
+			Expect(d.Spec.Template.Spec.InitContainers).To(BeEmpty())
+		}),
+		Entry("rewrites before patches", edgev1alpha1.MirrorTransformationSpec{
+			ImageRegistries: []edgev1alpha1.ImageRegistryRewrite{{From: "gcr.io/project", To: "registry.edge.local"}},
+			JSONPatch: []edgev1alpha1.JSONPatchOperation{
+				{Op: "test", Path: "/spec/template/spec/containers/0/image", Value: raw(`"registry.edge.local/app:v1"`)},
+			},
+		}, func(d *appsv1.Deployment) {
+			Expect(d.Spec.Template.Spec.Containers[0].Image).To(Equal("registry.edge.local/app:v1"))
+		}),
+	)
+
+	It("applies json merge patches to unstructured objects", func() {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "Widget",
+			"metadata":   map[string]interface{}{"name": "widget"},
+			"spec": map[string]interface{}{
+				"replicas": int64(3),
+				"ports":    []interface{}{int64(80), int64(443)},
+			},
+		}}
+
+		data, err := json.Marshal(obj)
+		Expect(err).NotTo(HaveOccurred())
+
+		spec := &edgev1alpha1.MirrorTransformationSpec{
+			MergePatch: raw(`{"spec":{"replicas":null,"ports":[8080]}}`),
+		}
+
+		data, err = applyTransformation(spec, data, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		// json merge patches replace lists and remove null fields
+		Expect(data).To(MatchJSON(`{"apiVersion":"example.com/v1","kind":"Widget","metadata":{"name":"widget"},"spec":{"ports":[8080]}}`))
+	})
+
+	DescribeTable("failing patches",
+		func(spec edgev1alpha1.MirrorTransformationSpec, patchSchema interface{}, message string) {
+			data, err := json.Marshal(testDeployment("gcr.io/project/app:v1"))
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = applyTransformation(&spec, data, patchSchema)
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("replacing a missing path", edgev1alpha1.MirrorTransformationSpec{
+			JSONPatch: []edgev1alpha1.JSONPatchOperation{{Op: "replace", Path: "/spec/missing/field", Value: raw(`1`)}},
+		}, &appsv1.Deployment{}, "couldn't apply json patch"),
+		Entry("failing test operation", edgev1alpha1.MirrorTransformationSpec{
+			JSONPatch: []edgev1alpha1.JSONPatchOperation{{Op: "test", Path: "/metadata/name", Value: raw(`"other"`)}},
+		}, &appsv1.Deployment{}, "couldn't apply json patch"),
+		Entry("unknown operation", edgev1alpha1.MirrorTransformationSpec{
+			JSONPatch: []edgev1alpha1.JSONPatchOperation{{Op: "rename", Path: "/metadata/name"}},
+		}, &appsv1.Deployment{}, "couldn't apply json patch"),
+		Entry("merge patch which isn't an object", edgev1alpha1.MirrorTransformationSpec{
+			MergePatch: raw(`[1]`),
+		}, &appsv1.Deployment{}, "couldn't apply merge patch"),
+		Entry("json merge patch which isn't json", edgev1alpha1.MirrorTransformationSpec{
+			MergePatch: raw(`{`),
+		}, nil, "couldn't apply merge patch"),
+	)
+
+	Describe("transforming objects", func() {
+		var transformer *Transformer
+
+		newTransformation := func(name string, spec edgev1alpha1.MirrorTransformationSpec) *edgev1alpha1.MirrorTransformation {
+			return &edgev1alpha1.MirrorTransformation{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "knative-edge-system"},
+				Spec:       spec,
+			}
+		}
+
+		BeforeEach(func() {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(edgev1alpha1.AddToScheme(scheme)).To(Succeed())
+
+			remote := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				// applied in the order of the names, so b-suffix sees the label set by a-site
+				newTransformation("b-suffix", edgev1alpha1.MirrorTransformationSpec{
+					JSONPatch: []edgev1alpha1.JSONPatchOperation{
+						{Op: "test", Path: "/metadata/labels/site", Value: raw(`"edge-1"`)},
+						{Op: "add", Path: "/metadata/labels/ordered", Value: raw(`"true"`)},
+					},
+				}),
+				newTransformation("a-site", edgev1alpha1.MirrorTransformationSpec{
+					Labels: &edgev1alpha1.MetadataRewrite{Set: map[string]string{"site": "edge-1"}},
+				}),
+				newTransformation("c-other-cluster", edgev1alpha1.MirrorTransformationSpec{
+					Clusters: []string{"edge-2"},
+					Labels:   &edgev1alpha1.MetadataRewrite{Set: map[string]string{"other-cluster": "true"}},
+				}),
+				newTransformation("d-other-env", edgev1alpha1.MirrorTransformationSpec{
+					Environments: []string{"testB"},
+					Labels:       &edgev1alpha1.MetadataRewrite{Set: map[string]string{"other-env": "true"}},
+				}),
+				newTransformation("e-configmaps", edgev1alpha1.MirrorTransformationSpec{
+					Resources: []edgev1alpha1.MirrorTransformationResource{{Kind: "ConfigMap"}},
+					Labels:    &edgev1alpha1.MetadataRewrite{Set: map[string]string{"configmap": "true"}},
+				}),
+				newTransformation("f-deployments", edgev1alpha1.MirrorTransformationSpec{
+					Clusters:     []string{"edge-1"},
+					Environments: []string{"testA"},
+					Resources:    []edgev1alpha1.MirrorTransformationResource{{Group: "apps", Kind: "Deployment"}},
+					MergePatch:   raw(`{"metadata":{"name":"moved","namespace":"elsewhere"},"spec":{"replicas":2}}`),
+				}),
+			).Build()
+
+			transformer = &Transformer{
+				Scheme:        scheme,
+				RemoteCluster: &stubCluster{client: remote},
+				ClusterName:   "edge-1",
+			}
+		})
+
+		It("applies the selected transformations in order", func() {
+			deployment := testDeployment("gcr.io/project/app:v1")
+
+			Expect(transformer.Transform(context.Background(), deployment)).To(Succeed())
+
+			Expect(deployment.Labels).To(Equal(map[string]string{
+				controllers.EnvironmentLabel: "testA",
+				"team":                       "edge",
+				"site":                       "edge-1",
+				"ordered":                    "true",
+			}))
+			Expect(*deployment.Spec.Replicas).To(BeEquivalentTo(2))
+			Expect(deployment.Spec.Template.Spec.Containers).To(HaveLen(2))
+		})
+
+		It("keeps the name and namespace", func() {
+			deployment := testDeployment("gcr.io/project/app:v1")
+
+			Expect(transformer.Transform(context.Background(), deployment)).To(Succeed())
+
+			Expect(deployment.Name).To(Equal("app"))
+			Expect(deployment.Namespace).To(Equal("default"))
+		})
+
+		It("selects transformations by kind", func() {
+			configMap := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "default"},
+				Data:       map[string]string{"foo": "bar"},
+			}
+
+			Expect(transformer.Transform(context.Background(), configMap)).To(Succeed())
+
+			Expect(configMap.Labels).To(Equal(map[string]string{"site": "edge-1", "ordered": "true", "configmap": "true"}))
+			Expect(configMap.Data).To(Equal(map[string]string{"foo": "bar"}))
+		})
+
+		It("fails with the name of the failing transformation", func() {
+			broken := newTransformation("0-broken", edgev1alpha1.MirrorTransformationSpec{
+				JSONPatch: []edgev1alpha1.JSONPatchOperation{{Op: "remove", Path: "/spec/missing"}},
+			})
+			Expect(transformer.RemoteCluster.GetClient().Create(context.Background(), broken)).To(Succeed())
+
+			deployment := testDeployment("gcr.io/project/app:v1")
+
+			Expect(transformer.Transform(context.Background(), deployment)).To(MatchError(ContainSubstring("couldn't apply mirror transformation 0-broken")))
+			Expect(deployment).To(Equal(testDeployment("gcr.io/project/app:v1")))
+		})
+	})
+})
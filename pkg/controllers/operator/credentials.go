@@ -0,0 +1,180 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"edge.jevv.dev/pkg/controllers"
+
+	operatorv1alpha1 "edge.jevv.dev/pkg/apis/operator/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// the referenced secrets can be in any namespace so they aren't watched, rotated credentials are
+// picked up on the next check instead
+const proxyCredentialsResyncPeriod = time.Minute
+
+func getProxyCredentialsName(name, namespace string) types.NamespacedName {
+	return types.NamespacedName{Name: fmt.Sprintf("%s-proxy-credentials", name), Namespace: namespace}
+}
+
+func hasProxyCredentials(edge *operatorv1alpha1.KnativeEdge) bool {
+	return edge != nil && (edge.Spec.RemoteAuth.TLSSecretRef != nil || edge.Spec.RemoteAuth.TokenSecretRef != nil)
+}
+
+func (r *EdgeReconciler) getReferencedSecret(ctx context.Context, edge *operatorv1alpha1.KnativeEdge, ref *corev1.SecretReference) (*corev1.Secret, error) {
+	namespace := ref.Namespace
+
+	if namespace == "" {
+		namespace = edge.Namespace
+	}
+
+	var secret corev1.Secret
+
+	if err := r.reader.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: namespace}, &secret); err != nil {
+		return nil, fmt.Errorf("couldn't retrieve secret %s/%s: %w", namespace, ref.Name, err)
+	}
+
+	return &secret, nil
+}
+
+// collectProxyCredentials merges the referenced secrets into the data of a single secret, which
+// is what the edge proxies mount.
+func (r *EdgeReconciler) collectProxyCredentials(ctx context.Context, edge *operatorv1alpha1.KnativeEdge) (map[string][]byte, error) {
+	data := make(map[string][]byte)
+	auth := edge.Spec.RemoteAuth
+
+	if auth.TLSSecretRef != nil {
+		secret, err := r.getReferencedSecret(ctx, edge, auth.TLSSecretRef)
+
+		if err != nil {
+			return nil, err
+		}
+
+		cert, hasCert := secret.Data[corev1.TLSCertKey]
+		key, hasKey := secret.Data[corev1.TLSPrivateKeyKey]
+
+		if hasCert != hasKey {
+			return nil, fmt.Errorf("secret %s must contain both %s and %s", secret.Name, corev1.TLSCertKey, corev1.TLSPrivateKeyKey)
+		}
+
+		if hasCert {
+			data[corev1.TLSCertKey] = cert
+			data[corev1.TLSPrivateKeyKey] = key
+		}
+
+		if ca, exists := secret.Data[controllers.ProxyCACertKey]; exists {
+			data[controllers.ProxyCACertKey] = ca
+		}
+	}
+
+	if auth.TokenSecretRef != nil {
+		secret, err := r.getReferencedSecret(ctx, edge, auth.TokenSecretRef)
+
+		if err != nil {
+			return nil, err
+		}
+
+		token, exists := secret.Data[controllers.ProxyTokenKey]
+
+		if !exists {
+			return nil, fmt.Errorf("secret %s doesn't contain %s", secret.Name, controllers.ProxyTokenKey)
+		}
+
+		data[controllers.ProxyTokenKey] = token
+	}
+
+	return data, nil
+}
+
+func (r *EdgeReconciler) reconcileProxyCredentials(ctx context.Context, edge *operatorv1alpha1.KnativeEdge) (ctrl.Result, error) {
+	log := r.Log.V(controllers.InfoLevel)
+
+	// owned secret is garbage collected with the KnativeEdge
+	if edge == nil || edge.Name == "" || edge.DeletionTimestamp != nil {
+		return ctrl.Result{}, nil
+	}
+
+	systemClient := r.SystemCluster.GetClient()
+	namespacedName := getProxyCredentialsName(edge.Name, controllers.SystemNamespace)
+
+	exists := true
+	var secret corev1.Secret
+
+	if err := systemClient.Get(ctx, namespacedName, &secret); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+
+		exists = false
+	}
+
+	if !hasProxyCredentials(edge) {
+		if !exists {
+			return ctrl.Result{}, nil
+		}
+
+		log.Info("Deleting KnativeEdge proxy credentials.", "secret", namespacedName.String())
+
+		if err := systemClient.Delete(ctx, &secret); err != nil && !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	data, err := r.collectProxyCredentials(ctx, edge)
+
+	if err != nil {
+		r.Recorder.Event(edge, "Warning", "ProxyCredentialsError", fmt.Sprintf("Proxy credentials couldn't be collected: %s", err))
+		return ctrl.Result{RequeueAfter: proxyCredentialsResyncPeriod}, nil
+	}
+
+	if exists && reflect.DeepEqual(secret.Data, data) {
+		return ctrl.Result{RequeueAfter: proxyCredentialsResyncPeriod}, nil
+	}
+
+	secret.Name = namespacedName.Name
+	secret.Namespace = namespacedName.Namespace
+	secret.Labels = getLabels(namespacedName)
+	secret.Type = corev1.SecretTypeOpaque
+	secret.Data = data
+
+	controllerutil.SetControllerReference(edge, &secret, r.Scheme)
+
+	if !exists {
+		log.Info("Creating KnativeEdge proxy credentials.", "secret", namespacedName.String())
+
+		if err := systemClient.Create(ctx, &secret); err != nil {
+			if apierrors.IsAlreadyExists(err) {
+				return ctrl.Result{Requeue: true}, nil
+			}
+
+			return ctrl.Result{}, err
+		}
+
+		r.Recorder.Event(edge, "Normal", "ProxyCredentialsCreated", "Knative Edge proxy credentials have been created.")
+	} else {
+		log.Info("Updating KnativeEdge proxy credentials.", "secret", namespacedName.String())
+
+		if err := systemClient.Update(ctx, &secret); err != nil {
+			if apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+				return ctrl.Result{Requeue: true}, nil
+			}
+
+			return ctrl.Result{}, err
+		}
+
+		r.Recorder.Event(edge, "Normal", "ProxyCredentialsRotated", "Knative Edge proxy credentials have been updated.")
+	}
+
+	return ctrl.Result{RequeueAfter: proxyCredentialsResyncPeriod}, nil
+}
@@ -3,8 +3,7 @@ package operator
 import (
 	"context"
 	"fmt"
-	"net/http"
-	"net/url"
+	"sort"
 	"strings"
 	"time"
 
@@ -26,6 +25,7 @@ import (
 
 	"edge.jevv.dev/pkg/controllers"
 	edgecontrollers "edge.jevv.dev/pkg/controllers/edge"
+	"edge.jevv.dev/pkg/controllers/utils"
 
 	edgev1alpha1 "edge.jevv.dev/pkg/apis/edge/v1alpha1"
 	operatorv1alpha1 "edge.jevv.dev/pkg/apis/operator/v1alpha1"
@@ -95,7 +95,26 @@ func (r *EdgeReconciler) Reconcile(ctx context.Context, request ctrl.Request) (c
 		return result, err
 	}
 
-	return r.reconcileDeployment(ctx, &edge)
+	result, err = r.reconcileDeployment(ctx, &edge)
+
+	if err != nil || result.Requeue || result.RequeueAfter > 0 {
+		return result, err
+	}
+
+	upstreamsResult, err := r.reconcileUpstreams(ctx, &edge)
+
+	if err != nil || upstreamsResult.Requeue {
+		return upstreamsResult, err
+	}
+
+	result, err = r.reconcileProxyCredentials(ctx, &edge)
+
+	// both check the referenced secrets again after a while
+	if err == nil && !result.Requeue && result.RequeueAfter == 0 {
+		result.RequeueAfter = upstreamsResult.RequeueAfter
+	}
+
+	return result, err
 }
 
 func (r *EdgeReconciler) reconcileCluster(ctx context.Context, edge *operatorv1alpha1.KnativeEdge) (*corev1.Secret, error) {
@@ -159,15 +178,17 @@ func (r *EdgeReconciler) reconcileCluster(ctx context.Context, edge *operatorv1a
 			}
 
 			kubeconfig, err := config.ClientConfig()
-			kubeconfig.Proxy = func(req *http.Request) (*url.URL, error) {
-				return url.Parse(edge.Spec.Proxy.HttpsProxy)
-			}
 
 			if err != nil {
 				r.Recorder.Event(edge, "Warning", "KubeconfigParsingError", fmt.Sprintf("Kubeconfig couldn't be retrieved: %s", err))
 				return nil, nil
 			}
 
+			// same proxy as the edge controller, so the operator works from the same sites
+			if proxy := utils.ProxyFunc(edge.Spec.Proxy.HttpProxy, edge.Spec.Proxy.HttpsProxy, edge.Spec.Proxy.NoProxy); proxy != nil {
+				kubeconfig.Proxy = proxy
+			}
+
 			// resync cache once in a while
 			// TODO: check later if disabling cache would be better
 			remoteCluster, err = cluster.New(kubeconfig, func(o *cluster.Options) {
@@ -450,6 +471,10 @@ func getDeploymentName(name, namespace string) types.NamespacedName {
 	return types.NamespacedName{Name: fmt.Sprintf("%s-controller", name), Namespace: namespace}
 }
 
+func getTrafficStoreName(name, namespace string) types.NamespacedName {
+	return types.NamespacedName{Name: fmt.Sprintf("%s-traffic", name), Namespace: namespace}
+}
+
 func (r *EdgeReconciler) buildSecret(namespacedName types.NamespacedName, edge *operatorv1alpha1.KnativeEdge, src, dst *corev1.Secret) {
 	if src == nil {
 		return
@@ -476,6 +501,7 @@ func (r *EdgeReconciler) buildSecret(namespacedName types.NamespacedName, edge *
 
 func (r *EdgeReconciler) buildDeployment(namespacedName, namespacedSecretName types.NamespacedName, edge *operatorv1alpha1.KnativeEdge, edgeCluster *edgev1alpha1.EdgeCluster, deployment *appsv1.Deployment) {
 	replicas := int32(1)
+	optional := true
 	labels := getLabels(namespacedName)
 
 	labels[controllers.EdgeTagLabel] = "TODO"
@@ -485,6 +511,11 @@ func (r *EdgeReconciler) buildDeployment(namespacedName, namespacedSecretName ty
 		proxyImage = edge.Spec.OverrideProxyImage
 	}
 
+	var proxyCredentials string
+	if hasProxyCredentials(edge) {
+		proxyCredentials = getProxyCredentialsName(edge.Name, namespacedName.Namespace).Name
+	}
+
 	annotations := deployment.Annotations
 	if annotations == nil {
 		annotations = make(map[string]string)
@@ -514,12 +545,28 @@ func (r *EdgeReconciler) buildDeployment(namespacedName, namespacedSecretName ty
 						Name:  "knative-edge-controller",
 						Args: []string{
 							"--envs", strings.Join(edgeCluster.Spec.Environments, ","),
+							"--cluster-name", edge.Spec.ClusterName,
 							"--proxy-image", proxyImage,
 							"--remote-url", edge.Spec.ClusterHostnameOrIp,
+							"--upstreams", formatUpstreams(edge.Spec.Upstreams),
 							"--http-proxy", edge.Spec.Proxy.HttpProxy,
 							"--https-proxy", edge.Spec.Proxy.HttpsProxy,
 							"--no-proxy", edge.Spec.Proxy.NoProxy,
 							"--prometheus-url", edge.Spec.Prometheus.URL,
+							"--otlp-endpoint", edge.Spec.Tracing.OtlpEndpoint,
+							"--proxy-credentials-secret", proxyCredentials,
+							"--proxy-token-header", edge.Spec.RemoteAuth.TokenHeader,
+							"--traffic-store", "configmap",
+							"--traffic-store-name", getTrafficStoreName(edge.Name, namespacedName.Namespace).Name,
+							"--traffic-store-namespace", namespacedName.Namespace,
+							"--snapshot-name", fmt.Sprintf("%s-snapshot", namespacedName.Name),
+							"--snapshot-namespace", namespacedName.Namespace,
+							"--drift-policy", edge.Spec.Drift.DefaultPolicy,
+							"--drift-policies", formatDriftPolicies(edge.Spec.Drift.Policies),
+							"--peer-url", edge.Spec.PeerOffload.URL,
+							"--peer-max-utilization", fmt.Sprint(edge.Spec.PeerOffload.MaxUtilization),
+							// bool flags only take their value after =
+							fmt.Sprintf("--peer-offload=%t", edge.Spec.PeerOffload.Enabled),
 						},
 						VolumeMounts: []corev1.VolumeMount{
 							{
@@ -527,6 +574,11 @@ func (r *EdgeReconciler) buildDeployment(namespacedName, namespacedSecretName ty
 								MountPath: edgecontrollers.ConfigPath,
 								ReadOnly:  true,
 							},
+							{
+								Name:      "upstreams",
+								MountPath: edgecontrollers.UpstreamsConfigPath,
+								ReadOnly:  true,
+							},
 						},
 					},
 				},
@@ -539,6 +591,16 @@ func (r *EdgeReconciler) buildDeployment(namespacedName, namespacedSecretName ty
 							},
 						},
 					},
+					{
+						Name: "upstreams",
+						VolumeSource: corev1.VolumeSource{
+							Secret: &corev1.SecretVolumeSource{
+								SecretName: getUpstreamsName(edge.Name, namespacedName.Namespace).Name,
+								// only exists when there are upstreams
+								Optional: &optional,
+							},
+						},
+					},
 				},
 				// experiments: temporary
 				NodeSelector: map[string]string{
@@ -551,6 +613,19 @@ func (r *EdgeReconciler) buildDeployment(namespacedName, namespacedSecretName ty
 	controllerutil.SetControllerReference(edge, deployment, r.Scheme)
 }
 
+// formatDriftPolicies formats the drift policies as the kind=policy pairs of the controller flag.
+func formatDriftPolicies(policies map[string]string) string {
+	pairs := make([]string, 0, len(policies))
+
+	for kind, policy := range policies {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", kind, policy))
+	}
+
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ",")
+}
+
 func getLabels(namespacedName types.NamespacedName) map[string]string {
 	return map[string]string{
 		controllers.AppLabel:        "controller",
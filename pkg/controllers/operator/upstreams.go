@@ -0,0 +1,161 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"edge.jevv.dev/pkg/controllers"
+	edgecontrollers "edge.jevv.dev/pkg/controllers/edge"
+
+	operatorv1alpha1 "edge.jevv.dev/pkg/apis/operator/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// like the proxy credentials, the referenced kubeconfigs aren't watched
+const upstreamsResyncPeriod = time.Minute
+
+func getUpstreamsName(name, namespace string) types.NamespacedName {
+	return types.NamespacedName{Name: fmt.Sprintf("%s-upstreams", name), Namespace: namespace}
+}
+
+// collectUpstreams merges the kubeconfigs of the upstreams into the data of a single secret, keyed
+// by the names of the upstreams, which is what the edge controller mounts.
+func (r *EdgeReconciler) collectUpstreams(ctx context.Context, edge *operatorv1alpha1.KnativeEdge) (map[string][]byte, error) {
+	data := make(map[string][]byte)
+
+	for i := range edge.Spec.Upstreams {
+		upstream := &edge.Spec.Upstreams[i]
+
+		if upstream.Name == edgecontrollers.PrimaryUpstream {
+			return nil, fmt.Errorf("upstream name %s is reserved", upstream.Name)
+		}
+
+		if _, exists := data[upstream.Name]; exists {
+			return nil, fmt.Errorf("duplicate upstream %s", upstream.Name)
+		}
+
+		secret, err := r.getReferencedSecret(ctx, edge, &upstream.SecretRef)
+
+		if err != nil {
+			return nil, err
+		}
+
+		kubeconfig, exists := secret.Data["kubeconfig"]
+
+		if !exists {
+			return nil, fmt.Errorf("secret %s of upstream %s doesn't contain kubeconfig", secret.Name, upstream.Name)
+		}
+
+		data[upstream.Name] = kubeconfig
+	}
+
+	return data, nil
+}
+
+func (r *EdgeReconciler) reconcileUpstreams(ctx context.Context, edge *operatorv1alpha1.KnativeEdge) (ctrl.Result, error) {
+	log := r.Log.V(controllers.InfoLevel)
+
+	// owned secret is garbage collected with the KnativeEdge
+	if edge == nil || edge.Name == "" || edge.DeletionTimestamp != nil {
+		return ctrl.Result{}, nil
+	}
+
+	systemClient := r.SystemCluster.GetClient()
+	namespacedName := getUpstreamsName(edge.Name, controllers.SystemNamespace)
+
+	exists := true
+	var secret corev1.Secret
+
+	if err := systemClient.Get(ctx, namespacedName, &secret); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+
+		exists = false
+	}
+
+	if len(edge.Spec.Upstreams) == 0 {
+		if !exists {
+			return ctrl.Result{}, nil
+		}
+
+		log.Info("Deleting KnativeEdge upstreams.", "secret", namespacedName.String())
+
+		if err := systemClient.Delete(ctx, &secret); err != nil && !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	data, err := r.collectUpstreams(ctx, edge)
+
+	if err != nil {
+		r.Recorder.Event(edge, "Warning", "UpstreamsError", fmt.Sprintf("Upstream kubeconfigs couldn't be collected: %s", err))
+		return ctrl.Result{RequeueAfter: upstreamsResyncPeriod}, nil
+	}
+
+	if exists && reflect.DeepEqual(secret.Data, data) {
+		return ctrl.Result{RequeueAfter: upstreamsResyncPeriod}, nil
+	}
+
+	secret.Name = namespacedName.Name
+	secret.Namespace = namespacedName.Namespace
+	secret.Labels = getLabels(namespacedName)
+	secret.Type = corev1.SecretTypeOpaque
+	secret.Data = data
+
+	controllerutil.SetControllerReference(edge, &secret, r.Scheme)
+
+	if !exists {
+		log.Info("Creating KnativeEdge upstreams.", "secret", namespacedName.String())
+
+		if err := systemClient.Create(ctx, &secret); err != nil {
+			if apierrors.IsAlreadyExists(err) {
+				return ctrl.Result{Requeue: true}, nil
+			}
+
+			return ctrl.Result{}, err
+		}
+
+		r.Recorder.Event(edge, "Normal", "UpstreamsCreated", "Knative Edge upstream kubeconfigs have been created.")
+	} else {
+		log.Info("Updating KnativeEdge upstreams.", "secret", namespacedName.String())
+
+		if err := systemClient.Update(ctx, &secret); err != nil {
+			if apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+				return ctrl.Result{Requeue: true}, nil
+			}
+
+			return ctrl.Result{}, err
+		}
+
+		r.Recorder.Event(edge, "Normal", "UpstreamsUpdated", "Knative Edge upstream kubeconfigs have been updated.")
+	}
+
+	return ctrl.Result{RequeueAfter: upstreamsResyncPeriod}, nil
+}
+
+// formatUpstreams formats the upstreams as the name=priority=url entries of the controller flag,
+// sorted by name.
+func formatUpstreams(upstreams []operatorv1alpha1.KnativeEdgeUpstream) string {
+	entries := make([]string, 0, len(upstreams))
+
+	for _, upstream := range upstreams {
+		entries = append(entries, fmt.Sprintf("%s=%d=%s", upstream.Name, upstream.Priority, upstream.ClusterHostnameOrIp))
+	}
+
+	sort.Strings(entries)
+
+	return strings.Join(entries, ",")
+}
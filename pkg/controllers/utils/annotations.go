@@ -13,10 +13,12 @@ func UpdateLastGenerationAnnotation(kind client.Object) {
 
 	if annotations == nil {
 		annotations = make(map[string]string)
-		kind.SetAnnotations(annotations)
 	}
 
 	annotations[controllers.LastGenerationAnnotation] = fmt.Sprint(kind.GetResourceVersion())
+
+	// unstructured objects return a copy of their annotations
+	kind.SetAnnotations(annotations)
 }
 
 func UpdateLastRemoteGenerationAnnotation(localKind, remoteKind client.Object) {
@@ -24,10 +26,10 @@ func UpdateLastRemoteGenerationAnnotation(localKind, remoteKind client.Object) {
 
 	if annotations == nil {
 		annotations = make(map[string]string)
-		localKind.SetAnnotations(annotations)
 	}
 
 	annotations[controllers.LastRemoteGenerationAnnotation] = fmt.Sprint(remoteKind.GetResourceVersion())
+	localKind.SetAnnotations(annotations)
 }
 
 func UpdateLabels(object client.Object) {
@@ -35,11 +37,13 @@ func UpdateLabels(object client.Object) {
 
 	if labels == nil {
 		labels = make(map[string]string)
-		object.SetLabels(labels)
 	}
 
 	labels[controllers.AppLabel] = "knative-edge"
 	labels[controllers.ManagedLabel] = "true"
 	labels[controllers.ManagedByLabel] = "knative-edge"
 	labels[controllers.CreatedByLabel] = "knative-edge-controller"
+
+	// unstructured objects return a copy of their labels
+	object.SetLabels(labels)
 }
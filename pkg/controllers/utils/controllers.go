@@ -40,6 +40,13 @@ func GetConfigurationNamespacedName(namespacedName types.NamespacedName) types.N
 	}
 }
 
+func GetEdgeServiceStatusNamespacedName(namespacedName types.NamespacedName, clusterName string) types.NamespacedName {
+	return types.NamespacedName{
+		Name:      fmt.Sprintf("%s-%s", namespacedName.Name, clusterName),
+		Namespace: namespacedName.Namespace,
+	}
+}
+
 func GetServiceNameFromConfiguration(configuration *servingv1.Configuration) string {
 	if !strings.HasSuffix(configuration.Name, EdgeProxySuffix) {
 		return ""
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("proxy", func() {
+	It("has no proxy without proxies", func() {
+		Expect(ProxyFunc("", "", "")).To(BeNil())
+		Expect(ProxyFunc("", "", "remote.example.com")).To(BeNil())
+	})
+
+	DescribeTable("picking the proxy of requests",
+		func(httpProxy, httpsProxy, noProxy, target, expected string) {
+			proxy := ProxyFunc(httpProxy, httpsProxy, noProxy)
+			Expect(proxy).NotTo(BeNil())
+
+			req, err := http.NewRequest(http.MethodGet, target, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			proxyURL, err := proxy(req)
+			Expect(err).NotTo(HaveOccurred())
+
+			if expected == "" {
+				Expect(proxyURL).To(BeNil())
+			} else {
+				Expect(proxyURL).NotTo(BeNil())
+				Expect(proxyURL.String()).To(Equal(expected))
+			}
+		},
+		Entry("http", "http://proxy:3128", "", "", "http://remote.example.com", "http://proxy:3128"),
+		Entry("https", "http://proxy:3128", "http://secure-proxy:3129", "", "https://remote.example.com:6443", "http://secure-proxy:3129"),
+		Entry("https without https proxy", "http://proxy:3128", "", "", "https://remote.example.com:6443", ""),
+		Entry("proxy without scheme", "proxy:3128", "", "", "http://remote.example.com", "http://proxy:3128"),
+		Entry("no proxy host", "http://proxy:3128", "", "remote.example.com", "http://remote.example.com", ""),
+		Entry("no proxy host of another port", "http://proxy:3128", "", "remote.example.com:8080", "http://remote.example.com", "http://proxy:3128"),
+		Entry("no proxy domain", "http://proxy:3128", "", ".example.com", "http://remote.example.com", ""),
+		Entry("no proxy domain without dot", "http://proxy:3128", "", "example.com", "http://remote.example.com", ""),
+		Entry("no proxy of other domains", "http://proxy:3128", "", "example.com", "http://remote.example.org", "http://proxy:3128"),
+		Entry("no proxy list", "http://proxy:3128", "", "other.example.org, remote.example.com", "http://remote.example.com", ""),
+		Entry("no proxy cidr", "http://proxy:3128", "", "10.0.0.0/8", "http://10.96.0.1", ""),
+		Entry("outside the no proxy cidr", "http://proxy:3128", "", "10.0.0.0/8", "http://192.168.0.1", "http://proxy:3128"),
+		Entry("no proxy wildcard", "http://proxy:3128", "", "*", "http://remote.example.com", ""),
+		Entry("localhost", "http://proxy:3128", "", "", "http://localhost:8080", ""),
+		Entry("loopback", "http://proxy:3128", "", "", "http://127.0.0.1:8080", ""),
+	)
+})
@@ -0,0 +1,23 @@
+package heartbeat
+
+import "time"
+
+const (
+	// how often the edge controller reports its status
+	ReportPeriod = 30 * time.Second
+	// how often the cloud checks for stale EdgeClusters
+	MonitorPeriod = 30 * time.Second
+	// an EdgeCluster is considered unreachable after missing this many reports
+	UnreachableAfter = 3 * ReportPeriod
+)
+
+const (
+	ReasonHeartbeatReported     = "HeartbeatReported"
+	ReasonHeartbeatTimeout      = "HeartbeatTimeout"
+	ReasonResourcesInSync       = "ResourcesInSync"
+	ReasonResourcesOutOfSync    = "ResourcesOutOfSync"
+	ReasonStatusCollectionError = "StatusCollectionFailed"
+	ReasonAsExpected            = "AsExpected"
+	ReasonNoLocalChanges        = "NoLocalChanges"
+	ReasonLocalChangesKept      = "LocalChangesKept"
+)
@@ -0,0 +1,115 @@
+package heartbeat
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	edgev1alpha1 "edge.jevv.dev/pkg/apis/edge/v1alpha1"
+	"edge.jevv.dev/pkg/controllers"
+)
+
+//+kubebuilder:rbac:groups=edge.jevv.dev,resources=edgeclusters,verbs=get;list;watch
+//+kubebuilder:rbac:groups=edge.jevv.dev,resources=edgeclusters/status,verbs=get;update;patch
+
+// EdgeClusterMonitor runs in the cloud and marks EdgeClusters with a stale heartbeat as unreachable.
+type EdgeClusterMonitor struct {
+	client.Client
+
+	Log              logr.Logger
+	UnreachableAfter time.Duration
+}
+
+func (m *EdgeClusterMonitor) NeedLeaderElection() bool {
+	return true
+}
+
+func (m *EdgeClusterMonitor) Start(ctx context.Context) error {
+	debug := m.Log.V(controllers.DebugLevel)
+	log := m.Log.V(controllers.InfoLevel)
+
+	if m.UnreachableAfter <= 0 {
+		m.UnreachableAfter = UnreachableAfter
+	}
+
+	log.Info("Starting EdgeCluster monitor runnable.", "unreachableAfter", m.UnreachableAfter)
+
+	go func() {
+		for {
+			timeoutCtx, timeoutCancel := context.WithTimeout(context.Background(), MonitorPeriod)
+
+			select {
+			case <-timeoutCtx.Done():
+				timeoutCancel()
+			case <-ctx.Done():
+				timeoutCancel()
+				return
+			}
+
+			if err := m.run(ctx); err != nil {
+				debug.Error(err, "Encountered an error while checking EdgeCluster heartbeats. Will skip this run.")
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (m *EdgeClusterMonitor) run(ctx context.Context) error {
+	log := m.Log.V(controllers.InfoLevel)
+
+	var edgeClusters edgev1alpha1.EdgeClusterList
+
+	if err := m.List(ctx, &edgeClusters); err != nil {
+		return fmt.Errorf("couldn't list edge clusters: %w", err)
+	}
+
+	now := time.Now()
+
+	for i := range edgeClusters.Items {
+		edgeCluster := &edgeClusters.Items[i]
+
+		if edgeCluster.Status.Phase == edgev1alpha1.EdgeClusterPhaseUnreachable {
+			continue
+		}
+
+		lastReportedAt := edgeCluster.CreationTimestamp.Time
+
+		if edgeCluster.Status.LastReportedAt != "" {
+			if reportedAt, err := time.Parse(time.RFC3339, edgeCluster.Status.LastReportedAt); err == nil {
+				lastReportedAt = reportedAt
+			}
+		}
+
+		if now.Sub(lastReportedAt) < m.UnreachableAfter {
+			continue
+		}
+
+		log.Info("EdgeCluster heartbeat is stale, marking as unreachable.", "EdgeCluster/Name", edgeCluster.Name, "lastReportedAt", edgeCluster.Status.LastReportedAt)
+
+		patch := client.MergeFrom(edgeCluster.DeepCopy())
+
+		edgeCluster.Status.Phase = edgev1alpha1.EdgeClusterPhaseUnreachable
+
+		meta.SetStatusCondition(&edgeCluster.Status.Conditions, metav1.Condition{
+			Type:               edgev1alpha1.EdgeClusterConditionConnected,
+			Status:             metav1.ConditionFalse,
+			Reason:             ReasonHeartbeatTimeout,
+			Message:            fmt.Sprintf("Edge controller hasn't reported in the last %s.", m.UnreachableAfter),
+			ObservedGeneration: edgeCluster.Generation,
+		})
+
+		if err := m.Status().Patch(ctx, edgeCluster, patch); err != nil {
+			return fmt.Errorf("couldn't patch edge cluster %s status: %w", edgeCluster.Name, err)
+		}
+	}
+
+	return nil
+}
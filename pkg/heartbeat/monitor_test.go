@@ -0,0 +1,64 @@
+package heartbeat
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	edgev1alpha1 "edge.jevv.dev/pkg/apis/edge/v1alpha1"
+)
+
+var _ = Describe("EdgeCluster monitor", func() {
+	// the entries are built with the tree, so they share the same time
+	now := time.Now()
+
+	newEdgeCluster := func(created, reported time.Duration, phase edgev1alpha1.EdgeClusterPhase) edgev1alpha1.EdgeCluster {
+		edgeCluster := edgev1alpha1.EdgeCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "edge", CreationTimestamp: metav1.NewTime(now.Add(-created))},
+			Status:     edgev1alpha1.EdgeClusterStatus{Phase: phase},
+		}
+
+		if reported > 0 {
+			edgeCluster.Status.LastReportedAt = now.Add(-reported).UTC().Format(time.RFC3339)
+		}
+
+		return edgeCluster
+	}
+
+	DescribeTable("marking stale EdgeClusters as unreachable",
+		func(edgeCluster edgev1alpha1.EdgeCluster, unreachable bool) {
+			c := &stubClient{lists: []client.ObjectList{&edgev1alpha1.EdgeClusterList{Items: []edgev1alpha1.EdgeCluster{edgeCluster}}}}
+			monitor := &EdgeClusterMonitor{Client: c, Log: logr.Discard(), UnreachableAfter: time.Minute}
+
+			Expect(monitor.run(context.Background())).To(Succeed())
+
+			if !unreachable {
+				Expect(c.patched).To(BeEmpty())
+				return
+			}
+
+			Expect(c.patched).To(HaveLen(1))
+
+			patched := c.patched[0].(*edgev1alpha1.EdgeCluster)
+			Expect(patched.Status.Phase).To(Equal(edgev1alpha1.EdgeClusterPhaseUnreachable))
+
+			condition := meta.FindStatusCondition(patched.Status.Conditions, edgev1alpha1.EdgeClusterConditionConnected)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(ReasonHeartbeatTimeout))
+		},
+		Entry("recent heartbeat", newEdgeCluster(time.Hour, 10*time.Second, edgev1alpha1.EdgeClusterPhaseReady), false),
+		Entry("stale heartbeat", newEdgeCluster(time.Hour, 5*time.Minute, edgev1alpha1.EdgeClusterPhaseReady), true),
+		Entry("stale heartbeat of a degraded cluster", newEdgeCluster(time.Hour, 5*time.Minute, edgev1alpha1.EdgeClusterPhaseDegraded), true),
+		Entry("already unreachable", newEdgeCluster(time.Hour, 5*time.Minute, edgev1alpha1.EdgeClusterPhaseUnreachable), false),
+		Entry("new cluster which never reported", newEdgeCluster(10*time.Second, 0, ""), false),
+		Entry("old cluster which never reported", newEdgeCluster(time.Hour, 0, ""), true),
+	)
+})
@@ -0,0 +1,337 @@
+package heartbeat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+
+	edgev1alpha1 "edge.jevv.dev/pkg/apis/edge/v1alpha1"
+	"edge.jevv.dev/pkg/controllers"
+	"edge.jevv.dev/pkg/controllers/edge"
+	"edge.jevv.dev/pkg/workoffload/prometheus/usage"
+	"edge.jevv.dev/pkg/workoffload/store"
+)
+
+type MirroredKind struct {
+	Name    string
+	NewList func() client.ObjectList
+}
+
+var DefaultMirroredKinds = []MirroredKind{
+	{Name: "Namespace", NewList: func() client.ObjectList { return &corev1.NamespaceList{} }},
+	{Name: "Secret", NewList: func() client.ObjectList { return &corev1.SecretList{} }},
+	{Name: "ConfigMap", NewList: func() client.ObjectList { return &corev1.ConfigMapList{} }},
+	{Name: "Service.serving.knative.dev", NewList: func() client.ObjectList { return &servingv1.ServiceList{} }},
+}
+
+// EdgeHeartbeat periodically patches the status of the EdgeCluster in the remote cluster.
+type EdgeHeartbeat struct {
+	client.Client
+
+	Log           logr.Logger
+	RemoteCluster cluster.Cluster
+	Store         store.TrafficStore
+	Drift         *edge.DriftTracker
+
+	// reports the utilization of the nodes, so the peers of the edge cluster know whether they can
+	// offload to it
+	Usage *usage.Collector
+
+	ClusterName string
+	Version     string
+	PeerUrl     string
+	Kinds       []MirroredKind
+}
+
+func (h *EdgeHeartbeat) NeedLeaderElection() bool {
+	// only the leader mirrors resources, so only the leader should report
+	return true
+}
+
+func (h *EdgeHeartbeat) Start(ctx context.Context) error {
+	debug := h.Log.V(controllers.DebugLevel)
+	log := h.Log.V(controllers.InfoLevel)
+
+	if h.ClusterName == "" {
+		log.Info("No cluster name provided, EdgeCluster status won't be reported.")
+		return nil
+	}
+
+	if h.Store == nil {
+		return fmt.Errorf("no traffic split store provided")
+	}
+
+	if h.Kinds == nil {
+		h.Kinds = DefaultMirroredKinds
+	}
+
+	log.Info("Starting edge heartbeat runnable.", "cluster", h.ClusterName)
+
+	go func() {
+		for {
+			if err := h.report(ctx); err != nil {
+				debug.Error(err, "Encountered an error while reporting EdgeCluster status. Will retry next period.")
+			}
+
+			timeoutCtx, timeoutCancel := context.WithTimeout(context.Background(), ReportPeriod)
+
+			select {
+			case <-timeoutCtx.Done():
+				timeoutCancel()
+			case <-ctx.Done():
+				timeoutCancel()
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// report patches the EdgeCluster of every upstream, since each of them offloads to the edge
+// cluster. Only the primary upstream must have one.
+func (h *EdgeHeartbeat) report(ctx context.Context) error {
+	var errs []string
+
+	for _, upstream := range edge.AllUpstreams(h.RemoteCluster) {
+		if err := h.reportTo(ctx, upstream); err != nil {
+			errs = append(errs, fmt.Sprintf("upstream %s: %s", upstream.Name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+func (h *EdgeHeartbeat) reportTo(ctx context.Context, upstream edge.Upstream) error {
+	var edgeCluster edgev1alpha1.EdgeCluster
+
+	// EdgeClusters aren't labeled with an environment, so they're not in the remote cache
+	if err := upstream.Cluster.GetAPIReader().Get(ctx, types.NamespacedName{Name: h.ClusterName}, &edgeCluster); err != nil {
+		if apierrors.IsNotFound(err) && upstream.Name != edge.PrimaryUpstream {
+			h.Log.V(controllers.DebugLevel).Info("no edge cluster in upstream, not reporting", "upstream", upstream.Name)
+			return nil
+		}
+
+		return fmt.Errorf("couldn't retrieve edge cluster %s: %w", h.ClusterName, err)
+	}
+
+	patch := client.MergeFrom(edgeCluster.DeepCopy())
+	h.buildStatus(ctx, &edgeCluster)
+
+	if err := upstream.Cluster.GetClient().Status().Patch(ctx, &edgeCluster, patch); err != nil {
+		return fmt.Errorf("couldn't patch edge cluster %s status: %w", h.ClusterName, err)
+	}
+
+	return nil
+}
+
+func (h *EdgeHeartbeat) buildStatus(ctx context.Context, edgeCluster *edgev1alpha1.EdgeCluster) {
+	status := &edgeCluster.Status
+	generation := edgeCluster.Generation
+
+	status.LastReportedAt = time.Now().UTC().Format(time.RFC3339)
+	status.ControllerVersion = h.Version
+	status.SyncedObjects = make(map[string]int64, len(h.Kinds))
+	status.OffloadTraffic = h.Store.Snapshot()
+	status.PeerURL = h.PeerUrl
+	status.Utilization = h.utilization(ctx)
+
+	var errs []string
+	var outOfSync []string
+
+	for _, kind := range h.Kinds {
+		localCount, err := h.countLocal(ctx, kind)
+
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", kind.Name, err))
+			continue
+		}
+
+		remoteCount, err := h.countRemote(ctx, kind)
+
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", kind.Name, err))
+			continue
+		}
+
+		status.SyncedObjects[kind.Name] = localCount
+
+		if localCount != remoteCount {
+			outOfSync = append(outOfSync, kind.Name)
+		}
+	}
+
+	sort.Strings(outOfSync)
+
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               edgev1alpha1.EdgeClusterConditionConnected,
+		Status:             metav1.ConditionTrue,
+		Reason:             ReasonHeartbeatReported,
+		Message:            "Edge controller is reporting.",
+		ObservedGeneration: generation,
+	})
+
+	if len(outOfSync) == 0 {
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               edgev1alpha1.EdgeClusterConditionSynced,
+			Status:             metav1.ConditionTrue,
+			Reason:             ReasonResourcesInSync,
+			Message:            "All mirrored resources are in sync.",
+			ObservedGeneration: generation,
+		})
+	} else {
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               edgev1alpha1.EdgeClusterConditionSynced,
+			Status:             metav1.ConditionFalse,
+			Reason:             ReasonResourcesOutOfSync,
+			Message:            fmt.Sprintf("Resources out of sync: %s.", strings.Join(outOfSync, ", ")),
+			ObservedGeneration: generation,
+		})
+	}
+
+	if drifted, pinned := h.Drift.Summary(); len(drifted) == 0 && len(pinned) == 0 {
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               edgev1alpha1.EdgeClusterConditionDrifted,
+			Status:             metav1.ConditionFalse,
+			Reason:             ReasonNoLocalChanges,
+			Message:            "Mirrored resources match the remote cluster.",
+			ObservedGeneration: generation,
+		})
+	} else {
+		var message []string
+
+		if len(drifted) > 0 {
+			message = append(message, fmt.Sprintf("Local changes kept: %s.", summarizeObjects(drifted)))
+		}
+
+		if len(pinned) > 0 {
+			message = append(message, fmt.Sprintf("Pinned: %s.", summarizeObjects(pinned)))
+		}
+
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               edgev1alpha1.EdgeClusterConditionDrifted,
+			Status:             metav1.ConditionTrue,
+			Reason:             ReasonLocalChangesKept,
+			Message:            strings.Join(message, " "),
+			ObservedGeneration: generation,
+		})
+	}
+
+	if len(errs) == 0 {
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               edgev1alpha1.EdgeClusterConditionDegraded,
+			Status:             metav1.ConditionFalse,
+			Reason:             ReasonAsExpected,
+			Message:            "Edge controller status was collected.",
+			ObservedGeneration: generation,
+		})
+	} else {
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               edgev1alpha1.EdgeClusterConditionDegraded,
+			Status:             metav1.ConditionTrue,
+			Reason:             ReasonStatusCollectionError,
+			Message:            strings.Join(errs, "; "),
+			ObservedGeneration: generation,
+		})
+	}
+
+	if len(outOfSync) == 0 && len(errs) == 0 {
+		status.Phase = edgev1alpha1.EdgeClusterPhaseReady
+	} else {
+		status.Phase = edgev1alpha1.EdgeClusterPhaseDegraded
+	}
+}
+
+// utilization returns nil when the node metrics are unavailable, the peers then don't offload to
+// the edge cluster since its spare capacity is unknown.
+func (h *EdgeHeartbeat) utilization(ctx context.Context) *edgev1alpha1.EdgeClusterUtilization {
+	debug := h.Log.V(controllers.DebugLevel)
+
+	if h.Usage == nil {
+		return nil
+	}
+
+	clusterUsage := usage.NewClusterUsage()
+
+	if err := h.Usage.UpdateNodesUsage(ctx, clusterUsage); err != nil {
+		debug.Error(err, "Couldn't collect node metrics, utilization won't be reported.")
+		return nil
+	}
+
+	clusterUsage.FinalizeClusterMetrics()
+
+	if clusterUsage.Cpu.Capacity == 0 || clusterUsage.Memory.Capacity == 0 {
+		return nil
+	}
+
+	return &edgev1alpha1.EdgeClusterUtilization{
+		Cpu:    int64(clusterUsage.Cpu.Percentage),
+		Memory: int64(clusterUsage.Memory.Percentage),
+	}
+}
+
+// summarizeObjects lists the first objects, so the condition message stays short.
+func summarizeObjects(objects []string) string {
+	const max = 10
+
+	if len(objects) <= max {
+		return strings.Join(objects, ", ")
+	}
+
+	return fmt.Sprintf("%s and %d more", strings.Join(objects[:max], ", "), len(objects)-max)
+}
+
+func (h *EdgeHeartbeat) countLocal(ctx context.Context, kind MirroredKind) (int64, error) {
+	list := kind.NewList()
+
+	if err := h.List(ctx, list); err != nil {
+		return 0, err
+	}
+
+	objects, err := meta.ExtractList(list)
+
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+
+	for _, object := range objects {
+		if obj, ok := object.(client.Object); ok && edge.IsManagedObject(obj) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (h *EdgeHeartbeat) countRemote(ctx context.Context, kind MirroredKind) (int64, error) {
+	list := kind.NewList()
+
+	// remote cache is already scoped to the edge environments
+	if err := h.RemoteCluster.GetClient().List(ctx, list); err != nil {
+		return 0, err
+	}
+
+	return int64(meta.LenList(list)), nil
+}
@@ -0,0 +1,123 @@
+package heartbeat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	edgev1alpha1 "edge.jevv.dev/pkg/apis/edge/v1alpha1"
+	"edge.jevv.dev/pkg/controllers"
+	"edge.jevv.dev/pkg/workoffload/store"
+)
+
+var _ = Describe("edge heartbeat", func() {
+	configMaps := func(count int, managed bool) *corev1.ConfigMapList {
+		list := &corev1.ConfigMapList{}
+
+		for i := 0; i < count; i++ {
+			configMap := corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("config-%d", i)}}
+
+			if managed {
+				configMap.Labels = map[string]string{controllers.ManagedLabel: "true"}
+			}
+
+			list.Items = append(list.Items, configMap)
+		}
+
+		return list
+	}
+
+	newHeartbeat := func(local, remote client.Client) *EdgeHeartbeat {
+		return &EdgeHeartbeat{
+			Client:        local,
+			Log:           logr.Discard(),
+			RemoteCluster: &stubCluster{client: remote},
+			Store:         &store.Store{},
+			Version:       "v1",
+			Kinds: []MirroredKind{
+				{Name: "ConfigMap", NewList: func() client.ObjectList { return &corev1.ConfigMapList{} }},
+			},
+		}
+	}
+
+	DescribeTable("building the status",
+		func(local, remote *stubClient, phase edgev1alpha1.EdgeClusterPhase, synced, degraded metav1.ConditionStatus) {
+			edgeCluster := &edgev1alpha1.EdgeCluster{ObjectMeta: metav1.ObjectMeta{Name: "edge", Generation: 2}}
+
+			newHeartbeat(local, remote).buildStatus(context.Background(), edgeCluster)
+
+			status := edgeCluster.Status
+			Expect(status.Phase).To(Equal(phase))
+			Expect(status.ControllerVersion).To(Equal("v1"))
+			Expect(status.LastReportedAt).NotTo(BeEmpty())
+
+			for conditionType, expected := range map[string]metav1.ConditionStatus{
+				edgev1alpha1.EdgeClusterConditionConnected: metav1.ConditionTrue,
+				edgev1alpha1.EdgeClusterConditionSynced:    synced,
+				edgev1alpha1.EdgeClusterConditionDegraded:  degraded,
+				edgev1alpha1.EdgeClusterConditionDrifted:   metav1.ConditionFalse,
+			} {
+				condition := meta.FindStatusCondition(status.Conditions, conditionType)
+				Expect(condition).NotTo(BeNil(), conditionType)
+				Expect(condition.Status).To(Equal(expected), conditionType)
+				Expect(condition.ObservedGeneration).To(BeEquivalentTo(2))
+			}
+		},
+		Entry("in sync",
+			&stubClient{lists: []client.ObjectList{configMaps(3, true)}},
+			&stubClient{lists: []client.ObjectList{configMaps(3, false)}},
+			edgev1alpha1.EdgeClusterPhaseReady, metav1.ConditionTrue, metav1.ConditionFalse),
+		Entry("unmanaged local objects aren't counted",
+			&stubClient{lists: []client.ObjectList{configMaps(3, false)}},
+			&stubClient{lists: []client.ObjectList{configMaps(0, false)}},
+			edgev1alpha1.EdgeClusterPhaseReady, metav1.ConditionTrue, metav1.ConditionFalse),
+		Entry("out of sync",
+			&stubClient{lists: []client.ObjectList{configMaps(2, true)}},
+			&stubClient{lists: []client.ObjectList{configMaps(3, false)}},
+			edgev1alpha1.EdgeClusterPhaseDegraded, metav1.ConditionFalse, metav1.ConditionFalse),
+		Entry("remote cluster can't be listed",
+			&stubClient{lists: []client.ObjectList{configMaps(3, true)}},
+			&stubClient{err: errors.New("connection refused")},
+			edgev1alpha1.EdgeClusterPhaseDegraded, metav1.ConditionTrue, metav1.ConditionTrue),
+	)
+
+	It("names the kinds out of sync", func() {
+		edgeCluster := &edgev1alpha1.EdgeCluster{}
+
+		local := &stubClient{lists: []client.ObjectList{configMaps(1, true)}}
+		remote := &stubClient{lists: []client.ObjectList{configMaps(2, false)}}
+
+		newHeartbeat(local, remote).buildStatus(context.Background(), edgeCluster)
+
+		Expect(edgeCluster.Status.SyncedObjects).To(Equal(map[string]int64{"ConfigMap": 1}))
+
+		condition := meta.FindStatusCondition(edgeCluster.Status.Conditions, edgev1alpha1.EdgeClusterConditionSynced)
+		Expect(condition.Reason).To(Equal(ReasonResourcesOutOfSync))
+		Expect(condition.Message).To(Equal("Resources out of sync: ConfigMap."))
+	})
+
+	DescribeTable("summarizing objects",
+		func(count int, expected string) {
+			objects := make([]string, count)
+
+			for i := range objects {
+				objects[i] = fmt.Sprint(i)
+			}
+
+			Expect(summarizeObjects(objects)).To(Equal(expected))
+		},
+		Entry("none", 0, ""),
+		Entry("few", 3, "0, 1, 2"),
+		Entry("exactly the max", 10, "0, 1, 2, 3, 4, 5, 6, 7, 8, 9"),
+		Entry("more than the max", 12, "0, 1, 2, 3, 4, 5, 6, 7, 8, 9 and 2 more"),
+	)
+})
@@ -0,0 +1,70 @@
+package heartbeat
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/cluster"
+)
+
+func TestHeartbeat(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Heartbeat Suite")
+}
+
+// stubClient serves the lists it's given by their type, and records the status patches.
+type stubClient struct {
+	client.Client
+
+	lists   []client.ObjectList
+	err     error
+	patched []client.Object
+}
+
+func (c *stubClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if c.err != nil {
+		return c.err
+	}
+
+	for _, stored := range c.lists {
+		if reflect.TypeOf(stored) == reflect.TypeOf(list) {
+			reflect.ValueOf(list).Elem().Set(reflect.ValueOf(stored.DeepCopyObject()).Elem())
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no %T", list)
+}
+
+func (c *stubClient) Status() client.StatusWriter {
+	return &stubStatusWriter{c}
+}
+
+type stubStatusWriter struct {
+	c *stubClient
+}
+
+func (w *stubStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	return fmt.Errorf("unexpected update")
+}
+
+func (w *stubStatusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	w.c.patched = append(w.c.patched, obj)
+	return nil
+}
+
+type stubCluster struct {
+	cluster.Cluster
+
+	client client.Client
+}
+
+func (c *stubCluster) GetClient() client.Client {
+	return c.client
+}
@@ -0,0 +1,169 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const namespace = "knative_edge"
+
+const (
+	OperationCreate = "create"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+
+	// the mirror transformations failed before the object could be created or updated
+	OperationTransform = "transform"
+)
+
+var (
+	MirrorOperations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "mirror",
+			Name:      "operations_total",
+			Help:      "Number of local objects created, updated or deleted to mirror the remote cluster.",
+		},
+		[]string{"kind", "operation"},
+	)
+
+	MirrorErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "mirror",
+			Name:      "errors_total",
+			Help:      "Number of failed attempts to create, update, delete or transform a local object.",
+		},
+		[]string{"kind", "operation"},
+	)
+
+	MirrorLag = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "mirror",
+			Name:      "lag_seconds",
+			Help:      "Time between a change of a remote object and its application to the local cluster.",
+			Buckets:   []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 300, 900},
+		},
+		[]string{"kind"},
+	)
+
+	MirrorDrift = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "mirror",
+			Name:      "drift_detections_total",
+			Help:      "Number of times a local object was found changed on the edge, by the drift policy applied to it.",
+		},
+		[]string{"kind", "policy"},
+	)
+
+	OffloadTraffic = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "workoffload",
+			Name:      "traffic_percent",
+			Help:      "Percentage of the traffic of a service which is offloaded to the remote cluster.",
+		},
+		[]string{"namespace", "service"},
+	)
+
+	OffloadRemoteReachable = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "workoffload",
+			Name:      "remote_reachable",
+			Help:      "Whether the edge proxies of a service can reach the remote cluster (1) or not (0).",
+		},
+		[]string{"namespace", "service"},
+	)
+
+	StrategyDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "workoffload",
+			Name:      "strategy_duration_seconds",
+			Help:      "Time taken to execute a work offload strategy.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"strategy"},
+	)
+
+	StrategyErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "workoffload",
+			Name:      "strategy_errors_total",
+			Help:      "Number of failed executions of a work offload strategy.",
+		},
+		[]string{"strategy"},
+	)
+
+	PrometheusQueryFailures = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "workoffload",
+			Name:      "prometheus_query_failures_total",
+			Help:      "Number of failed queries to Prometheus, including retries.",
+		},
+	)
+
+	RemoteClusterUp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "remote",
+			Name:      "cluster_up",
+			Help:      "Whether the upstream cluster was reachable on the last probe (1) or not (0).",
+		},
+		[]string{"upstream"},
+	)
+
+	RemoteClusterLastContact = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "remote",
+			Name:      "last_contact_timestamp_seconds",
+			Help:      "Unix timestamp of the last successful probe of the upstream cluster.",
+		},
+		[]string{"upstream"},
+	)
+
+	RemoteSnapshotInUse = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "remote",
+			Name:      "snapshot_in_use",
+			Help:      "Whether the remote objects of a kind are read from the local snapshot (1) or the remote cluster (0).",
+		},
+		[]string{"kind"},
+	)
+
+	RemoteSnapshotLastSaved = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "remote",
+			Name:      "snapshot_last_saved_timestamp_seconds",
+			Help:      "Unix timestamp of the last time the local snapshot of the remote objects of a kind was saved.",
+		},
+		[]string{"kind"},
+	)
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(
+		MirrorOperations,
+		MirrorErrors,
+		MirrorLag,
+		MirrorDrift,
+		OffloadTraffic,
+		OffloadRemoteReachable,
+		StrategyDuration,
+		StrategyErrors,
+		PrometheusQueryFailures,
+		RemoteClusterUp,
+		RemoteClusterLastContact,
+		RemoteSnapshotInUse,
+		RemoteSnapshotLastSaved,
+	)
+}
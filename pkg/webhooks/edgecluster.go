@@ -0,0 +1,83 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	edgev1alpha1 "edge.jevv.dev/pkg/apis/edge/v1alpha1"
+)
+
+//+kubebuilder:webhook:path=/validate-edge-jevv-dev-v1alpha1-edgecluster,mutating=false,failurePolicy=fail,sideEffects=None,groups=edge.jevv.dev,resources=edgeclusters,verbs=create;update,versions=v1alpha1,name=vedgecluster.edge.jevv.dev,admissionReviewVersions=v1
+
+// EdgeClusterValidator rejects EdgeClusters whose environments can't be mirrored, since the edge
+// controller selects the resources to mirror by their environment label.
+type EdgeClusterValidator struct{}
+
+func (v *EdgeClusterValidator) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&edgev1alpha1.EdgeCluster{}).
+		WithValidator(v).
+		Complete()
+}
+
+func (v *EdgeClusterValidator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	return v.validate(obj)
+}
+
+func (v *EdgeClusterValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	return v.validate(newObj)
+}
+
+func (v *EdgeClusterValidator) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
+
+func (v *EdgeClusterValidator) validate(obj runtime.Object) error {
+	edgeCluster, ok := obj.(*edgev1alpha1.EdgeCluster)
+
+	if !ok {
+		return fmt.Errorf("expected an EdgeCluster but got %T", obj)
+	}
+
+	var errs field.ErrorList
+
+	path := field.NewPath("spec", "environments")
+	seen := make(map[string]bool)
+
+	if len(edgeCluster.Spec.Environments) == 0 {
+		errs = append(errs, field.Required(path, "at least one environment is required"))
+	}
+
+	for i, env := range edgeCluster.Spec.Environments {
+		if strings.TrimSpace(env) == "" {
+			errs = append(errs, field.Invalid(path.Index(i), env, "must not be empty"))
+			continue
+		}
+
+		if seen[env] {
+			errs = append(errs, field.Duplicate(path.Index(i), env))
+			continue
+		}
+
+		seen[env] = true
+
+		// environments are label values, and joined by commas in the flags of the edge controller
+		if msgs := validation.IsValidLabelValue(env); len(msgs) > 0 {
+			errs = append(errs, field.Invalid(path.Index(i), env, strings.Join(msgs, "; ")))
+		}
+	}
+
+	if len(errs) > 0 {
+		return apierrors.NewInvalid(edgev1alpha1.GroupVersion.WithKind("EdgeCluster").GroupKind(), edgeCluster.Name, errs)
+	}
+
+	return nil
+}
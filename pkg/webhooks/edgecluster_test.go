@@ -0,0 +1,56 @@
+package webhooks
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	edgev1alpha1 "edge.jevv.dev/pkg/apis/edge/v1alpha1"
+)
+
+var _ = Describe("EdgeCluster validator", func() {
+	validator := &EdgeClusterValidator{}
+
+	newEdgeCluster := func(envs ...string) *edgev1alpha1.EdgeCluster {
+		return &edgev1alpha1.EdgeCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "edge"},
+			Spec:       edgev1alpha1.EdgeClusterSpec{Environments: envs},
+		}
+	}
+
+	DescribeTable("admission",
+		func(envs []string, allowed bool) {
+			errs := []error{
+				validator.ValidateCreate(context.Background(), newEdgeCluster(envs...)),
+				validator.ValidateUpdate(context.Background(), newEdgeCluster(), newEdgeCluster(envs...)),
+			}
+
+			for _, err := range errs {
+				if allowed {
+					Expect(err).NotTo(HaveOccurred())
+				} else {
+					Expect(apierrors.IsInvalid(err)).To(BeTrue(), "expected an invalid error, got %v", err)
+				}
+			}
+		},
+		Entry("single environment", []string{"prod"}, true),
+		Entry("several environments", []string{"prod", "staging"}, true),
+		Entry("no environment", nil, false),
+		Entry("empty environment", []string{"prod", " "}, false),
+		Entry("duplicate environment", []string{"prod", "prod"}, false),
+		Entry("environment which isn't a label value", []string{"prod,staging"}, false),
+	)
+
+	It("always allows deletes", func() {
+		Expect(validator.ValidateDelete(context.Background(), newEdgeCluster())).To(Succeed())
+	})
+
+	It("rejects other objects", func() {
+		Expect(validator.ValidateCreate(context.Background(), &corev1.Secret{})).NotTo(Succeed())
+	})
+})
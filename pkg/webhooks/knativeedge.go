@@ -0,0 +1,145 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "edge.jevv.dev/pkg/apis/operator/v1alpha1"
+)
+
+//+kubebuilder:webhook:path=/mutate-operator-edge-jevv-dev-v1alpha1-knativeedge,mutating=true,failurePolicy=fail,sideEffects=None,groups=operator.edge.jevv.dev,resources=knativeedges,verbs=create;update,versions=v1alpha1,name=mknativeedge.operator.edge.jevv.dev,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-operator-edge-jevv-dev-v1alpha1-knativeedge,mutating=false,failurePolicy=fail,sideEffects=None,groups=operator.edge.jevv.dev,resources=knativeedges,verbs=create;update,versions=v1alpha1,name=vknativeedge.operator.edge.jevv.dev,admissionReviewVersions=v1
+
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get
+
+// KnativeEdgeWebhook defaults and validates KnativeEdges, so mistakes are rejected when they're
+// applied instead of showing up as warning events of the operator. The referenced secrets are read
+// through Reader since the cache of the operator may not cover their namespaces.
+type KnativeEdgeWebhook struct {
+	Reader client.Reader
+}
+
+func (w *KnativeEdgeWebhook) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&operatorv1alpha1.KnativeEdge{}).
+		WithDefaulter(w).
+		WithValidator(w).
+		Complete()
+}
+
+// Default names the EdgeCluster after the KnativeEdge, and looks for the kubeconfig secret in the
+// namespace of the KnativeEdge.
+func (w *KnativeEdgeWebhook) Default(ctx context.Context, obj runtime.Object) error {
+	edge, ok := obj.(*operatorv1alpha1.KnativeEdge)
+
+	if !ok {
+		return fmt.Errorf("expected a KnativeEdge but got %T", obj)
+	}
+
+	if edge.Spec.ClusterName == "" {
+		edge.Spec.ClusterName = edge.Name
+	}
+
+	if edge.Spec.SecretRef != nil && edge.Spec.SecretRef.Namespace == "" {
+		edge.Spec.SecretRef.Namespace = edge.Namespace
+	}
+
+	return nil
+}
+
+func (w *KnativeEdgeWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	return w.validate(ctx, obj)
+}
+
+func (w *KnativeEdgeWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	return w.validate(ctx, newObj)
+}
+
+func (w *KnativeEdgeWebhook) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
+
+func (w *KnativeEdgeWebhook) validate(ctx context.Context, obj runtime.Object) error {
+	edge, ok := obj.(*operatorv1alpha1.KnativeEdge)
+
+	if !ok {
+		return fmt.Errorf("expected a KnativeEdge but got %T", obj)
+	}
+
+	var errs field.ErrorList
+
+	spec := &edge.Spec
+	path := field.NewPath("spec")
+
+	appendErr := func(err *field.Error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// EdgeClusters are cluster scoped, so the name must be a valid object name
+	if spec.ClusterName != "" {
+		if msgs := validation.IsDNS1123Subdomain(spec.ClusterName); len(msgs) > 0 {
+			errs = append(errs, field.Invalid(path.Child("clusterName"), spec.ClusterName, strings.Join(msgs, "; ")))
+		}
+	}
+
+	if spec.ClusterHostnameOrIp == "" {
+		errs = append(errs, field.Required(path.Child("clusterHostnameOrIp"), "the edge proxies offload to it"))
+	} else {
+		appendErr(validateURL(path.Child("clusterHostnameOrIp"), spec.ClusterHostnameOrIp, "http", "https"))
+	}
+
+	if spec.SecretRef != nil {
+		appendErr(validateKubeconfigSecret(ctx, w.Reader, path.Child("secretRef"), spec.SecretRef, edge.Namespace))
+	}
+
+	for i := range spec.Upstreams {
+		upstream := &spec.Upstreams[i]
+		upstreamPath := path.Child("upstreams").Index(i)
+
+		if upstream.ClusterHostnameOrIp != "" {
+			appendErr(validateURL(upstreamPath.Child("clusterHostnameOrIp"), upstream.ClusterHostnameOrIp, "http", "https"))
+		}
+
+		appendErr(validateKubeconfigSecret(ctx, w.Reader, upstreamPath.Child("secretRef"), &upstream.SecretRef, edge.Namespace))
+	}
+
+	if spec.Proxy.HttpProxy != "" {
+		appendErr(validateProxyURL(path.Child("proxy", "httpProxy"), spec.Proxy.HttpProxy))
+	}
+
+	if spec.Proxy.HttpsProxy != "" {
+		appendErr(validateProxyURL(path.Child("proxy", "httpsProxy"), spec.Proxy.HttpsProxy))
+	}
+
+	// the edge controller is always given the url of the Prometheus instance
+	if spec.Prometheus == nil {
+		errs = append(errs, field.Required(path.Child("prometheus"), ""))
+	} else {
+		appendErr(validateURL(path.Child("prometheus", "url"), spec.Prometheus.URL, "http", "https"))
+	}
+
+	if spec.Tracing.OtlpEndpoint != "" {
+		appendErr(validateURL(path.Child("tracing", "otlpEndpoint"), spec.Tracing.OtlpEndpoint, "http", "https"))
+	}
+
+	if spec.PeerOffload.URL != "" {
+		appendErr(validateURL(path.Child("peerOffload", "url"), spec.PeerOffload.URL, "http", "https"))
+	}
+
+	if len(errs) > 0 {
+		return apierrors.NewInvalid(operatorv1alpha1.GroupVersion.WithKind("KnativeEdge").GroupKind(), edge.Name, errs)
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "edge.jevv.dev/pkg/apis/operator/v1alpha1"
+)
+
+const kubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: remote
+  cluster:
+    server: https://10.0.0.1:6443
+users:
+- name: remote
+  user:
+    token: token
+contexts:
+- name: remote
+  context:
+    cluster: remote
+    user: remote
+current-context: remote
+`
+
+var _ = Describe("KnativeEdge webhook", func() {
+	reader := &stubReader{
+		secrets: map[client.ObjectKey]corev1.Secret{
+			{Name: "remote", Namespace: "edge"}:         {Data: map[string][]byte{"kubeconfig": []byte(kubeconfig)}},
+			{Name: "other", Namespace: "cloud"}:         {Data: map[string][]byte{"kubeconfig": []byte(kubeconfig)}},
+			{Name: "empty", Namespace: "edge"}:          {Data: map[string][]byte{}},
+			{Name: "invalid-config", Namespace: "edge"}: {Data: map[string][]byte{"kubeconfig": []byte("clusters: {}")}},
+		},
+	}
+
+	webhook := &KnativeEdgeWebhook{Reader: reader}
+
+	newKnativeEdge := func(mutate func(spec *operatorv1alpha1.KnativeEdgeSpec)) *operatorv1alpha1.KnativeEdge {
+		edge := &operatorv1alpha1.KnativeEdge{
+			ObjectMeta: metav1.ObjectMeta{Name: "edge", Namespace: "edge"},
+			Spec: operatorv1alpha1.KnativeEdgeSpec{
+				ClusterHostnameOrIp: "http://10.0.0.1",
+				SecretRef:           &corev1.SecretReference{Name: "remote"},
+				Prometheus:          &operatorv1alpha1.KnativeEdgePrometheus{URL: "http://prometheus:9090"},
+			},
+		}
+
+		if mutate != nil {
+			mutate(&edge.Spec)
+		}
+
+		return edge
+	}
+
+	Describe("defaulting", func() {
+		It("names the EdgeCluster after the KnativeEdge and looks for the secret in its namespace", func() {
+			edge := newKnativeEdge(nil)
+
+			Expect(webhook.Default(context.Background(), edge)).To(Succeed())
+			Expect(edge.Spec.ClusterName).To(Equal("edge"))
+			Expect(edge.Spec.SecretRef.Namespace).To(Equal("edge"))
+		})
+
+		It("keeps the values which are set", func() {
+			edge := newKnativeEdge(func(spec *operatorv1alpha1.KnativeEdgeSpec) {
+				spec.ClusterName = "other"
+				spec.SecretRef = &corev1.SecretReference{Name: "other", Namespace: "cloud"}
+			})
+
+			Expect(webhook.Default(context.Background(), edge)).To(Succeed())
+			Expect(edge.Spec.ClusterName).To(Equal("other"))
+			Expect(edge.Spec.SecretRef.Namespace).To(Equal("cloud"))
+		})
+	})
+
+	DescribeTable("validation",
+		func(mutate func(spec *operatorv1alpha1.KnativeEdgeSpec), allowed bool) {
+			err := webhook.ValidateCreate(context.Background(), newKnativeEdge(mutate))
+
+			if allowed {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(apierrors.IsInvalid(err)).To(BeTrue(), "expected an invalid error, got %v", err)
+			}
+		},
+		Entry("defaults", nil, true),
+		Entry("secret in another namespace", func(spec *operatorv1alpha1.KnativeEdgeSpec) {
+			spec.SecretRef = &corev1.SecretReference{Name: "other", Namespace: "cloud"}
+		}, true),
+		Entry("upstreams, proxies, tracing and peers", func(spec *operatorv1alpha1.KnativeEdgeSpec) {
+			spec.Upstreams = []operatorv1alpha1.KnativeEdgeUpstream{
+				{Name: "backup", SecretRef: corev1.SecretReference{Name: "other", Namespace: "cloud"}, ClusterHostnameOrIp: "https://10.0.0.2"},
+			}
+			spec.Proxy = operatorv1alpha1.KnativeEdgeProxy{HttpProxy: "proxy:3128", HttpsProxy: "socks5://proxy:1080"}
+			spec.Tracing.OtlpEndpoint = "http://collector:4318"
+			spec.PeerOffload.URL = "http://10.0.0.3"
+		}, true),
+		Entry("invalid cluster name", func(spec *operatorv1alpha1.KnativeEdgeSpec) {
+			spec.ClusterName = "Edge_1"
+		}, false),
+		Entry("missing cluster address", func(spec *operatorv1alpha1.KnativeEdgeSpec) {
+			spec.ClusterHostnameOrIp = ""
+		}, false),
+		Entry("cluster address without scheme", func(spec *operatorv1alpha1.KnativeEdgeSpec) {
+			spec.ClusterHostnameOrIp = "10.0.0.1"
+		}, false),
+		Entry("cluster address with another scheme", func(spec *operatorv1alpha1.KnativeEdgeSpec) {
+			spec.ClusterHostnameOrIp = "ftp://10.0.0.1"
+		}, false),
+		Entry("missing secret", func(spec *operatorv1alpha1.KnativeEdgeSpec) {
+			spec.SecretRef.Name = "missing"
+		}, false),
+		Entry("secret without kubeconfig", func(spec *operatorv1alpha1.KnativeEdgeSpec) {
+			spec.SecretRef.Name = "empty"
+		}, false),
+		Entry("secret with invalid kubeconfig", func(spec *operatorv1alpha1.KnativeEdgeSpec) {
+			spec.SecretRef.Name = "invalid-config"
+		}, false),
+		Entry("upstream without secret", func(spec *operatorv1alpha1.KnativeEdgeSpec) {
+			spec.Upstreams = []operatorv1alpha1.KnativeEdgeUpstream{{Name: "backup"}}
+		}, false),
+		Entry("invalid proxy", func(spec *operatorv1alpha1.KnativeEdgeSpec) {
+			spec.Proxy.HttpsProxy = "ftp://proxy"
+		}, false),
+		Entry("missing prometheus", func(spec *operatorv1alpha1.KnativeEdgeSpec) {
+			spec.Prometheus = nil
+		}, false),
+		Entry("invalid otlp endpoint", func(spec *operatorv1alpha1.KnativeEdgeSpec) {
+			spec.Tracing.OtlpEndpoint = "collector:4318"
+		}, false),
+	)
+
+	It("reports the secrets it can't read as internal errors", func() {
+		webhook := &KnativeEdgeWebhook{Reader: &stubReader{err: errors.New("connection refused")}}
+
+		err := webhook.ValidateCreate(context.Background(), newKnativeEdge(nil))
+
+		var statusErr *apierrors.StatusError
+		Expect(errors.As(err, &statusErr)).To(BeTrue())
+		Expect(statusErr.ErrStatus.Details.Causes).To(ContainElement(HaveField("Type", metav1.CauseType("InternalError"))))
+	})
+})
@@ -0,0 +1,233 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+
+	edgev1alpha1 "edge.jevv.dev/pkg/apis/edge/v1alpha1"
+	"edge.jevv.dev/pkg/controllers"
+	"edge.jevv.dev/pkg/controllers/edge"
+	"edge.jevv.dev/pkg/workoffload/prometheus/usage"
+	"edge.jevv.dev/pkg/workoffload/schedule"
+	"edge.jevv.dev/pkg/workoffload/strategy"
+)
+
+//+kubebuilder:webhook:path=/validate-serving-knative-dev-v1-service,mutating=false,failurePolicy=fail,sideEffects=None,groups=serving.knative.dev,resources=services,verbs=create;update,versions=v1,name=vkservice.edge.jevv.dev,admissionReviewVersions=v1
+
+//+kubebuilder:rbac:groups=edge.jevv.dev,resources=edgeclusters,verbs=get;list;watch
+
+var strategyNames = []string{
+	strategy.LatencyRatioStrategyName,
+	strategy.ResourcePressureStrategyName,
+	strategy.RequestRateStrategyName,
+	strategy.FixedScheduleStrategyName,
+}
+
+// KServiceValidator rejects Knative Services with edge annotations which the edge controllers would
+// ignore, and environments no EdgeCluster mirrors, before they're mirrored to the edges.
+type KServiceValidator struct {
+	Reader client.Reader
+}
+
+func (v *KServiceValidator) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&servingv1.Service{}).
+		WithValidator(v).
+		Complete()
+}
+
+func (v *KServiceValidator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	return v.validate(ctx, obj, nil)
+}
+
+func (v *KServiceValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	return v.validate(ctx, newObj, oldObj)
+}
+
+func (v *KServiceValidator) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
+
+func (v *KServiceValidator) validate(ctx context.Context, obj, oldObj runtime.Object) error {
+	service, ok := obj.(*servingv1.Service)
+
+	if !ok {
+		return fmt.Errorf("expected a Knative Service but got %T", obj)
+	}
+
+	errs := validateAnnotations(service.Annotations, field.NewPath("metadata", "annotations"))
+
+	// services whose environment didn't change are still updated after their EdgeCluster is gone
+	env, labeled := service.Labels[controllers.EnvironmentLabel]
+	changed := true
+
+	if oldService, ok := oldObj.(*servingv1.Service); ok {
+		oldEnv, oldLabeled := oldService.Labels[controllers.EnvironmentLabel]
+		changed = env != oldEnv || labeled != oldLabeled
+	}
+
+	if labeled && changed {
+		if err := v.validateEnvironment(ctx, env); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return apierrors.NewInvalid(servingv1.SchemeGroupVersion.WithKind("Service").GroupKind(), service.Name, errs)
+	}
+
+	return nil
+}
+
+func (v *KServiceValidator) validateEnvironment(ctx context.Context, env string) *field.Error {
+	path := field.NewPath("metadata", "labels").Key(controllers.EnvironmentLabel)
+
+	var edgeClusters edgev1alpha1.EdgeClusterList
+
+	if err := v.Reader.List(ctx, &edgeClusters); err != nil {
+		return field.InternalError(path, fmt.Errorf("couldn't list EdgeClusters: %w", err))
+	}
+
+	envs := make(map[string]bool)
+
+	for _, edgeCluster := range edgeClusters.Items {
+		for _, clusterEnv := range edgeCluster.Spec.Environments {
+			envs[clusterEnv] = true
+		}
+	}
+
+	if envs[env] {
+		return nil
+	}
+
+	known := make([]string, 0, len(envs))
+
+	for clusterEnv := range envs {
+		known = append(known, clusterEnv)
+	}
+
+	sort.Strings(known)
+
+	return field.NotSupported(path, env, known)
+}
+
+// validateAnnotations checks the annotations the same way the edge controllers read them, adding
+// the ranges they're meaningful in.
+func validateAnnotations(annotations map[string]string, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	if annotations == nil {
+		return errs
+	}
+
+	if name, exists := annotations[strategy.StrategyNameAnnotation]; exists {
+		found := false
+
+		for _, strategyName := range strategyNames {
+			found = found || name == strategyName
+		}
+
+		if !found {
+			errs = append(errs, field.NotSupported(path.Key(strategy.StrategyNameAnnotation), name, strategyNames))
+		}
+	}
+
+	parseNumber(annotations, path, strategy.TrafficInertiaAnnotation, 0, 1, 0, &errs)
+	parseInteger(annotations, path, controllers.EdgeFixedTrafficAnnotation, 0, 100, &errs)
+
+	parseNumber(annotations, path, usage.LatencyRatioTargetAnnotation, 0, math.Inf(1), 0, &errs)
+	parseNumber(annotations, path, usage.LatencyRatioDecayAnnotation, 0, 1, 0, &errs)
+
+	latencySoftLimit := parseNumber(annotations, path, usage.LatencyRatioSoftLimitAnnotation, 0, math.Inf(1), usage.LatencyRatioSoftLimitAnnotationDefaultValue, &errs)
+	latencyHardLimit := parseNumber(annotations, path, usage.LatencyRatioHardLimitAnnotation, 0, math.Inf(1), usage.LatencyRatioHardLimitAnnotationDefaultValue, &errs)
+
+	if latencySoftLimit >= latencyHardLimit {
+		errs = append(errs, field.Invalid(path.Key(usage.LatencyRatioSoftLimitAnnotation), strconv.FormatFloat(latencySoftLimit, 'g', -1, 64), fmt.Sprintf("must be less than the hard limit %g", latencyHardLimit)))
+	}
+
+	requestRateSoftLimit := parseNumber(annotations, path, usage.RequestRateSoftLimitAnnotation, 0, math.Inf(1), usage.RequestRateSoftLimitAnnotationDefaultValue, &errs)
+	requestRateHardLimit := parseNumber(annotations, path, usage.RequestRateHardLimitAnnotation, 0, math.Inf(1), usage.RequestRateHardLimitAnnotationDefaultValue, &errs)
+
+	if requestRateSoftLimit >= requestRateHardLimit {
+		errs = append(errs, field.Invalid(path.Key(usage.RequestRateSoftLimitAnnotation), strconv.FormatFloat(requestRateSoftLimit, 'g', -1, 64), fmt.Sprintf("must be less than the hard limit %g", requestRateHardLimit)))
+	}
+
+	parseNumber(annotations, path, usage.CpuPressureThresholdAnnotation, 0, 100, 0, &errs)
+	parseNumber(annotations, path, usage.MemoryPressureThresholdAnnotation, 0, 100, 0, &errs)
+
+	_, hasSchedule := annotations[schedule.ScheduleAnnotation]
+	_, hasTimezone := annotations[schedule.ScheduleTimezoneAnnotation]
+	_, hasDefault := annotations[schedule.ScheduleDefaultAnnotation]
+
+	if hasSchedule || hasTimezone || hasDefault {
+		if err := schedule.ValidateAnnotations(annotations); err != nil {
+			errs = append(errs, field.Invalid(path.Key(schedule.ScheduleAnnotation), field.OmitValueType{}, err.Error()))
+		}
+	}
+
+	if _, err := edge.GetProxySettings(annotations); err != nil {
+		errs = append(errs, field.Invalid(path, field.OmitValueType{}, err.Error()))
+	}
+
+	return errs
+}
+
+// parseNumber returns the value of the annotation, or the default value when it's missing or
+// invalid.
+func parseNumber(annotations map[string]string, path *field.Path, key string, min, max, defaultValue float64, errs *field.ErrorList) float64 {
+	value, exists := annotations[key]
+
+	if !exists {
+		return defaultValue
+	}
+
+	number, err := strconv.ParseFloat(value, 32)
+
+	if err != nil {
+		*errs = append(*errs, field.Invalid(path.Key(key), value, "must be a number"))
+		return defaultValue
+	}
+
+	if number < min || number > max {
+		*errs = append(*errs, field.Invalid(path.Key(key), value, rangeMessage(min, max)))
+		return defaultValue
+	}
+
+	return number
+}
+
+func parseInteger(annotations map[string]string, path *field.Path, key string, min, max int64, errs *field.ErrorList) {
+	value, exists := annotations[key]
+
+	if !exists {
+		return
+	}
+
+	number, err := strconv.ParseInt(value, 10, 64)
+
+	if err != nil {
+		*errs = append(*errs, field.Invalid(path.Key(key), value, "must be an integer"))
+	} else if number < min || number > max {
+		*errs = append(*errs, field.Invalid(path.Key(key), value, rangeMessage(float64(min), float64(max))))
+	}
+}
+
+func rangeMessage(min, max float64) string {
+	if math.IsInf(max, 1) {
+		return fmt.Sprintf("must be at least %g", min)
+	}
+
+	return fmt.Sprintf("must be between %g and %g", min, max)
+}
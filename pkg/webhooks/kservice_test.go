@@ -0,0 +1,130 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+
+	edgev1alpha1 "edge.jevv.dev/pkg/apis/edge/v1alpha1"
+	"edge.jevv.dev/pkg/controllers"
+	"edge.jevv.dev/pkg/workoffload/prometheus/usage"
+	"edge.jevv.dev/pkg/workoffload/schedule"
+	"edge.jevv.dev/pkg/workoffload/strategy"
+)
+
+var _ = Describe("Knative Service validator", func() {
+	newService := func(labels, annotations map[string]string) *servingv1.Service {
+		return &servingv1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "default", Labels: labels, Annotations: annotations},
+		}
+	}
+
+	DescribeTable("annotations",
+		func(annotations map[string]string, invalid ...string) {
+			errs := validateAnnotations(annotations, field.NewPath("metadata", "annotations"))
+
+			fields := make([]string, 0, len(errs))
+
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+
+			Expect(fields).To(ConsistOf(invalid))
+		},
+		Entry("none", nil),
+		Entry("valid strategy settings", map[string]string{
+			strategy.StrategyNameAnnotation:        strategy.RequestRateStrategyName,
+			strategy.TrafficInertiaAnnotation:      "0.5",
+			controllers.EdgeFixedTrafficAnnotation: "30",
+			usage.RequestRateSoftLimitAnnotation:   "10",
+			usage.RequestRateHardLimitAnnotation:   "100",
+			usage.CpuPressureThresholdAnnotation:   "80",
+			usage.LatencyRatioSoftLimitAnnotation:  "2",
+			controllers.ProxyMinScaleAnnotation:    "1",
+		}),
+		Entry("unknown strategy", map[string]string{
+			strategy.StrategyNameAnnotation: "random",
+		}, "metadata.annotations["+strategy.StrategyNameAnnotation+"]"),
+		Entry("inertia out of range", map[string]string{
+			strategy.TrafficInertiaAnnotation: "2",
+		}, "metadata.annotations["+strategy.TrafficInertiaAnnotation+"]"),
+		Entry("fixed traffic which isn't an integer", map[string]string{
+			controllers.EdgeFixedTrafficAnnotation: "12.5",
+		}, "metadata.annotations["+controllers.EdgeFixedTrafficAnnotation+"]"),
+		Entry("threshold which isn't a number", map[string]string{
+			usage.MemoryPressureThresholdAnnotation: "high",
+		}, "metadata.annotations["+usage.MemoryPressureThresholdAnnotation+"]"),
+		Entry("soft limit above the default hard limit", map[string]string{
+			usage.RequestRateSoftLimitAnnotation: "500",
+		}, "metadata.annotations["+usage.RequestRateSoftLimitAnnotation+"]"),
+		Entry("soft limit above the hard limit", map[string]string{
+			usage.LatencyRatioSoftLimitAnnotation: "3",
+			usage.LatencyRatioHardLimitAnnotation: "2",
+		}, "metadata.annotations["+usage.LatencyRatioSoftLimitAnnotation+"]"),
+		Entry("invalid schedule", map[string]string{
+			schedule.ScheduleAnnotation: "always",
+		}, "metadata.annotations["+schedule.ScheduleAnnotation+"]"),
+		Entry("invalid proxy settings", map[string]string{
+			controllers.ProxyMinScaleAnnotation: "many",
+		}, "metadata.annotations"),
+	)
+
+	Describe("environments", func() {
+		reader := &stubReader{
+			edgeClusters: []edgev1alpha1.EdgeCluster{
+				{Spec: edgev1alpha1.EdgeClusterSpec{Environments: []string{"prod"}}},
+				{Spec: edgev1alpha1.EdgeClusterSpec{Environments: []string{"staging", "prod"}}},
+			},
+		}
+
+		validator := &KServiceValidator{Reader: reader}
+
+		withEnv := func(env string) map[string]string {
+			return map[string]string{controllers.EnvironmentLabel: env}
+		}
+
+		DescribeTable("admission",
+			func(labels, oldLabels map[string]string, allowed bool) {
+				var err error
+
+				if oldLabels == nil {
+					err = validator.ValidateCreate(context.Background(), newService(labels, nil))
+				} else {
+					err = validator.ValidateUpdate(context.Background(), newService(oldLabels, nil), newService(labels, nil))
+				}
+
+				if allowed {
+					Expect(err).NotTo(HaveOccurred())
+				} else {
+					Expect(apierrors.IsInvalid(err)).To(BeTrue(), "expected an invalid error, got %v", err)
+				}
+			},
+			Entry("without environment", nil, nil, true),
+			Entry("mirrored environment", withEnv("prod"), nil, true),
+			Entry("environment of another EdgeCluster", withEnv("staging"), nil, true),
+			Entry("unknown environment", withEnv("dev"), nil, false),
+			Entry("empty environment", withEnv(""), nil, false),
+			Entry("unchanged unknown environment", withEnv("dev"), withEnv("dev"), true),
+			Entry("changed to an unknown environment", withEnv("dev"), withEnv("prod"), false),
+			Entry("labeled with an unknown environment", withEnv("dev"), map[string]string{}, false),
+		)
+
+		It("rejects the environment when the EdgeClusters can't be listed", func() {
+			validator := &KServiceValidator{Reader: &stubReader{err: errors.New("connection refused")}}
+
+			Expect(validator.ValidateCreate(context.Background(), newService(withEnv("prod"), nil))).NotTo(Succeed())
+		})
+
+		It("always allows deletes", func() {
+			Expect(validator.ValidateDelete(context.Background(), newService(withEnv("dev"), nil))).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,56 @@
+package webhooks
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	edgev1alpha1 "edge.jevv.dev/pkg/apis/edge/v1alpha1"
+)
+
+func TestWebhooks(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Webhooks Suite")
+}
+
+// stubReader serves the secrets and EdgeClusters the webhooks read, or err for every call.
+type stubReader struct {
+	secrets      map[client.ObjectKey]corev1.Secret
+	edgeClusters []edgev1alpha1.EdgeCluster
+	err          error
+}
+
+func (r *stubReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	secret, ok := obj.(*corev1.Secret)
+	found, exists := r.secrets[key]
+
+	if !ok || !exists {
+		return apierrors.NewNotFound(corev1.Resource("secrets"), key.Name)
+	}
+
+	found.DeepCopyInto(secret)
+
+	return nil
+}
+
+func (r *stubReader) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	if edgeClusters, ok := list.(*edgev1alpha1.EdgeClusterList); ok {
+		edgeClusters.Items = append(edgeClusters.Items[:0], r.edgeClusters...)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// validateURL checks that the value is an absolute url with a host and one of the schemes.
+func validateURL(path *field.Path, value string, schemes ...string) *field.Error {
+	u, err := url.Parse(value)
+
+	if err != nil {
+		return field.Invalid(path, value, err.Error())
+	}
+
+	if u.Host == "" {
+		return field.Invalid(path, value, fmt.Sprintf("must be an absolute url, e.g. %s://10.0.0.1", schemes[0]))
+	}
+
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			return nil
+		}
+	}
+
+	return field.Invalid(path, value, fmt.Sprintf("scheme must be one of %s", strings.Join(schemes, ", ")))
+}
+
+// validateProxyURL accepts the proxy urls like the HTTP_PROXY env does, where the scheme is
+// optional.
+func validateProxyURL(path *field.Path, value string) *field.Error {
+	if !strings.Contains(value, "://") {
+		value = "http://" + value
+	}
+
+	return validateURL(path, value, "http", "https", "socks5")
+}
+
+// validateKubeconfigSecret checks that the secret exists and has a kubeconfig which can be loaded.
+func validateKubeconfigSecret(ctx context.Context, reader client.Reader, path *field.Path, ref *corev1.SecretReference, namespace string) *field.Error {
+	if ref.Name == "" {
+		return field.Required(path.Child("name"), "")
+	}
+
+	if ref.Namespace != "" {
+		namespace = ref.Namespace
+	}
+
+	name := types.NamespacedName{Name: ref.Name, Namespace: namespace}
+
+	var secret corev1.Secret
+
+	if err := reader.Get(ctx, name, &secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return field.NotFound(path, name.String())
+		}
+
+		return field.InternalError(path, fmt.Errorf("couldn't retrieve secret %s: %w", name.String(), err))
+	}
+
+	kubeconfig, exists := secret.Data["kubeconfig"]
+
+	if !exists {
+		return field.Invalid(path, name.String(), "secret doesn't contain kubeconfig")
+	}
+
+	config, err := clientcmd.NewClientConfigFromBytes(kubeconfig)
+
+	if err == nil {
+		_, err = config.ClientConfig()
+	}
+
+	if err != nil {
+		return field.Invalid(path, name.String(), fmt.Sprintf("kubeconfig couldn't be loaded: %s", err))
+	}
+
+	return nil
+}
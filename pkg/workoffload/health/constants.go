@@ -0,0 +1,12 @@
+package health
+
+import "time"
+
+const (
+	// ProxyHealthPort and ProxyHealthPath are where the edge proxy reports whether it can reach
+	// the remote cluster.
+	ProxyHealthPort = 9095
+	ProxyHealthPath = "/healthz/remote"
+
+	ProxyHealthTimeout = time.Second * 2
+)
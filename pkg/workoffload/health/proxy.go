@@ -0,0 +1,146 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1 "k8s.io/api/core/v1"
+	"knative.dev/serving/pkg/apis/serving"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+
+	"edge.jevv.dev/pkg/controllers"
+	"edge.jevv.dev/pkg/controllers/utils"
+)
+
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+
+// ProxyHealthChecker asks the edge proxies of a service whether they can reach the remote
+// cluster. The proxies open a circuit breaker when the link to the remote cluster is down.
+type ProxyHealthChecker struct {
+	client.Client
+
+	Log        logr.Logger
+	HttpClient *http.Client
+	Port       int
+}
+
+func NewProxyHealthChecker(log logr.Logger, client client.Client) *ProxyHealthChecker {
+	return &ProxyHealthChecker{
+		Client:     client,
+		Log:        log,
+		HttpClient: &http.Client{Timeout: ProxyHealthTimeout},
+		Port:       ProxyHealthPort,
+	}
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	if pod.Status.PodIP == "" || pod.DeletionTimestamp != nil {
+		return false
+	}
+
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
+func (c *ProxyHealthChecker) checkPod(ctx context.Context, pod *corev1.Pod) (bool, error) {
+	url := fmt.Sprintf("http://%s:%d%s", pod.Status.PodIP, c.Port, ProxyHealthPath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+
+	if err != nil {
+		return false, err
+	}
+
+	res, err := c.HttpClient.Do(req)
+
+	if err != nil {
+		return false, err
+	}
+
+	res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusServiceUnavailable:
+		return false, nil
+	}
+
+	return false, fmt.Errorf("unexpected status from proxy %s: %s", pod.Name, res.Status)
+}
+
+// IsRemoteReachable is false when every proxy of the service that answered reports the remote
+// cluster as unreachable, or when none of the proxies could be asked at all. Without any ready
+// proxy, there's no reason to change the traffic.
+func (c *ProxyHealthChecker) IsRemoteReachable(ctx context.Context, service *servingv1.Service) (bool, error) {
+	var pods corev1.PodList
+
+	configurationName := utils.GetConfigurationNamespacedName(types.NamespacedName{Name: service.Name, Namespace: service.Namespace})
+
+	if err := c.List(ctx, &pods, client.InNamespace(service.Namespace), client.MatchingLabels{
+		serving.ConfigurationLabelKey: configurationName.Name,
+		controllers.ManagedLabel:      "true",
+	}); err != nil {
+		return true, fmt.Errorf("couldn't list edge proxy pods: %w", err)
+	}
+
+	return c.checkPods(ctx, pods.Items)
+}
+
+func (c *ProxyHealthChecker) checkPods(ctx context.Context, pods []corev1.Pod) (bool, error) {
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	var lastErr error
+
+	reachable, unreachable, failed := 0, 0, 0
+
+	for i := range pods {
+		pod := &pods[i]
+
+		if !isPodReady(pod) {
+			continue
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			ok, err := c.checkPod(ctx, pod)
+
+			lock.Lock()
+			defer lock.Unlock()
+
+			if err != nil {
+				c.Log.V(controllers.DebugLevel).Info("couldn't check edge proxy health", "pod", pod.Name, "error", err.Error())
+				lastErr = err
+				failed++
+			} else if ok {
+				reachable++
+			} else {
+				unreachable++
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	// the proxies serve the health endpoint as long as they run, so not hearing from any of them
+	// is no sign that the remote cluster is fine
+	if reachable == 0 && unreachable == 0 && failed > 0 {
+		return false, fmt.Errorf("couldn't check any of the %d edge proxies: %w", failed, lastErr)
+	}
+
+	return reachable > 0 || unreachable == 0, nil
+}
@@ -0,0 +1,96 @@
+package health
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("edge proxy health checker", func() {
+	newPod := func(name string) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Status: corev1.PodStatus{
+				PodIP: "127.0.0.1",
+				Conditions: []corev1.PodCondition{
+					{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+				},
+			},
+		}
+	}
+
+	// newProxy serves the health endpoint with the given status, or nothing at all when it's 0
+	newProxy := func(status int) (*ProxyHealthChecker, func()) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != ProxyHealthPath {
+				http.NotFound(w, r)
+				return
+			}
+
+			w.WriteHeader(status)
+		}))
+
+		_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+		Expect(err).NotTo(HaveOccurred())
+
+		checker := NewProxyHealthChecker(logr.Discard(), nil)
+		checker.Port, err = strconv.Atoi(port)
+		Expect(err).NotTo(HaveOccurred())
+
+		if status == 0 {
+			server.Close()
+		}
+
+		return checker, server.Close
+	}
+
+	DescribeTable("checking whether the remote cluster is reachable",
+		func(status int, pods int, expectedReachable bool, expectedErr bool) {
+			checker, stop := newProxy(status)
+			defer stop()
+
+			var items []corev1.Pod
+
+			for i := 0; i < pods; i++ {
+				items = append(items, newPod("proxy-"+strconv.Itoa(i)))
+			}
+
+			reachable, err := checker.checkPods(context.Background(), items)
+
+			Expect(reachable).To(Equal(expectedReachable))
+
+			if expectedErr {
+				Expect(err).To(HaveOccurred())
+			} else {
+				Expect(err).NotTo(HaveOccurred())
+			}
+		},
+		Entry("is reachable when the proxies are up", http.StatusOK, 2, true, false),
+		Entry("is unreachable when the proxies report it down", http.StatusServiceUnavailable, 2, false, false),
+		Entry("is unreachable when no proxy answers", 0, 2, false, true),
+		Entry("is unreachable when the proxies answer with an unexpected status", http.StatusInternalServerError, 1, false, true),
+		Entry("keeps the traffic without ready proxies", http.StatusServiceUnavailable, 0, true, false),
+	)
+
+	It("ignores proxies that aren't ready", func() {
+		checker, stop := newProxy(http.StatusServiceUnavailable)
+		defer stop()
+
+		pod := newPod("proxy")
+		pod.Status.Conditions[0].Status = corev1.ConditionFalse
+
+		reachable, err := checker.checkPods(context.Background(), []corev1.Pod{pod})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reachable).To(BeTrue())
+	})
+})
@@ -0,0 +1,44 @@
+package history
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const HandlerPath = "/debug/workoffload/decisions"
+
+// Handler serves the decision history as json, either for all services, or for a single one
+// with HandlerPath/<namespace>/<name>.
+func (h *DecisionHistory) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var body interface{}
+		path := strings.Trim(strings.TrimPrefix(r.URL.Path, HandlerPath), "/")
+
+		if path == "" {
+			body = h.GetAll()
+		} else {
+			namespace, name, found := strings.Cut(path, "/")
+
+			if !found || namespace == "" || name == "" || strings.Contains(name, "/") {
+				http.Error(w, "expected <namespace>/<name>", http.StatusBadRequest)
+				return
+			}
+
+			body = h.Get(types.NamespacedName{Name: name, Namespace: namespace}.String())
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(w).Encode(body); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
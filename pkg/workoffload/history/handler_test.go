@@ -0,0 +1,60 @@
+package history
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("decision history handler", func() {
+	h := &DecisionHistory{Size: 3}
+	h.Record(Decision{Service: "default/hello", Strategy: "request-rate", Traffic: 40})
+	h.Record(Decision{Service: "default/world", Strategy: "fixed-schedule", Traffic: 100})
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		h.Handler().ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+
+		return recorder
+	}
+
+	DescribeTable("requests",
+		func(method, path string, status int) {
+			Expect(serve(method, path).Code).To(Equal(status))
+		},
+		Entry("all services", http.MethodGet, HandlerPath, http.StatusOK),
+		Entry("all services with a trailing slash", http.MethodGet, HandlerPath+"/", http.StatusOK),
+		Entry("single service", http.MethodGet, HandlerPath+"/default/hello", http.StatusOK),
+		Entry("unknown service", http.MethodGet, HandlerPath+"/default/missing", http.StatusOK),
+		Entry("namespace only", http.MethodGet, HandlerPath+"/default", http.StatusBadRequest),
+		Entry("too many segments", http.MethodGet, HandlerPath+"/default/hello/world", http.StatusBadRequest),
+		Entry("other method", http.MethodPost, HandlerPath, http.StatusMethodNotAllowed),
+	)
+
+	It("serves the decisions of all services", func() {
+		var body map[string][]Decision
+		Expect(json.Unmarshal(serve(http.MethodGet, HandlerPath).Body.Bytes(), &body)).To(Succeed())
+
+		Expect(body).To(HaveLen(2))
+		Expect(body["default/world"]).To(HaveLen(1))
+		Expect(body["default/world"][0].Traffic).To(BeEquivalentTo(100))
+	})
+
+	It("serves the decisions of a single service", func() {
+		recorder := serve(http.MethodGet, HandlerPath+"/default/hello")
+		Expect(recorder.Header().Get("Content-Type")).To(Equal("application/json"))
+
+		var body []Decision
+		Expect(json.Unmarshal(recorder.Body.Bytes(), &body)).To(Succeed())
+
+		Expect(body).To(HaveLen(1))
+		Expect(body[0].Strategy).To(Equal("request-rate"))
+	})
+
+	It("serves an empty list for unknown services", func() {
+		Expect(serve(http.MethodGet, HandlerPath+"/default/missing").Body.String()).To(Equal("[]\n"))
+	})
+})
@@ -0,0 +1,125 @@
+package history
+
+import (
+	"sync"
+	"time"
+)
+
+// keep the last 60 decisions of each service, i.e. one hour with the default evaluation period
+const DefaultDecisionHistorySize = 60
+
+// Decision is a single traffic offload decision taken for a service, with the inputs that led
+// to it.
+type Decision struct {
+	Timestamp time.Time `json:"timestamp"`
+	Service   string    `json:"service"`
+	Strategy  string    `json:"strategy"`
+	Action    string    `json:"action"`
+
+	Inputs map[string]string `json:"inputs,omitempty"`
+
+	PreviousTraffic int64 `json:"previousTraffic"`
+	DesiredTraffic  int64 `json:"desiredTraffic"`
+	Traffic         int64 `json:"traffic"`
+}
+
+// ring is a fixed size buffer which overwrites its oldest decisions.
+type ring struct {
+	decisions []Decision
+	next      int
+	full      bool
+}
+
+func (r *ring) add(decision Decision) {
+	r.decisions[r.next] = decision
+	r.next = (r.next + 1) % len(r.decisions)
+
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// list returns the decisions from oldest to newest.
+func (r *ring) list() []Decision {
+	if !r.full {
+		ret := make([]Decision, r.next)
+		copy(ret, r.decisions[:r.next])
+		return ret
+	}
+
+	ret := make([]Decision, 0, len(r.decisions))
+	ret = append(ret, r.decisions[r.next:]...)
+	ret = append(ret, r.decisions[:r.next]...)
+
+	return ret
+}
+
+// DecisionHistory keeps a bounded history of decisions for each service.
+type DecisionHistory struct {
+	Size int
+
+	buffers map[string]*ring
+	lock    sync.RWMutex
+}
+
+func (h *DecisionHistory) Record(decision Decision) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.buffers == nil {
+		h.buffers = make(map[string]*ring)
+	}
+
+	buffer, exists := h.buffers[decision.Service]
+
+	if !exists {
+		size := h.Size
+
+		if size <= 0 {
+			size = DefaultDecisionHistorySize
+		}
+
+		buffer = &ring{decisions: make([]Decision, size)}
+		h.buffers[decision.Service] = buffer
+	}
+
+	buffer.add(decision)
+}
+
+func (h *DecisionHistory) Get(service string) []Decision {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	buffer, exists := h.buffers[service]
+
+	if !exists {
+		return []Decision{}
+	}
+
+	return buffer.list()
+}
+
+func (h *DecisionHistory) GetAll() map[string][]Decision {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	ret := make(map[string][]Decision, len(h.buffers))
+
+	for service, buffer := range h.buffers {
+		ret[service] = buffer.list()
+	}
+
+	return ret
+}
+
+// Forget removes the decisions of services that are no longer offloaded.
+func (h *DecisionHistory) Forget(services map[string]bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	for service := range h.buffers {
+		if !services[service] {
+			delete(h.buffers, service)
+		}
+	}
+}
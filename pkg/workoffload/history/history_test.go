@@ -0,0 +1,74 @@
+package history
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("decision history", func() {
+	record := func(h *DecisionHistory, service string, traffic ...int64) {
+		for _, value := range traffic {
+			h.Record(Decision{Service: service, Traffic: value})
+		}
+	}
+
+	traffics := func(decisions []Decision) []int64 {
+		ret := make([]int64, 0, len(decisions))
+
+		for _, decision := range decisions {
+			ret = append(ret, decision.Traffic)
+		}
+
+		return ret
+	}
+
+	DescribeTable("keeping the last decisions from oldest to newest",
+		func(size int, traffic []int64, expected []int64) {
+			h := &DecisionHistory{Size: size}
+			record(h, "default/hello", traffic...)
+
+			Expect(traffics(h.Get("default/hello"))).To(Equal(expected))
+		},
+		Entry("empty", 3, []int64{}, []int64{}),
+		Entry("partially filled", 3, []int64{10, 20}, []int64{10, 20}),
+		Entry("exactly filled", 3, []int64{10, 20, 30}, []int64{10, 20, 30}),
+		Entry("wrapped around", 3, []int64{10, 20, 30, 40, 50}, []int64{30, 40, 50}),
+		Entry("wrapped around twice", 2, []int64{10, 20, 30, 40, 50}, []int64{40, 50}),
+	)
+
+	It("defaults the size", func() {
+		h := &DecisionHistory{}
+
+		for i := 0; i < DefaultDecisionHistorySize+5; i++ {
+			record(h, "default/hello", int64(i))
+		}
+
+		decisions := h.Get("default/hello")
+		Expect(decisions).To(HaveLen(DefaultDecisionHistorySize))
+		Expect(decisions[0].Traffic).To(BeEquivalentTo(5))
+	})
+
+	It("doesn't share the decisions it returns", func() {
+		h := &DecisionHistory{Size: 3}
+		record(h, "default/hello", 10)
+
+		h.Get("default/hello")[0].Traffic = 99
+
+		Expect(traffics(h.Get("default/hello"))).To(Equal([]int64{10}))
+	})
+
+	It("keeps the decisions of each service apart and forgets the removed ones", func() {
+		h := &DecisionHistory{Size: 3}
+		record(h, "default/hello", 10, 20)
+		record(h, "default/world", 30)
+
+		Expect(h.GetAll()).To(HaveLen(2))
+		Expect(traffics(h.Get("default/world"))).To(Equal([]int64{30}))
+
+		h.Forget(map[string]bool{"default/world": true})
+
+		Expect(h.Get("default/hello")).To(BeEmpty())
+		Expect(h.GetAll()).To(HaveKey("default/world"))
+		Expect(h.GetAll()).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,121 @@
+package pressure
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
+
+	"k8s.io/apimachinery/pkg/types"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"edge.jevv.dev/pkg/controllers"
+	"edge.jevv.dev/pkg/workoffload/prometheus/usage"
+	"edge.jevv.dev/pkg/workoffload/strategy"
+)
+
+// ResourcePressureStrategy offloads traffic while the cluster, the nodes running the pods of a
+// service, or the pods themselves are under cpu or memory pressure, so the edge sheds load
+// before latency degrades. It only needs metrics-server.
+type ResourcePressureStrategy struct {
+	Log logr.Logger
+
+	Collector usage.Collector
+
+	cluster *usage.ClusterUsage
+}
+
+func NewResourcePressureStrategy(log logr.Logger, client client.Client, metricsClient *metricsv.Clientset) *ResourcePressureStrategy {
+	return &ResourcePressureStrategy{
+		Log: log.WithName("resource-pressure"),
+		Collector: usage.Collector{
+			Client:        client,
+			Log:           log.WithName("resource-pressure"),
+			MetricsClient: metricsClient,
+		},
+	}
+}
+
+func (s *ResourcePressureStrategy) Execute(ctx context.Context) error {
+	debug := s.Log.V(controllers.DebugLevel)
+
+	cluster := usage.NewClusterUsage()
+
+	if err := s.Collector.UpdateNodesUsage(ctx, cluster); err != nil {
+		return err
+	}
+
+	if err := s.Collector.UpdatePodsUsage(ctx, cluster); err != nil {
+		return err
+	}
+
+	// service percentages are relative to the cluster capacity
+	cluster.FinalizeClusterMetrics()
+	cluster.FinalizeKServiceMetrics()
+	s.cluster = cluster
+
+	debug.Info("debug cluster pressure", "nodes", len(cluster.Nodes), "cpu", cluster.CpuPressure, "mem", cluster.MemoryPressure)
+
+	return nil
+}
+
+func (s *ResourcePressureStrategy) GetResults(services []servingv1.Service) []strategy.WorkOffloadServiceResult {
+	debug := s.Log.V(controllers.DebugLevel)
+
+	ret := make([]strategy.WorkOffloadServiceResult, 0, len(services))
+
+	for i := range services {
+		service := &services[i]
+		serviceName := types.NamespacedName{Name: service.Name, Namespace: service.Namespace}
+		thresholds := usage.GetPressureThresholds(*service)
+
+		var action strategy.TrafficAction = strategy.DecreaseTraffic
+
+		underPressure, inputs := s.isUnderPressure(serviceName, thresholds)
+
+		if underPressure {
+			action = strategy.IncreaseTraffic
+		}
+
+		ret = append(ret, strategy.WorkOffloadServiceResult{
+			Name:    serviceName,
+			Service: service,
+			Action:  action,
+			Inputs:  inputs,
+		})
+
+		debug.Info("debug results", "service", serviceName, "thresholds", thresholds, "action", action)
+	}
+
+	return ret
+}
+
+func (s *ResourcePressureStrategy) isUnderPressure(serviceName types.NamespacedName, thresholds usage.PressureThresholds) (bool, map[string]string) {
+	cpuPressure, memoryPressure := s.cluster.GetPressure(thresholds)
+
+	inputs := map[string]string{
+		"cpuPressureThreshold":    strategy.FormatInput(thresholds.Cpu),
+		"memoryPressureThreshold": strategy.FormatInput(thresholds.Memory),
+		"clusterCpuPressure":      cpuPressure.String(),
+		"clusterMemoryPressure":   memoryPressure.String(),
+	}
+
+	if cpuPressure == usage.HighPressure || memoryPressure == usage.HighPressure {
+		return true, inputs
+	}
+
+	serviceUsage, exists := s.cluster.Services[serviceName.String()]
+
+	if !exists {
+		return false, inputs
+	}
+
+	serviceUsage.UpdatePressure(s.cluster.Nodes, thresholds)
+
+	inputs["serviceCpuPressure"] = serviceUsage.CpuPressure.String()
+	inputs["serviceMemoryPressure"] = serviceUsage.MemoryPressure.String()
+
+	return serviceUsage.CpuPressure == usage.HighPressure || serviceUsage.MemoryPressure == usage.HighPressure, inputs
+}
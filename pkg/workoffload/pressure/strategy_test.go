@@ -0,0 +1,72 @@
+package pressure
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+
+	"edge.jevv.dev/pkg/workoffload/prometheus/usage"
+	"edge.jevv.dev/pkg/workoffload/strategy"
+)
+
+var _ = Describe("resource pressure strategy", func() {
+	// newCluster has a node for each cpu percentage, and the pods of the service on the first one
+	newCluster := func(nodeCpu []float32, podCpu ...float32) *usage.ClusterUsage {
+		cluster := usage.NewClusterUsage()
+
+		for i, cpu := range nodeCpu {
+			name := fmt.Sprintf("node-%d", i)
+			cluster.Nodes[name] = &usage.NodeUsage{Name: name, Cpu: usage.UsageMetric{Percentage: cpu}}
+		}
+
+		service := cluster.AddKService("hello", "default")
+
+		for i, cpu := range podCpu {
+			service.Pods = append(service.Pods, &usage.PodUsage{Name: fmt.Sprintf("hello-%d", i), Node: "node-0", Cpu: usage.UsageMetric{Percentage: cpu}})
+		}
+
+		return cluster
+	}
+
+	newService := func(annotations map[string]string) servingv1.Service {
+		return servingv1.Service{ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "default", Annotations: annotations}}
+	}
+
+	DescribeTable("offloading under pressure",
+		func(cluster *usage.ClusterUsage, annotations map[string]string, action int) {
+			s := &ResourcePressureStrategy{Log: logr.Discard(), cluster: cluster}
+
+			results := s.GetResults([]servingv1.Service{newService(annotations)})
+
+			Expect(results).To(HaveLen(1))
+			Expect(results[0].Name.String()).To(Equal("default/hello"))
+			Expect(results[0].Action).To(BeEquivalentTo(action))
+			Expect(results[0].Inputs).To(HaveKeyWithValue("cpuPressureThreshold", "80.000"))
+		},
+		Entry("idle cluster", newCluster([]float32{10, 20, 30}, 10), nil, strategy.DecreaseTraffic),
+		Entry("most nodes under pressure", newCluster([]float32{90, 85, 30}), nil, strategy.IncreaseTraffic),
+		Entry("a few nodes under pressure", newCluster([]float32{10, 20, 90}), nil, strategy.DecreaseTraffic),
+		Entry("pod under pressure", newCluster([]float32{10, 20, 30}, 10, 95), nil, strategy.IncreaseTraffic),
+		Entry("node of the pods under pressure", newCluster([]float32{90, 20, 30}, 10), nil, strategy.IncreaseTraffic),
+		Entry("service without pods", newCluster([]float32{90, 20, 30}), nil, strategy.DecreaseTraffic),
+	)
+
+	It("applies the thresholds of the service", func() {
+		s := &ResourcePressureStrategy{Log: logr.Discard(), cluster: newCluster([]float32{10, 20, 30}, 50)}
+
+		results := s.GetResults([]servingv1.Service{
+			newService(nil),
+			newService(map[string]string{usage.CpuPressureThresholdAnnotation: "40"}),
+		})
+
+		Expect(results[0].Action).To(BeEquivalentTo(strategy.DecreaseTraffic))
+		Expect(results[1].Action).To(BeEquivalentTo(strategy.IncreaseTraffic))
+		Expect(results[1].Inputs).To(HaveKeyWithValue("cpuPressureThreshold", "40.000"))
+		Expect(results[1].Inputs).To(HaveKeyWithValue("serviceCpuPressure", usage.HighPressure.String()))
+	})
+})
@@ -13,6 +13,7 @@ import (
 	"github.com/go-logr/logr"
 
 	"edge.jevv.dev/pkg/controllers"
+	"edge.jevv.dev/pkg/metrics"
 )
 
 var ErrPrometheusBadRequest = errors.New("prometheus rejected the request")
@@ -143,6 +144,8 @@ func (t *PrometheusClient) QueryWithRetry(ctx context.Context, q PrometheusQuery
 			return result, nil
 		}
 
+		metrics.PrometheusQueryFailures.Inc()
+
 		if errors.Is(err, ErrPrometheusServiceUnavailable) {
 			// give it some time to recover
 			time.Sleep(time.Second / 5)
@@ -0,0 +1,147 @@
+package prometheus
+
+import (
+	"context"
+	"math"
+	"strconv"
+
+	"github.com/go-logr/logr"
+
+	"k8s.io/apimachinery/pkg/types"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+
+	"edge.jevv.dev/pkg/controllers"
+	prometheus "edge.jevv.dev/pkg/workoffload/prometheus/client"
+	"edge.jevv.dev/pkg/workoffload/prometheus/usage"
+	"edge.jevv.dev/pkg/workoffload/strategy"
+)
+
+// RequestRateStrategy offloads traffic once the request rate of a service goes over its soft
+// limit, up to all the traffic when it reaches the hard limit.
+type RequestRateStrategy struct {
+	Log logr.Logger
+
+	PrometheusClient prometheus.PrometheusClient
+
+	rates map[string]float32
+}
+
+func NewRequestRateStrategy(log logr.Logger, prometheusUrl string) (*RequestRateStrategy, error) {
+	prometheusClient, err := newPrometheusClient(log, prometheusUrl)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &RequestRateStrategy{
+		Log:              log.WithName("request-rate"),
+		PrometheusClient: *prometheusClient,
+	}, nil
+}
+
+func (s *RequestRateStrategy) Execute(ctx context.Context) error {
+	debug := s.Log.V(controllers.DebugLevel)
+
+	result, err := s.PrometheusClient.QueryWithRetry(ctx, ServiceRequestRate)
+
+	if err != nil {
+		return err
+	}
+
+	rates := make(map[string]float32, len(result.Data))
+
+	for _, data := range result.Data {
+		namespace := data.Metric["namespace_name"]
+		serviceName := data.Metric["service_name"]
+
+		if serviceName == "" {
+			continue
+		}
+
+		// only the most recent value matters
+		for i := len(data.Data) - 1; i >= 0; i-- {
+			value, err := strconv.ParseFloat(data.Data[i].Value, 32)
+
+			if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+				continue
+			}
+
+			name := types.NamespacedName{Name: serviceName, Namespace: namespace}
+			rates[name.String()] = float32(value)
+
+			break
+		}
+	}
+
+	s.rates = rates
+	debug.Info("debug request rates", "rates", rates)
+
+	return nil
+}
+
+func (s *RequestRateStrategy) GetResults(services []servingv1.Service) []strategy.WorkOffloadServiceResult {
+	ret := make([]strategy.WorkOffloadServiceResult, 0, len(services))
+
+	for i := range services {
+		service := &services[i]
+		serviceName := types.NamespacedName{Name: service.Name, Namespace: service.Namespace}
+
+		var action strategy.TrafficAction = strategy.PreserveTraffic
+		var desiredTraffic int64 = -1
+		var inputs map[string]string
+
+		// don't update traffic if there's no data for the service
+		if rate, exists := s.rates[serviceName.String()]; exists {
+			softLimit, hardLimit := getRequestRateLimits(service)
+
+			action = strategy.SetTraffic
+			inputs = map[string]string{
+				"requestRate":          strategy.FormatInput(rate),
+				"requestRateSoftLimit": strategy.FormatInput(softLimit),
+				"requestRateHardLimit": strategy.FormatInput(hardLimit),
+			}
+
+			if rate < softLimit {
+				desiredTraffic = 0
+			} else if rate >= hardLimit {
+				desiredTraffic = 100
+			} else {
+				desiredTraffic = int64(100.0 * (rate - softLimit) / (hardLimit - softLimit))
+			}
+		}
+
+		ret = append(ret, strategy.WorkOffloadServiceResult{
+			Name:           serviceName,
+			Service:        service,
+			Action:         action,
+			DesiredTraffic: desiredTraffic,
+			Inputs:         inputs,
+		})
+	}
+
+	return ret
+}
+
+func getRequestRateLimits(service *servingv1.Service) (float32, float32) {
+	var softLimit float32 = usage.RequestRateSoftLimitAnnotationDefaultValue
+	var hardLimit float32 = usage.RequestRateHardLimitAnnotationDefaultValue
+
+	if service.Annotations == nil {
+		return softLimit, hardLimit
+	}
+
+	if value, err := strconv.ParseFloat(service.Annotations[usage.RequestRateSoftLimitAnnotation], 32); err == nil {
+		softLimit = float32(value)
+	}
+
+	if value, err := strconv.ParseFloat(service.Annotations[usage.RequestRateHardLimitAnnotation], 32); err == nil {
+		hardLimit = float32(value)
+	}
+
+	// a hard limit under the soft limit means all or nothing
+	if hardLimit < softLimit {
+		hardLimit = softLimit
+	}
+
+	return softLimit, hardLimit
+}
@@ -0,0 +1,97 @@
+package prometheus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+
+	prometheus "edge.jevv.dev/pkg/workoffload/prometheus/client"
+	"edge.jevv.dev/pkg/workoffload/prometheus/usage"
+	"edge.jevv.dev/pkg/workoffload/strategy"
+)
+
+var _ = Describe("request rate strategy", func() {
+	newService := func(name string, annotations map[string]string) servingv1.Service {
+		return servingv1.Service{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Annotations: annotations}}
+	}
+
+	limits := func(soft, hard string) map[string]string {
+		return map[string]string{
+			usage.RequestRateSoftLimitAnnotation: soft,
+			usage.RequestRateHardLimitAnnotation: hard,
+		}
+	}
+
+	DescribeTable("interpolating the traffic between the limits",
+		func(rate float32, annotations map[string]string, traffic int64) {
+			s := &RequestRateStrategy{Log: logr.Discard(), rates: map[string]float32{"default/hello": rate}}
+
+			results := s.GetResults([]servingv1.Service{newService("hello", annotations)})
+
+			Expect(results).To(HaveLen(1))
+			Expect(results[0].Action).To(BeEquivalentTo(strategy.SetTraffic))
+			Expect(results[0].DesiredTraffic).To(Equal(traffic))
+			Expect(results[0].Inputs).To(HaveKey("requestRate"))
+		},
+		Entry("idle", float32(0), nil, int64(0)),
+		Entry("under the default soft limit", float32(49), nil, int64(0)),
+		Entry("at the default soft limit", float32(50), nil, int64(0)),
+		Entry("between the default limits", float32(125), nil, int64(50)),
+		Entry("at the default hard limit", float32(200), nil, int64(100)),
+		Entry("over the default hard limit", float32(1000), nil, int64(100)),
+		Entry("between the limits of the service", float32(15), limits("10", "20"), int64(50)),
+		Entry("close to the hard limit of the service", float32(19.9), limits("10", "20"), int64(98)),
+		Entry("hard limit under the soft limit", float32(15), limits("20", "10"), int64(0)),
+		Entry("at the limit when they're the same", float32(20), limits("20", "10"), int64(100)),
+		Entry("invalid limits", float32(125), limits("many", "more"), int64(50)),
+	)
+
+	It("preserves the traffic of services without a rate", func() {
+		s := &RequestRateStrategy{Log: logr.Discard(), rates: map[string]float32{"default/hello": 1000}}
+
+		results := s.GetResults([]servingv1.Service{newService("world", nil)})
+
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].Action).To(BeEquivalentTo(strategy.PreserveTraffic))
+		Expect(results[0].DesiredTraffic).To(BeEquivalentTo(-1))
+		Expect(results[0].Inputs).To(BeNil())
+	})
+
+	It("keeps the most recent valid rate of each service", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{
+				"status": "success",
+				"data": {
+					"resultType": "matrix",
+					"result": [
+						{"metric": {"namespace_name": "default", "service_name": "hello"}, "values": [[1, "10"], [2, "42"]]},
+						{"metric": {"namespace_name": "default", "service_name": "world"}, "values": [[1, "7"], [2, "NaN"]]},
+						{"metric": {"namespace_name": "default", "service_name": "empty"}, "values": [[1, "NaN"], [2, "+Inf"]]},
+						{"metric": {"namespace_name": "default"}, "values": [[1, "5"]]}
+					]
+				}
+			}`))
+		}))
+		defer server.Close()
+
+		serverURL, err := url.Parse(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+
+		s := &RequestRateStrategy{
+			Log:              logr.Discard(),
+			PrometheusClient: prometheus.PrometheusClient{Log: logr.Discard(), Url: *serverURL},
+		}
+
+		Expect(s.Execute(context.Background())).To(Succeed())
+		Expect(s.rates).To(Equal(map[string]float32{"default/hello": 42, "default/world": 7}))
+	})
+})
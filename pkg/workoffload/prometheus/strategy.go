@@ -8,9 +8,6 @@ import (
 	"github.com/go-logr/logr"
 	metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
 
-	corev1 "k8s.io/api/core/v1"
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
 
@@ -22,23 +19,36 @@ import (
 	"edge.jevv.dev/pkg/workoffload/strategy"
 )
 
-//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
-//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
-//+kubebuilder:rbac:groups=metrics.k8s.io,resources=nodes,verbs=get;list;watch
-//+kubebuilder:rbac:groups=metrics.k8s.io,resources=pods,verbs=get;list;watch
-
+// PrometheusStrategy offloads traffic based on the ratio between the 95th and 50th percentiles
+// of the request latency of each service.
 type PrometheusStrategy struct {
-	client.Client
-
 	Log logr.Logger
 
 	PrometheusClient prometheus.PrometheusClient
-	MetricsClient    *metricsv.Clientset
+	Collector        usage.Collector
 
 	cluster *usage.ClusterUsage
 }
 
 func NewStrategy(log logr.Logger, prometheusUrl string, client client.Client, metricsClient *metricsv.Clientset) (*PrometheusStrategy, error) {
+	prometheusClient, err := newPrometheusClient(log, prometheusUrl)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &PrometheusStrategy{
+		Log:              log.WithName("prometheus"),
+		PrometheusClient: *prometheusClient,
+		Collector: usage.Collector{
+			Client:        client,
+			Log:           log.WithName("prometheus"),
+			MetricsClient: metricsClient,
+		},
+	}, nil
+}
+
+func newPrometheusClient(log logr.Logger, prometheusUrl string) (*prometheus.PrometheusClient, error) {
 	var prometheusURL *url.URL
 	var err error
 
@@ -54,14 +64,9 @@ func NewStrategy(log logr.Logger, prometheusUrl string, client client.Client, me
 		return nil, fmt.Errorf("prometheus url is invalid: %w", err)
 	}
 
-	return &PrometheusStrategy{
-		Client:        client,
-		Log:           log.WithName("prometheus"),
-		MetricsClient: metricsClient,
-		PrometheusClient: prometheus.PrometheusClient{
-			Log: log.WithName("prometheus"),
-			Url: *prometheusURL,
-		},
+	return &prometheus.PrometheusClient{
+		Log: log.WithName("prometheus"),
+		Url: *prometheusURL,
 	}, nil
 }
 
@@ -71,11 +76,11 @@ func (s *PrometheusStrategy) Execute(ctx context.Context) error {
 	var err error
 	cluster := usage.NewClusterUsage()
 
-	if err = s.updateNodesUsage(ctx, cluster); err != nil {
+	if err = s.Collector.UpdateNodesUsage(ctx, cluster); err != nil {
 		return err
 	}
 
-	if err := s.updatePodsUsage(ctx, cluster); err != nil {
+	if err := s.Collector.UpdatePodsUsage(ctx, cluster); err != nil {
 		return err
 	}
 
@@ -94,94 +99,6 @@ func (s *PrometheusStrategy) Execute(ctx context.Context) error {
 	return nil
 }
 
-func (s *PrometheusStrategy) updateNodesUsage(ctx context.Context, cluster *usage.ClusterUsage) error {
-	debug := s.Log.V(controllers.DebugLevel + 1)
-
-	var nodeList corev1.NodeList
-
-	if err := s.Client.List(ctx, &nodeList); err != nil {
-		return fmt.Errorf("cannot list nodes metrics: %w", err)
-	}
-
-	nodeMetricsList, err := s.MetricsClient.MetricsV1beta1().NodeMetricses().List(ctx, metav1.ListOptions{})
-
-	if err != nil {
-		return fmt.Errorf("cannot list node metrics: %w", err)
-	}
-
-	defer cluster.FinalizeNodeMetrics()
-
-	for _, node := range nodeList.Items {
-		debug.Info("debug node", "node", node.Name, "cpu", node.Status.Capacity.Cpu(), "mem", node.Status.Capacity.Memory())
-		cluster.AddNode(node)
-	}
-
-	for _, nodeMetrics := range nodeMetricsList.Items {
-		debug.Info("debug metrics", "node", nodeMetrics.Name, "cpu", nodeMetrics.Usage.Cpu(), "mem", nodeMetrics.Usage.Memory())
-		cluster.UpdateNodeMetrics(nodeMetrics)
-	}
-
-	return nil
-}
-
-func (s *PrometheusStrategy) updatePodsUsage(ctx context.Context, cluster *usage.ClusterUsage) error {
-	debug := s.Log.V(controllers.DebugLevel + 1)
-
-	var namespaceList corev1.NamespaceList
-
-	if err := s.Client.List(ctx, &namespaceList); err != nil {
-		return fmt.Errorf("cannot list namespaces: %w", err)
-	}
-
-	defer cluster.FinalizePodMetrics()
-
-	for _, namespace := range namespaceList.Items {
-		debug.Info("debug update pods", "namespace", namespace.Name)
-
-		err := s.updatePodsUsageInNamespace(ctx, cluster, namespace.Name)
-
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
-func (s *PrometheusStrategy) updatePodsUsageInNamespace(ctx context.Context, cluster *usage.ClusterUsage, namespace string) error {
-	debug := s.Log.V(controllers.DebugLevel + 1)
-
-	podMetricsList, err := s.MetricsClient.MetricsV1beta1().PodMetricses(namespace).List(ctx, metav1.ListOptions{})
-
-	if err != nil {
-		return fmt.Errorf("cannot list pod metrics: %w", err)
-	}
-
-	for _, podMetrics := range podMetricsList.Items {
-		var pod corev1.Pod
-		podName := types.NamespacedName{Name: podMetrics.Name, Namespace: podMetrics.Namespace}
-
-		debug.Info("debug update pods", "pod", podName.String())
-
-		if err := s.Client.Get(ctx, podName, &pod); err != nil {
-			// not in the cache?
-			if apierrors.IsNotFound(err) {
-				debug.Info("debug update pods (not found)", "pod", podName.String())
-				continue
-			}
-
-			return fmt.Errorf("cannot retrieve pod %s: %w", podName.String(), err)
-		}
-
-		debug.Info("debug metrics", "namespace", namespace, "pod", podMetrics.Name, "containers", podMetrics.Containers)
-
-		cluster.AddPod(pod)
-		cluster.UpdatePodMetrics(podMetrics)
-	}
-
-	return nil
-}
-
 func (s *PrometheusStrategy) updateKServiceUsage(ctx context.Context, cluster *usage.ClusterUsage) error {
 	debug := s.Log.V(controllers.DebugLevel)
 
@@ -218,21 +135,32 @@ func (s *PrometheusStrategy) GetResults(services []servingv1.Service) []strategy
 	// now := time.Now()
 	ret := make([]strategy.WorkOffloadServiceResult, 0, len(services))
 
-	for _, service := range services {
+	for i := range services {
+		service := &services[i]
+
 		var action strategy.TrafficAction = strategy.PreserveTraffic
 		var desiredTraffic int64 = -1
 		serviceName := types.NamespacedName{Name: service.Name, Namespace: service.Namespace}
 
 		serviceUsage, exists := s.cluster.Services[serviceName.String()]
 
+		inputs := map[string]string{
+			"clusterCpuPressure":    s.cluster.CpuPressure.String(),
+			"clusterMemoryPressure": s.cluster.MemoryPressure.String(),
+		}
+
 		// don't update traffic if service not in usage
 		if exists {
 			// FIXME: this is ugly
-			serviceUsage.UpdateWithKService(service)
+			serviceUsage.UpdateWithKService(*service)
 			serviceUsage.FinalizeKServiceMetrics()
 
 			action = strategy.SetTraffic
 
+			inputs["latencyRatio"] = strategy.FormatInput(serviceUsage.RequestLatency)
+			inputs["latencyRatioSoftLimit"] = strategy.FormatInput(serviceUsage.RequestLatencySoftLimit)
+			inputs["latencyRatioHardLimit"] = strategy.FormatInput(serviceUsage.RequestLatencyHardLimit)
+
 			if serviceUsage.RequestLatency < serviceUsage.RequestLatencySoftLimit {
 				desiredTraffic = 0
 			} else if serviceUsage.RequestLatency >= serviceUsage.RequestLatencyHardLimit {
@@ -247,9 +175,10 @@ func (s *PrometheusStrategy) GetResults(services []servingv1.Service) []strategy
 
 		ret = append(ret, strategy.WorkOffloadServiceResult{
 			Name:           serviceName,
-			Service:        &service,
+			Service:        service,
 			Action:         action,
 			DesiredTraffic: desiredTraffic,
+			Inputs:         inputs,
 		})
 
 		if serviceUsage != nil {
@@ -48,30 +48,33 @@ func (c *ClusterUsage) FinalizeClusterMetrics() {
 		c.Memory.Percentage = float32(memoryUsage) / float32(memoryCapacity) * 100.0
 	}
 
+	c.CpuPressure, c.MemoryPressure = c.GetPressure(DefaultPressureThresholds)
+}
+
+// GetPressure returns the cpu and memory pressure of the cluster, which is high when enough of
+// its nodes are over the given thresholds.
+func (c *ClusterUsage) GetPressure(thresholds PressureThresholds) (Pressure, Pressure) {
+	if len(c.Nodes) == 0 {
+		return LowPressure, LowPressure
+	}
+
 	nodesWithCpuPressure := 0
 	nodesWithMemPressure := 0
 
 	for _, node := range c.Nodes {
-		if node.CpuPressure == HighPressure {
+		if pressureFromPercentage(node.Cpu.Percentage, thresholds.Cpu) == HighPressure {
 			nodesWithCpuPressure++
 		}
 
-		if node.MemoryPressure == HighPressure {
+		if pressureFromPercentage(node.Memory.Percentage, thresholds.Memory) == HighPressure {
 			nodesWithMemPressure++
 		}
 	}
 
-	if float32(nodesWithCpuPressure)/float32(len(c.Nodes))*100 >= NODE_PRESSURE_THRESHOLD {
-		c.CpuPressure = HighPressure
-	} else {
-		c.CpuPressure = LowPressure
-	}
+	cpuPressure := pressureFromPercentage(float32(nodesWithCpuPressure)/float32(len(c.Nodes))*100, NODE_PRESSURE_THRESHOLD)
+	memoryPressure := pressureFromPercentage(float32(nodesWithMemPressure)/float32(len(c.Nodes))*100, NODE_PRESSURE_THRESHOLD)
 
-	if float32(nodesWithMemPressure)/float32(len(c.Nodes))*100 >= NODE_PRESSURE_THRESHOLD {
-		c.MemoryPressure = HighPressure
-	} else {
-		c.MemoryPressure = LowPressure
-	}
+	return cpuPressure, memoryPressure
 }
 
 func (c *ClusterUsage) UpdateFromPreviousState(prev *ClusterUsage) {
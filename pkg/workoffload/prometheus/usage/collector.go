@@ -0,0 +1,119 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"edge.jevv.dev/pkg/controllers"
+)
+
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+//+kubebuilder:rbac:groups=metrics.k8s.io,resources=nodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=metrics.k8s.io,resources=pods,verbs=get;list;watch
+
+// Collector fills a ClusterUsage with the node and pod metrics from metrics-server.
+type Collector struct {
+	client.Client
+
+	Log           logr.Logger
+	MetricsClient *metricsv.Clientset
+}
+
+func (c *Collector) UpdateNodesUsage(ctx context.Context, cluster *ClusterUsage) error {
+	debug := c.Log.V(controllers.DebugLevel + 1)
+
+	var nodeList corev1.NodeList
+
+	if err := c.Client.List(ctx, &nodeList); err != nil {
+		return fmt.Errorf("cannot list nodes metrics: %w", err)
+	}
+
+	nodeMetricsList, err := c.MetricsClient.MetricsV1beta1().NodeMetricses().List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		return fmt.Errorf("cannot list node metrics: %w", err)
+	}
+
+	defer cluster.FinalizeNodeMetrics()
+
+	for _, node := range nodeList.Items {
+		debug.Info("debug node", "node", node.Name, "cpu", node.Status.Capacity.Cpu(), "mem", node.Status.Capacity.Memory())
+		cluster.AddNode(node)
+	}
+
+	for _, nodeMetrics := range nodeMetricsList.Items {
+		debug.Info("debug metrics", "node", nodeMetrics.Name, "cpu", nodeMetrics.Usage.Cpu(), "mem", nodeMetrics.Usage.Memory())
+		cluster.UpdateNodeMetrics(nodeMetrics)
+	}
+
+	return nil
+}
+
+func (c *Collector) UpdatePodsUsage(ctx context.Context, cluster *ClusterUsage) error {
+	debug := c.Log.V(controllers.DebugLevel + 1)
+
+	var namespaceList corev1.NamespaceList
+
+	if err := c.Client.List(ctx, &namespaceList); err != nil {
+		return fmt.Errorf("cannot list namespaces: %w", err)
+	}
+
+	defer cluster.FinalizePodMetrics()
+
+	for _, namespace := range namespaceList.Items {
+		debug.Info("debug update pods", "namespace", namespace.Name)
+
+		err := c.updatePodsUsageInNamespace(ctx, cluster, namespace.Name)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Collector) updatePodsUsageInNamespace(ctx context.Context, cluster *ClusterUsage, namespace string) error {
+	debug := c.Log.V(controllers.DebugLevel + 1)
+
+	podMetricsList, err := c.MetricsClient.MetricsV1beta1().PodMetricses(namespace).List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		return fmt.Errorf("cannot list pod metrics: %w", err)
+	}
+
+	for _, podMetrics := range podMetricsList.Items {
+		var pod corev1.Pod
+		podName := types.NamespacedName{Name: podMetrics.Name, Namespace: podMetrics.Namespace}
+
+		debug.Info("debug update pods", "pod", podName.String())
+
+		if err := c.Client.Get(ctx, podName, &pod); err != nil {
+			// not in the cache?
+			if apierrors.IsNotFound(err) {
+				debug.Info("debug update pods (not found)", "pod", podName.String())
+				continue
+			}
+
+			return fmt.Errorf("cannot retrieve pod %s: %w", podName.String(), err)
+		}
+
+		debug.Info("debug metrics", "namespace", namespace, "pod", podMetrics.Name, "containers", podMetrics.Containers)
+
+		cluster.AddPod(pod)
+		cluster.UpdatePodMetrics(podMetrics)
+	}
+
+	return nil
+}
@@ -11,3 +11,16 @@ const (
 	LatencyRatioHardLimitAnnotationDefaultValue = 4.0
 	LatencyRatioDecayAnnotationDefaultValue     = 0.75
 )
+
+const (
+	RequestRateSoftLimitAnnotation = "strategy.edge.jevv.dev/request-rate-soft-limit"
+	RequestRateHardLimitAnnotation = "strategy.edge.jevv.dev/request-rate-hard-limit"
+
+	RequestRateSoftLimitAnnotationDefaultValue = 50.0
+	RequestRateHardLimitAnnotationDefaultValue = 200.0
+)
+
+const (
+	CpuPressureThresholdAnnotation    = "strategy.edge.jevv.dev/cpu-pressure-threshold"
+	MemoryPressureThresholdAnnotation = "strategy.edge.jevv.dev/memory-pressure-threshold"
+)
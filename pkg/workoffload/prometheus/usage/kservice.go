@@ -200,6 +200,37 @@ func (s *KServiceUsage) FinalizeKServiceMetrics() {
 	s.RequestLatency = requestLatency
 }
 
+// UpdatePressure sets the pressure of the service, which is high when one of its pods is over
+// the thresholds relative to its limits, or runs on a node that is over them.
+func (s *KServiceUsage) UpdatePressure(nodes map[string]*NodeUsage, thresholds PressureThresholds) {
+	s.CpuPressure = LowPressure
+	s.MemoryPressure = LowPressure
+
+	for _, pod := range s.Pods {
+		if pressureFromPercentage(pod.Cpu.Percentage, thresholds.Cpu) == HighPressure {
+			s.CpuPressure = HighPressure
+		}
+
+		if pressureFromPercentage(pod.Memory.Percentage, thresholds.Memory) == HighPressure {
+			s.MemoryPressure = HighPressure
+		}
+
+		node, exists := nodes[pod.Node]
+
+		if !exists {
+			continue
+		}
+
+		if pressureFromPercentage(node.Cpu.Percentage, thresholds.Cpu) == HighPressure {
+			s.CpuPressure = HighPressure
+		}
+
+		if pressureFromPercentage(node.Memory.Percentage, thresholds.Memory) == HighPressure {
+			s.MemoryPressure = HighPressure
+		}
+	}
+}
+
 func (c *ClusterUsage) AddKService(name, namespace string) *KServiceUsage {
 	namespacedName := types.NamespacedName{Name: name, Namespace: namespace}
 	usage, exists := c.Services[namespacedName.String()]
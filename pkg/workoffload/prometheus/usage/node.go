@@ -98,16 +98,7 @@ func (c *ClusterUsage) UpdateNodeMetrics(metric metricsv1beta1.NodeMetrics) {
 
 func (c *ClusterUsage) FinalizeNodeMetrics() {
 	for _, node := range c.Nodes {
-		if node.Cpu.Percentage >= CPU_PRESSURE_THRESHOLD {
-			node.CpuPressure = HighPressure
-		} else {
-			node.CpuPressure = LowPressure
-		}
-
-		if node.Memory.Percentage >= MEM_PRESSURE_THRESHOLD {
-			node.MemoryPressure = HighPressure
-		} else {
-			node.MemoryPressure = LowPressure
-		}
+		node.CpuPressure = pressureFromPercentage(node.Cpu.Percentage, CPU_PRESSURE_THRESHOLD)
+		node.MemoryPressure = pressureFromPercentage(node.Memory.Percentage, MEM_PRESSURE_THRESHOLD)
 	}
 }
@@ -19,6 +19,10 @@ type PodUsage struct {
 	Cpu     UsageMetric
 	Memory  UsageMetric
 	Storage UsageMetric
+
+	// sum of the container limits, if all containers have one
+	CpuLimit    int64
+	MemoryLimit int64
 }
 
 func (p *PodUsage) UpdateCpuUsage(q *resource.Quantity) {
@@ -87,6 +91,8 @@ func (c *ClusterUsage) AddPod(pod corev1.Pod) *PodUsage {
 		usage.Node = pod.Spec.NodeName
 	}
 
+	usage.CpuLimit, usage.MemoryLimit = getPodLimits(pod)
+
 	c.Pods[namespacedName.String()] = usage
 
 	if pod.Labels == nil {
@@ -117,14 +123,46 @@ func (c *ClusterUsage) UpdatePodMetrics(metric metricsv1beta1.PodMetrics) {
 
 func (c *ClusterUsage) FinalizePodMetrics() {
 	for _, pod := range c.Pods {
-		node, exists := c.Nodes[pod.Node]
+		cpuCapacity := pod.CpuLimit
+		memoryCapacity := pod.MemoryLimit
+
+		// pods without limits can use the whole node
+		if node, exists := c.Nodes[pod.Node]; exists {
+			if cpuCapacity <= 0 {
+				cpuCapacity = node.Cpu.Capacity
+			}
+
+			if memoryCapacity <= 0 {
+				memoryCapacity = node.Memory.Capacity
+			}
+		}
+
+		pod.UpdateCpuCapacity(cpuCapacity)
+		pod.UpdateMemoryCapacity(memoryCapacity)
+	}
+}
+
+func getPodLimits(pod corev1.Pod) (int64, int64) {
+	var cpuLimit int64 = 0
+	var memoryLimit int64 = 0
+
+	for _, container := range pod.Spec.Containers {
+		cpu := container.Resources.Limits.Cpu()
+		memory := container.Resources.Limits.Memory()
 
-		if !exists {
-			// TODO: log?
-			continue
+		// a single container without a limit leaves the pod unbounded
+		if cpu.IsZero() || cpuLimit < 0 {
+			cpuLimit = -1
+		} else {
+			cpuLimit += cpu.MilliValue()
 		}
 
-		pod.UpdateCpuCapacity(node.Cpu.Capacity)
-		pod.UpdateMemoryCapacity(node.Memory.Capacity)
+		if memory.IsZero() || memoryLimit < 0 {
+			memoryLimit = -1
+		} else {
+			memoryLimit += memory.Value()
+		}
 	}
+
+	return cpuLimit, memoryLimit
 }
@@ -1,5 +1,11 @@
 package usage
 
+import (
+	"strconv"
+
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+)
+
 type Pressure int
 
 const (
@@ -23,3 +29,42 @@ const (
 	MEM_PRESSURE_THRESHOLD  float32 = 80.0
 	NODE_PRESSURE_THRESHOLD float32 = 66
 )
+
+// PressureThresholds are the usage percentages from which cpu and memory are under pressure.
+type PressureThresholds struct {
+	Cpu    float32
+	Memory float32
+}
+
+var DefaultPressureThresholds = PressureThresholds{
+	Cpu:    CPU_PRESSURE_THRESHOLD,
+	Memory: MEM_PRESSURE_THRESHOLD,
+}
+
+// GetPressureThresholds returns the thresholds of a service, which can override the defaults
+// through annotations.
+func GetPressureThresholds(service servingv1.Service) PressureThresholds {
+	thresholds := DefaultPressureThresholds
+
+	if service.Annotations == nil {
+		return thresholds
+	}
+
+	if value, err := strconv.ParseFloat(service.Annotations[CpuPressureThresholdAnnotation], 32); err == nil && value > 0 {
+		thresholds.Cpu = float32(value)
+	}
+
+	if value, err := strconv.ParseFloat(service.Annotations[MemoryPressureThresholdAnnotation], 32); err == nil && value > 0 {
+		thresholds.Memory = float32(value)
+	}
+
+	return thresholds
+}
+
+func pressureFromPercentage(percentage, threshold float32) Pressure {
+	if percentage >= threshold {
+		return HighPressure
+	}
+
+	return LowPressure
+}
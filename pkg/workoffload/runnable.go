@@ -3,12 +3,16 @@ package workoffload
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	klabels "k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -17,7 +21,12 @@ import (
 	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
 
 	"edge.jevv.dev/pkg/controllers"
+	"edge.jevv.dev/pkg/metrics"
+	"edge.jevv.dev/pkg/workoffload/health"
+	"edge.jevv.dev/pkg/workoffload/history"
+	"edge.jevv.dev/pkg/workoffload/pressure"
 	"edge.jevv.dev/pkg/workoffload/prometheus"
+	"edge.jevv.dev/pkg/workoffload/schedule"
 	"edge.jevv.dev/pkg/workoffload/store"
 	"edge.jevv.dev/pkg/workoffload/strategy"
 )
@@ -37,6 +46,8 @@ func (ko *KServiceListOptions) ApplyToList(o *client.ListOptions) {
 	}
 }
 
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
 type EdgeWorkOffload struct {
 	client.Client
 
@@ -44,10 +55,17 @@ type EdgeWorkOffload struct {
 
 	Log           logr.Logger
 	Envs          []string
-	Store         *store.Store
+	Store         store.TrafficStore
 	PrometheusUrl string
 
-	strategy strategy.WorkOffloadStrategy
+	Recorder record.EventRecorder
+	History  *history.DecisionHistory
+
+	// ProxyHealth is optional, without it traffic is offloaded even if the remote cluster is down
+	ProxyHealth *health.ProxyHealthChecker
+
+	registry         *strategy.Registry
+	exportedServices map[types.NamespacedName]bool
 }
 
 func (t *EdgeWorkOffload) NeedLeaderElection() bool {
@@ -206,10 +224,6 @@ func (t *EdgeWorkOffload) run(ctx context.Context, services []servingv1.Service)
 		return nil
 	}
 
-	if err := t.strategy.Execute(ctx); err != nil {
-		return fmt.Errorf("could not execute strategy: %w", err)
-	}
-
 	// recover what last traffic was set to
 	for _, service := range services {
 		serviceName := types.NamespacedName{Name: service.Name, Namespace: service.Namespace}
@@ -228,7 +242,7 @@ func (t *EdgeWorkOffload) run(ctx context.Context, services []servingv1.Service)
 			previousTrafficStr := service.Annotations[controllers.EdgeProxyTrafficAnnotation]
 			previousTraffic, err := strconv.ParseInt(previousTrafficStr, 10, 64)
 
-			if err != nil {
+			if err == nil {
 				traffic = previousTraffic
 			}
 		}
@@ -236,7 +250,9 @@ func (t *EdgeWorkOffload) run(ctx context.Context, services []servingv1.Service)
 		t.Store.Set(serviceName.String(), traffic)
 	}
 
-	for _, result := range t.strategy.GetResults(services) {
+	results, err := t.getResults(ctx, services)
+
+	for _, result := range results {
 		// TODO: check if service has traffic enabled (might not matter)
 
 		traffic, exists := t.Store.Get(result.Name.String())
@@ -246,9 +262,15 @@ func (t *EdgeWorkOffload) run(ctx context.Context, services []servingv1.Service)
 			traffic = 0
 		}
 
+		previousTraffic := traffic
+		inputs := result.Inputs
+
+		if inputs == nil {
+			inputs = make(map[string]string)
+		}
+
 		switch result.Action {
 		case strategy.PreserveTraffic:
-			continue
 		case strategy.SetTraffic:
 			inertia := strategy.TrafficInertiaDefaultValue
 			annotations := result.Service.Annotations
@@ -263,6 +285,7 @@ func (t *EdgeWorkOffload) run(ctx context.Context, services []servingv1.Service)
 				}
 			}
 
+			inputs["inertia"] = strategy.FormatInput(inertia)
 			traffic = int64(float32(traffic)*inertia + float32(result.DesiredTraffic)*(1-inertia))
 		case strategy.IncreaseTraffic:
 			traffic += 10
@@ -276,11 +299,189 @@ func (t *EdgeWorkOffload) run(ctx context.Context, services []servingv1.Service)
 			traffic = 0
 		}
 
+		if !t.isRemoteReachable(ctx, result) {
+			// nothing to offload to, keep all the traffic until the proxies can reach the remote again
+			inputs["remote"] = "unreachable"
+			traffic = 0
+		}
+
+		t.recordDecision(result, inputs, previousTraffic, traffic)
+		metrics.OffloadTraffic.WithLabelValues(result.Name.Namespace, result.Name.Name).Set(float64(traffic))
+
+		if result.Action == strategy.PreserveTraffic && traffic == previousTraffic {
+			continue
+		}
+
 		t.Store.Set(result.Name.String(), traffic)
 		debug.Info("debug results", "name", result.Name, "action", result.Action, "traffic", traffic)
 	}
 
-	return nil
+	if t.History != nil {
+		serviceNames := make(map[string]bool, len(services))
+
+		for _, service := range services {
+			serviceNames[types.NamespacedName{Name: service.Name, Namespace: service.Namespace}.String()] = true
+		}
+
+		t.History.Forget(serviceNames)
+	}
+
+	t.forgetRemovedServices(services)
+
+	t.Store.SetLastRun(time.Now())
+
+	return err
+}
+
+func (t *EdgeWorkOffload) isRemoteReachable(ctx context.Context, result strategy.WorkOffloadServiceResult) bool {
+	if t.ProxyHealth == nil || result.Service == nil {
+		return true
+	}
+
+	reachable, err := t.ProxyHealth.IsRemoteReachable(ctx, result.Service)
+
+	if err != nil {
+		t.Log.V(controllers.DebugLevel).Error(err, "Couldn't check if the remote cluster is reachable.", "name", result.Name)
+	}
+
+	if reachable {
+		metrics.OffloadRemoteReachable.WithLabelValues(result.Name.Namespace, result.Name.Name).Set(1)
+	} else {
+		metrics.OffloadRemoteReachable.WithLabelValues(result.Name.Namespace, result.Name.Name).Set(0)
+	}
+
+	return reachable
+}
+
+// forgetRemovedServices stops exporting the traffic of services which are no longer offloaded, and
+// removes it from the store, so the other replicas don't keep it either.
+func (t *EdgeWorkOffload) forgetRemovedServices(services []servingv1.Service) {
+	current := make(map[types.NamespacedName]bool, len(services))
+
+	for _, service := range services {
+		current[types.NamespacedName{Name: service.Name, Namespace: service.Namespace}] = true
+	}
+
+	for serviceName := range t.exportedServices {
+		if !current[serviceName] {
+			metrics.OffloadTraffic.DeleteLabelValues(serviceName.Namespace, serviceName.Name)
+			metrics.OffloadRemoteReachable.DeleteLabelValues(serviceName.Namespace, serviceName.Name)
+			t.Store.Delete(serviceName.String())
+		}
+	}
+
+	t.exportedServices = current
+}
+
+func (t *EdgeWorkOffload) recordDecision(result strategy.WorkOffloadServiceResult, inputs map[string]string, previousTraffic, traffic int64) {
+	if t.History != nil {
+		t.History.Record(history.Decision{
+			Timestamp:       time.Now(),
+			Service:         result.Name.String(),
+			Strategy:        result.Strategy,
+			Action:          result.Action.String(),
+			Inputs:          inputs,
+			PreviousTraffic: previousTraffic,
+			DesiredTraffic:  result.DesiredTraffic,
+			Traffic:         traffic,
+		})
+	}
+
+	// only changes are worth an event, otherwise there would be one every run
+	if t.Recorder == nil || result.Service == nil || previousTraffic == traffic {
+		return
+	}
+
+	keys := make([]string, 0, len(inputs))
+
+	for key := range inputs {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	details := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		details = append(details, fmt.Sprintf("%s=%s", key, inputs[key]))
+	}
+
+	t.Recorder.Eventf(
+		result.Service, corev1.EventTypeNormal, "TrafficOffloadChanged",
+		"Offloaded traffic changed from %d%% to %d%% (strategy: %s, action: %s, inputs: %s)",
+		previousTraffic, traffic, result.Strategy, result.Action, strings.Join(details, " "),
+	)
+}
+
+// getResults runs each strategy selected by at least one service, and only for those services.
+// A failing strategy doesn't prevent the others from updating their services.
+func (t *EdgeWorkOffload) getResults(ctx context.Context, services []servingv1.Service) ([]strategy.WorkOffloadServiceResult, error) {
+	debug := t.Log.V(controllers.DebugLevel)
+
+	var failed []string
+	results := make([]strategy.WorkOffloadServiceResult, 0, len(services))
+
+	for name, group := range t.registry.GroupByStrategy(services) {
+		s, exists := t.registry.Get(name)
+
+		if !exists {
+			debug.Info("unknown strategy, traffic will be preserved", "strategy", name, "services", len(group))
+			continue
+		}
+
+		startTime := time.Now()
+		err := s.Execute(ctx)
+		metrics.StrategyDuration.WithLabelValues(name).Observe(time.Since(startTime).Seconds())
+
+		if err != nil {
+			t.Log.Error(err, "Couldn't execute work offload strategy.", "strategy", name)
+			metrics.StrategyErrors.WithLabelValues(name).Inc()
+			failed = append(failed, name)
+			continue
+		}
+
+		for _, result := range s.GetResults(group) {
+			result.Strategy = name
+			results = append(results, result)
+		}
+	}
+
+	if len(failed) > 0 {
+		return results, fmt.Errorf("could not execute strategies: %v", failed)
+	}
+
+	return results, nil
+}
+
+func (t *EdgeWorkOffload) buildRegistry() (*strategy.Registry, error) {
+	registry := strategy.NewRegistry(strategy.DefaultStrategyName)
+
+	latencyRatio, err := prometheus.NewStrategy(t.Log, t.PrometheusUrl, t.Client, t.MetricsClient)
+
+	if err != nil {
+		return nil, err
+	}
+
+	requestRate, err := prometheus.NewRequestRateStrategy(t.Log, t.PrometheusUrl)
+
+	if err != nil {
+		return nil, err
+	}
+
+	strategies := map[string]strategy.WorkOffloadStrategy{
+		strategy.LatencyRatioStrategyName:     latencyRatio,
+		strategy.RequestRateStrategyName:      requestRate,
+		strategy.ResourcePressureStrategyName: pressure.NewResourcePressureStrategy(t.Log, t.Client, t.MetricsClient),
+		strategy.FixedScheduleStrategyName:    schedule.NewFixedScheduleStrategy(t.Log),
+	}
+
+	for name, s := range strategies {
+		if err := registry.Register(name, s); err != nil {
+			return nil, err
+		}
+	}
+
+	return registry, nil
 }
 
 func (t *EdgeWorkOffload) Start(ctx context.Context) error {
@@ -291,10 +492,12 @@ func (t *EdgeWorkOffload) Start(ctx context.Context) error {
 
 	log.Info("Starting edge traffic runnable.")
 
-	if t.strategy, err = prometheus.NewStrategy(t.Log, t.PrometheusUrl, t.Client, t.MetricsClient); err != nil {
+	if t.registry, err = t.buildRegistry(); err != nil {
 		return err
 	}
 
+	debug.Info("registered work offload strategies", "strategies", t.registry.Names())
+
 	if t.Store == nil {
 		return fmt.Errorf("no traffic split store provided")
 	}
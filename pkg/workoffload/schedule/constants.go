@@ -0,0 +1,12 @@
+package schedule
+
+const (
+	// ScheduleAnnotation holds comma separated windows of the form HH:MM-HH:MM=traffic, for example
+	// "08:00-18:00=60,18:00-23:00=20". Windows may wrap around midnight.
+	ScheduleAnnotation         = "strategy.edge.jevv.dev/schedule"
+	ScheduleTimezoneAnnotation = "strategy.edge.jevv.dev/schedule-timezone"
+	ScheduleDefaultAnnotation  = "strategy.edge.jevv.dev/schedule-default-traffic"
+
+	ScheduleTimezoneAnnotationDefaultValue       = "UTC"
+	ScheduleDefaultAnnotationDefaultValue  int64 = 0
+)
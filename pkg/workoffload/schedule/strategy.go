@@ -0,0 +1,203 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"k8s.io/apimachinery/pkg/types"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+
+	"edge.jevv.dev/pkg/controllers"
+	"edge.jevv.dev/pkg/workoffload/strategy"
+)
+
+// FixedScheduleStrategy offloads a fixed share of the traffic depending on the time of day, as
+// configured in the annotations of each service.
+type FixedScheduleStrategy struct {
+	Log logr.Logger
+
+	now time.Time
+}
+
+type window struct {
+	start   time.Duration
+	end     time.Duration
+	traffic int64
+}
+
+func (w window) contains(t time.Duration) bool {
+	if w.start <= w.end {
+		return t >= w.start && t < w.end
+	}
+
+	// wraps around midnight
+	return t >= w.start || t < w.end
+}
+
+func NewFixedScheduleStrategy(log logr.Logger) *FixedScheduleStrategy {
+	return &FixedScheduleStrategy{Log: log.WithName("fixed-schedule")}
+}
+
+func (s *FixedScheduleStrategy) Execute(ctx context.Context) error {
+	s.now = time.Now()
+	return nil
+}
+
+func (s *FixedScheduleStrategy) GetResults(services []servingv1.Service) []strategy.WorkOffloadServiceResult {
+	debug := s.Log.V(controllers.DebugLevel)
+
+	ret := make([]strategy.WorkOffloadServiceResult, 0, len(services))
+
+	for i := range services {
+		service := &services[i]
+		serviceName := types.NamespacedName{Name: service.Name, Namespace: service.Namespace}
+
+		result := strategy.WorkOffloadServiceResult{
+			Name:           serviceName,
+			Service:        service,
+			Action:         strategy.PreserveTraffic,
+			DesiredTraffic: -1,
+		}
+
+		if service.Annotations != nil {
+			result.Inputs = map[string]string{
+				"schedule":         service.Annotations[ScheduleAnnotation],
+				"scheduleTimezone": service.Annotations[ScheduleTimezoneAnnotation],
+			}
+		}
+
+		if traffic, err := s.getScheduledTraffic(service); err != nil {
+			debug.Info("invalid schedule, traffic will be preserved", "service", serviceName, "error", err.Error())
+		} else {
+			result.Action = strategy.SetTraffic
+			result.DesiredTraffic = traffic
+		}
+
+		ret = append(ret, result)
+	}
+
+	return ret
+}
+
+func (s *FixedScheduleStrategy) getScheduledTraffic(service *servingv1.Service) (int64, error) {
+	return scheduledTraffic(service.Annotations, s.now)
+}
+
+// ValidateAnnotations checks the schedule annotations of a service the same way they're read when
+// the traffic is scheduled.
+func ValidateAnnotations(annotations map[string]string) error {
+	if value := annotations[ScheduleDefaultAnnotation]; value != "" {
+		if traffic, err := strconv.ParseInt(value, 10, 64); err == nil && (traffic < 0 || traffic > 100) {
+			return fmt.Errorf("default traffic %d isn't between 0 and 100", traffic)
+		}
+	}
+
+	_, err := scheduledTraffic(annotations, time.Now())
+
+	return err
+}
+
+func scheduledTraffic(annotations map[string]string, now time.Time) (int64, error) {
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	timezone := ScheduleTimezoneAnnotationDefaultValue
+
+	if value := annotations[ScheduleTimezoneAnnotation]; value != "" {
+		timezone = value
+	}
+
+	location, err := time.LoadLocation(timezone)
+
+	if err != nil {
+		return 0, fmt.Errorf("couldn't load timezone %s: %w", timezone, err)
+	}
+
+	traffic := ScheduleDefaultAnnotationDefaultValue
+
+	if value := annotations[ScheduleDefaultAnnotation]; value != "" {
+		if traffic, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return 0, fmt.Errorf("couldn't parse default traffic: %w", err)
+		}
+	}
+
+	windows, err := parseSchedule(annotations[ScheduleAnnotation])
+
+	if err != nil {
+		return 0, err
+	}
+
+	now = now.In(location)
+	timeOfDay := time.Duration(now.Hour())*time.Hour + time.Duration(now.Minute())*time.Minute
+
+	// first matching window wins
+	for _, w := range windows {
+		if w.contains(timeOfDay) {
+			return w.traffic, nil
+		}
+	}
+
+	return traffic, nil
+}
+
+func parseSchedule(schedule string) ([]window, error) {
+	windows := make([]window, 0)
+
+	for _, entry := range strings.Split(schedule, ",") {
+		entry = strings.TrimSpace(entry)
+
+		if entry == "" {
+			continue
+		}
+
+		span, trafficStr, found := strings.Cut(entry, "=")
+
+		if !found {
+			return nil, fmt.Errorf("schedule entry %q has no traffic", entry)
+		}
+
+		startStr, endStr, found := strings.Cut(span, "-")
+
+		if !found {
+			return nil, fmt.Errorf("schedule entry %q has no end time", entry)
+		}
+
+		start, err := parseTimeOfDay(startStr)
+
+		if err != nil {
+			return nil, err
+		}
+
+		end, err := parseTimeOfDay(endStr)
+
+		if err != nil {
+			return nil, err
+		}
+
+		traffic, err := strconv.ParseInt(strings.TrimSpace(trafficStr), 10, 64)
+
+		if err != nil || traffic < 0 || traffic > 100 {
+			return nil, fmt.Errorf("schedule entry %q has invalid traffic", entry)
+		}
+
+		windows = append(windows, window{start: start, end: end, traffic: traffic})
+	}
+
+	return windows, nil
+}
+
+func parseTimeOfDay(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+
+	if err != nil {
+		return 0, fmt.Errorf("couldn't parse time of day %q: %w", value, err)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
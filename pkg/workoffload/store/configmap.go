@@ -0,0 +1,225 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"edge.jevv.dev/pkg/controllers"
+)
+
+// changes are written to (or read from) the config map every 10 seconds
+const ConfigMapStoreSyncPeriod = time.Second * 10
+
+const ConfigMapStoreDataKey = "store.json"
+
+type storeContent struct {
+	LastRun time.Time            `json:"lastRun"`
+	Items   map[string]storeItem `json:"items"`
+	Deleted map[string]time.Time `json:"deleted,omitempty"`
+}
+
+// ConfigMapStore is a TrafficStore persisted in a ConfigMap, so the traffic split survives
+// restarts and leader failover, and is shared between replicas. Replicas write their changes
+// and read the changes of the others periodically.
+type ConfigMapStore struct {
+	Store
+
+	Client client.Client
+	// the manager cache only sees managed objects
+	Reader client.Reader
+
+	Name types.NamespacedName
+
+	dirty     bool
+	dirtyLock sync.Mutex
+}
+
+func (s *ConfigMapStore) NeedLeaderElection() bool {
+	return false
+}
+
+func (s *ConfigMapStore) Start(ctx context.Context) error {
+	log := s.Log.V(controllers.InfoLevel)
+
+	if s.Client == nil || s.Reader == nil {
+		return fmt.Errorf("no client provided for config map store")
+	}
+
+	if err := s.Store.Start(ctx); err != nil {
+		return err
+	}
+
+	// not fatal, we'll try again on the next sync
+	if err := s.load(ctx); err != nil {
+		s.Log.Error(err, "Couldn't load traffic split store.", "configmap", s.Name.String())
+	} else {
+		log.Info("Loaded traffic split store.", "configmap", s.Name.String())
+	}
+
+	go func() {
+		for {
+			timeoutCtx, timeoutCancel := context.WithTimeout(context.Background(), ConfigMapStoreSyncPeriod)
+
+			select {
+			case <-timeoutCtx.Done():
+				timeoutCancel() // not sure if necessary
+			case <-ctx.Done():
+				timeoutCancel()
+
+				// last chance to persist our changes
+				flushCtx, flushCancel := context.WithTimeout(context.Background(), ConfigMapStoreSyncPeriod)
+				defer flushCancel()
+
+				if err := s.sync(flushCtx); err != nil {
+					s.Log.Error(err, "Couldn't persist traffic split store.", "configmap", s.Name.String())
+				}
+
+				return
+			}
+
+			if err := s.sync(ctx); err != nil {
+				s.Log.Error(err, "Couldn't sync traffic split store.", "configmap", s.Name.String())
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (s *ConfigMapStore) Set(key string, value int64) {
+	s.Store.Set(key, value)
+	s.markDirty(true)
+}
+
+func (s *ConfigMapStore) Delete(key string) {
+	s.Store.Delete(key)
+	s.markDirty(true)
+}
+
+func (s *ConfigMapStore) SetLastRun(timestamp time.Time) {
+	s.Store.SetLastRun(timestamp)
+	s.markDirty(true)
+}
+
+func (s *ConfigMapStore) markDirty(dirty bool) bool {
+	s.dirtyLock.Lock()
+	defer s.dirtyLock.Unlock()
+
+	wasDirty := s.dirty
+	s.dirty = dirty
+
+	return wasDirty
+}
+
+// sync writes the store if it changed, otherwise it reads the changes made by other replicas.
+func (s *ConfigMapStore) sync(ctx context.Context) error {
+	if !s.markDirty(false) {
+		return s.load(ctx)
+	}
+
+	if err := s.save(ctx); err != nil {
+		// try again next time
+		s.markDirty(true)
+		return err
+	}
+
+	return nil
+}
+
+func (s *ConfigMapStore) load(ctx context.Context) error {
+	var configMap corev1.ConfigMap
+
+	if err := s.Reader.Get(ctx, s.Name, &configMap); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+
+		return fmt.Errorf("couldn't get config map: %w", err)
+	}
+
+	content, err := decodeContent(&configMap)
+
+	if err != nil {
+		return err
+	}
+
+	s.merge(content)
+
+	return nil
+}
+
+func (s *ConfigMapStore) save(ctx context.Context) error {
+	debug := s.Log.V(controllers.DebugLevel)
+
+	var configMap corev1.ConfigMap
+
+	shouldCreate := false
+
+	if err := s.Reader.Get(ctx, s.Name, &configMap); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("couldn't get config map: %w", err)
+		}
+
+		shouldCreate = true
+	}
+
+	// don't overwrite more recent changes from other replicas
+	if !shouldCreate {
+		content, err := decodeContent(&configMap)
+
+		if err != nil {
+			return err
+		}
+
+		s.merge(content)
+	}
+
+	data, err := json.Marshal(s.export())
+
+	if err != nil {
+		return fmt.Errorf("couldn't encode traffic split store: %w", err)
+	}
+
+	configMap.Name = s.Name.Name
+	configMap.Namespace = s.Name.Namespace
+	configMap.Data = map[string]string{ConfigMapStoreDataKey: string(data)}
+
+	debug.Info("persisting traffic split store", "configmap", s.Name.String(), "size", len(data))
+
+	if shouldCreate {
+		err = s.Client.Create(ctx, &configMap)
+	} else {
+		err = s.Client.Update(ctx, &configMap)
+	}
+
+	if err != nil {
+		return fmt.Errorf("couldn't write config map: %w", err)
+	}
+
+	return nil
+}
+
+func decodeContent(configMap *corev1.ConfigMap) (storeContent, error) {
+	var content storeContent
+
+	data, exists := configMap.Data[ConfigMapStoreDataKey]
+
+	if !exists || data == "" {
+		return content, nil
+	}
+
+	if err := json.Unmarshal([]byte(data), &content); err != nil {
+		return content, fmt.Errorf("couldn't decode traffic split store: %w", err)
+	}
+
+	return content, nil
+}
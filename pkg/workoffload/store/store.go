@@ -16,20 +16,29 @@ const StoreCleanupPeriod = time.Minute * 30
 // retain items for max 1 hour
 const StoreMaxItemTtl = time.Hour
 
+// keep the last 10 changes of each item
+const StoreMaxItemHistory = 10
+
+// Store is an in-memory TrafficStore, which is lost when the controller restarts.
 type Store struct {
 	manager.Runnable
 	manager.LeaderElectionRunnable
 
 	Log logr.Logger
 
-	data map[string]storeItem
-	lock sync.Mutex
+	data    map[string]storeItem
+	lastRun time.Time
+	lock    sync.Mutex
+
+	// when each removed key was deleted, so merging an older copy of it doesn't bring it back
+	deleted map[string]time.Time
 }
 
 type storeItem struct {
-	Timestamp time.Time
-	Key       string
-	Value     int64
+	Timestamp time.Time     `json:"timestamp"`
+	Key       string        `json:"key"`
+	Value     int64         `json:"value"`
+	History   []HistoryItem `json:"history,omitempty"`
 }
 
 func (s *Store) NeedLeaderElection() bool {
@@ -48,7 +57,7 @@ func (s *Store) cleanUp() {
 	now := time.Now()
 
 	for key, item := range s.data {
-		if item.Timestamp.Add(StoreMaxItemTtl).After(now) {
+		if !isExpired(item.Timestamp, now) {
 			continue
 		}
 
@@ -59,13 +68,31 @@ func (s *Store) cleanUp() {
 		delete(s.data, key)
 	}
 
+	// copies older than the deletion are expired too by now, and merge skips them
+	for key, timestamp := range s.deleted {
+		if isExpired(timestamp, now) {
+			delete(s.deleted, key)
+		}
+	}
+
 	debug.Info("finished cleaning up data store", "keysRemoved", len(keysToRemove))
 }
 
-func (s *Store) Start(ctx context.Context) error {
+func isExpired(timestamp time.Time, now time.Time) bool {
+	return !timestamp.Add(StoreMaxItemTtl).After(now)
+}
+
+func (s *Store) init() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
 	if s.data == nil {
 		s.data = make(map[string]storeItem)
 	}
+}
+
+func (s *Store) Start(ctx context.Context) error {
+	s.init()
 
 	go func() {
 		for {
@@ -94,13 +121,45 @@ func (s *Store) Set(key string, value int64) {
 
 	debug.Info("updating data store item", "key", key, "value", value)
 
+	now := time.Now()
+	previous, exists := s.data[key]
+
 	item := storeItem{
-		Timestamp: time.Now(),
+		Timestamp: now,
 		Key:       key,
 		Value:     value,
+		History:   previous.History,
+	}
+
+	// only record changes
+	if !exists || previous.Value != value {
+		item.History = append(item.History, HistoryItem{Timestamp: now, Value: value})
+
+		if len(item.History) > StoreMaxItemHistory {
+			item.History = item.History[len(item.History)-StoreMaxItemHistory:]
+		}
 	}
 
 	s.data[key] = item
+	delete(s.deleted, key)
+}
+
+// Delete removes the key, e.g. when its service is deleted.
+func (s *Store) Delete(key string) {
+	debug := s.Log.V(controllers.DebugLevel)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	debug.Info("deleting data store item", "key", key)
+
+	delete(s.data, key)
+
+	if s.deleted == nil {
+		s.deleted = make(map[string]time.Time)
+	}
+
+	s.deleted[key] = time.Now()
 }
 
 func (s *Store) Get(key string) (int64, bool) {
@@ -128,3 +187,118 @@ func (s *Store) GetLastUpdateTimestamp(key string) (time.Time, bool) {
 
 	return item.Timestamp, true
 }
+
+func (s *Store) Snapshot() map[string]int64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	snapshot := make(map[string]int64, len(s.data))
+
+	for key, item := range s.data {
+		snapshot[key] = item.Value
+	}
+
+	return snapshot
+}
+
+func (s *Store) GetHistory(key string) []HistoryItem {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	item, exists := s.data[key]
+
+	if !exists {
+		return nil
+	}
+
+	history := make([]HistoryItem, len(item.History))
+	copy(history, item.History)
+
+	return history
+}
+
+func (s *Store) GetLastRun() (time.Time, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.lastRun, !s.lastRun.IsZero()
+}
+
+func (s *Store) SetLastRun(timestamp time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.lastRun = timestamp
+}
+
+// export returns a copy of the store content.
+func (s *Store) export() storeContent {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	content := storeContent{
+		LastRun: s.lastRun,
+		Items:   make(map[string]storeItem, len(s.data)),
+		Deleted: make(map[string]time.Time, len(s.deleted)),
+	}
+
+	for key, item := range s.data {
+		content.Items[key] = item
+	}
+
+	for key, timestamp := range s.deleted {
+		content.Deleted[key] = timestamp
+	}
+
+	return content
+}
+
+// merge adds the items from another store, keeping the most recent ones. Items which expired or
+// were deleted after their last change, on either side, aren't merged back.
+func (s *Store) merge(content storeContent) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.data == nil {
+		s.data = make(map[string]storeItem)
+	}
+
+	if s.deleted == nil {
+		s.deleted = make(map[string]time.Time)
+	}
+
+	if content.LastRun.After(s.lastRun) {
+		s.lastRun = content.LastRun
+	}
+
+	now := time.Now()
+
+	for key, timestamp := range content.Deleted {
+		if isExpired(timestamp, now) || !timestamp.After(s.deleted[key]) {
+			continue
+		}
+
+		s.deleted[key] = timestamp
+
+		if current, exists := s.data[key]; exists && !current.Timestamp.After(timestamp) {
+			delete(s.data, key)
+		}
+	}
+
+	for key, item := range content.Items {
+		if isExpired(item.Timestamp, now) {
+			continue
+		}
+
+		if deletedAt, deleted := s.deleted[key]; deleted && !item.Timestamp.After(deletedAt) {
+			continue
+		}
+
+		if current, exists := s.data[key]; exists && !item.Timestamp.After(current.Timestamp) {
+			continue
+		}
+
+		item.Key = key
+		s.data[key] = item
+	}
+}
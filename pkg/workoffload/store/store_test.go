@@ -0,0 +1,115 @@
+package store
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/go-logr/logr"
+)
+
+var _ = Describe("traffic split store", func() {
+	var s *Store
+
+	// the entries are built with the tree, so they share the same time
+	now := time.Now()
+
+	BeforeEach(func() {
+		s = &Store{Log: logr.Discard()}
+		s.init()
+	})
+
+	item := func(value int64, age time.Duration) storeItem {
+		return storeItem{Timestamp: now.Add(-age), Value: value}
+	}
+
+	DescribeTable("merging the content of another replica",
+		func(local map[string]storeItem, deleted map[string]time.Duration, content storeContent, expected map[string]int64) {
+			for key, item := range local {
+				s.data[key] = item
+			}
+
+			for key, age := range deleted {
+				if s.deleted == nil {
+					s.deleted = make(map[string]time.Time)
+				}
+
+				s.deleted[key] = now.Add(-age)
+			}
+
+			s.merge(content)
+
+			Expect(s.Snapshot()).To(Equal(expected))
+		},
+		Entry("adds new items", nil, nil,
+			storeContent{Items: map[string]storeItem{"default/a": item(10, time.Minute)}},
+			map[string]int64{"default/a": 10}),
+		Entry("keeps the most recent item",
+			map[string]storeItem{"default/a": item(10, time.Minute), "default/b": item(20, 5*time.Minute)}, nil,
+			storeContent{Items: map[string]storeItem{"default/a": item(30, 2*time.Minute), "default/b": item(40, time.Minute)}},
+			map[string]int64{"default/a": 10, "default/b": 40}),
+		Entry("skips expired items", nil, nil,
+			storeContent{Items: map[string]storeItem{"default/a": item(10, StoreMaxItemTtl+time.Minute)}},
+			map[string]int64{}),
+		Entry("skips items deleted after their last change", nil,
+			map[string]time.Duration{"default/a": time.Minute},
+			storeContent{Items: map[string]storeItem{"default/a": item(10, 2*time.Minute)}},
+			map[string]int64{}),
+		Entry("adds items changed after their deletion", nil,
+			map[string]time.Duration{"default/a": 2 * time.Minute},
+			storeContent{Items: map[string]storeItem{"default/a": item(10, time.Minute)}},
+			map[string]int64{"default/a": 10}),
+		Entry("deletes items deleted by another replica",
+			map[string]storeItem{"default/a": item(10, 2*time.Minute), "default/b": item(20, time.Minute)}, nil,
+			storeContent{Deleted: map[string]time.Time{"default/a": now.Add(-time.Minute), "default/b": now.Add(-2 * time.Minute)}},
+			map[string]int64{"default/b": 20}),
+	)
+
+	It("doesn't bring back deleted items when merging them again", func() {
+		s.Set("default/a", 10)
+		content := s.export()
+
+		s.Delete("default/a")
+		s.merge(content)
+
+		_, exists := s.Get("default/a")
+		Expect(exists).To(BeFalse())
+		Expect(s.export().Deleted).To(HaveKey("default/a"))
+
+		s.Set("default/a", 20)
+
+		value, exists := s.Get("default/a")
+		Expect(exists).To(BeTrue())
+		Expect(value).To(Equal(int64(20)))
+		Expect(s.export().Deleted).NotTo(HaveKey("default/a"))
+	})
+
+	It("cleans up expired items and deletions", func() {
+		s.data["default/a"] = item(10, StoreMaxItemTtl+time.Minute)
+		s.data["default/b"] = item(20, StoreMaxItemTtl-time.Minute)
+		s.deleted = map[string]time.Time{
+			"default/c": now.Add(-StoreMaxItemTtl - time.Minute),
+			"default/d": now.Add(-time.Minute),
+		}
+
+		s.cleanUp()
+
+		Expect(s.Snapshot()).To(Equal(map[string]int64{"default/b": 20}))
+		Expect(s.deleted).To(HaveLen(1))
+		Expect(s.deleted).To(HaveKey("default/d"))
+	})
+
+	It("records only the last changes in the history", func() {
+		for i := 0; i < StoreMaxItemHistory+5; i++ {
+			s.Set("default/a", int64(i))
+			s.Set("default/a", int64(i))
+		}
+
+		history := s.GetHistory("default/a")
+
+		Expect(history).To(HaveLen(StoreMaxItemHistory))
+		Expect(history[0].Value).To(Equal(int64(5)))
+		Expect(history[StoreMaxItemHistory-1].Value).To(Equal(int64(StoreMaxItemHistory + 4)))
+	})
+})
@@ -0,0 +1,31 @@
+package store
+
+import (
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// TrafficStore keeps the traffic split of each service, as computed by the work offload runnable
+// and applied by the Knative Service reconciler.
+type TrafficStore interface {
+	manager.Runnable
+
+	Get(key string) (int64, bool)
+	Set(key string, value int64)
+	Delete(key string)
+	GetLastUpdateTimestamp(key string) (time.Time, bool)
+	GetHistory(key string) []HistoryItem
+	Snapshot() map[string]int64
+
+	GetLastRun() (time.Time, bool)
+	SetLastRun(timestamp time.Time)
+}
+
+type HistoryItem struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     int64     `json:"value"`
+}
+
+var _ TrafficStore = &Store{}
+var _ TrafficStore = &ConfigMapStore{}
@@ -6,4 +6,13 @@ const (
 
 	TrafficInertiaAnnotation           = "strategy.edge.jevv.dev/traffic-inertia"
 	TrafficInertiaDefaultValue float32 = 0.75
+
+	StrategyNameAnnotation = "strategy.edge.jevv.dev/name"
+
+	LatencyRatioStrategyName     = "latency-ratio"
+	ResourcePressureStrategyName = "resource-pressure"
+	RequestRateStrategyName      = "request-rate"
+	FixedScheduleStrategyName    = "fixed-schedule"
+
+	DefaultStrategyName = LatencyRatioStrategyName
 )
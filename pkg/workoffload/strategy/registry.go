@@ -0,0 +1,76 @@
+package strategy
+
+import (
+	"fmt"
+	"sort"
+
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+)
+
+// Registry holds the named work offload strategies, so each Knative Service can pick its own
+// through the strategy.edge.jevv.dev/name annotation.
+type Registry struct {
+	defaultName string
+	strategies  map[string]WorkOffloadStrategy
+}
+
+func NewRegistry(defaultName string) *Registry {
+	return &Registry{
+		defaultName: defaultName,
+		strategies:  make(map[string]WorkOffloadStrategy),
+	}
+}
+
+func (r *Registry) Register(name string, strategy WorkOffloadStrategy) error {
+	if name == "" {
+		return fmt.Errorf("strategy name is empty")
+	}
+
+	if _, exists := r.strategies[name]; exists {
+		return fmt.Errorf("strategy %s is already registered", name)
+	}
+
+	r.strategies[name] = strategy
+
+	return nil
+}
+
+func (r *Registry) Get(name string) (WorkOffloadStrategy, bool) {
+	strategy, exists := r.strategies[name]
+	return strategy, exists
+}
+
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.strategies))
+
+	for name := range r.strategies {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// NameFor returns the name of the strategy selected by the service, or the default one.
+func (r *Registry) NameFor(service servingv1.Service) string {
+	if service.Annotations != nil {
+		if name := service.Annotations[StrategyNameAnnotation]; name != "" {
+			return name
+		}
+	}
+
+	return r.defaultName
+}
+
+// GroupByStrategy splits services by the name of the strategy they selected.
+func (r *Registry) GroupByStrategy(services []servingv1.Service) map[string][]servingv1.Service {
+	groups := make(map[string][]servingv1.Service)
+
+	for _, service := range services {
+		name := r.NameFor(service)
+		groups[name] = append(groups[name], service)
+	}
+
+	return groups
+}
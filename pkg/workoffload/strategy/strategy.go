@@ -2,6 +2,7 @@ package strategy
 
 import (
 	"context"
+	"strconv"
 
 	"k8s.io/apimachinery/pkg/types"
 
@@ -38,9 +39,18 @@ type WorkOffloadServiceResult struct {
 
 	Action         TrafficAction
 	DesiredTraffic int64
+
+	// set by the runnable, from the name the strategy is registered with
+	Strategy string
+	// what led to the action, to explain it later
+	Inputs map[string]string
 }
 
 type WorkOffloadStrategy interface {
 	Execute(ctx context.Context) error
 	GetResults(services []servingv1.Service) []WorkOffloadServiceResult
 }
+
+func FormatInput(value float32) string {
+	return strconv.FormatFloat(float64(value), 'f', 3, 32)
+}